@@ -0,0 +1,78 @@
+// Command membership-repair reports transactions whose source or targets are not members of the transaction's project.
+// Each project to repair has to be confirmed with -fix, its missing members are added as former members,
+// so they don't get access to the project. Once no violations are left the membership foreign keys are validated.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/diezfx/split-app-backend/internal/config"
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/diezfx/split-app-backend/pkg/logger"
+	"github.com/diezfx/split-app-backend/pkg/postgres"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+func main() {
+	var fix []uuid.UUID
+	flag.Func("fix", "id of a project whose missing members are added as former members, can be repeated", func(value string) error {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return err
+		}
+		fix = append(fix, id)
+		return nil
+	})
+	flag.Parse()
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal(ctx, err).Msg("read config")
+	}
+	if cfg.Storage != config.PostgresStorage {
+		logger.Fatal(ctx, fmt.Errorf("storage backend %q", cfg.Storage)).Msg("only the postgres storage can be repaired")
+	}
+
+	psqlClient, err := postgres.New(cfg.DB)
+	if err != nil {
+		logger.Fatal(ctx, err).Msg("create postgres client")
+	}
+	storageClient, err := storage.New(ctx, psqlClient)
+	if err != nil {
+		logger.Fatal(ctx, err).Msg("create storage client")
+	}
+
+	violations, err := storageClient.GetMembershipViolations(ctx)
+	if err != nil {
+		logger.Fatal(ctx, err).Msg("get membership violations")
+	}
+	for _, v := range violations {
+		fmt.Printf("project %s transaction %s: %s %s is not a member\n", v.ProjectID, v.TransactionID, v.Role, v.UserID)
+	}
+	fmt.Printf("%d violations found\n", len(violations))
+
+	if len(fix) == 0 {
+		if len(violations) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	for _, id := range fix {
+		if !slices.ContainsFunc(violations, func(v storage.MembershipViolation) bool { return v.ProjectID == id }) {
+			logger.Fatal(ctx, fmt.Errorf("project %s", id)).Msg("project to fix has no violations")
+		}
+	}
+	added, validated, err := storageClient.RepairMembershipViolations(ctx, fix)
+	if err != nil {
+		logger.Fatal(ctx, err).Msg("repair membership violations")
+	}
+	fmt.Printf("%d former memberships added\n", added)
+	if !validated {
+		fmt.Println("violations are left in other projects, the membership foreign keys are not validated yet")
+	}
+}
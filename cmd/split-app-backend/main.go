@@ -2,19 +2,44 @@ package main
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/diezfx/split-app-backend/internal/setup"
 	"github.com/diezfx/split-app-backend/pkg/logger"
 )
 
+// shutdownTimeout is how long running requests may take after a shutdown signal.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
-	srv, err := setup.SetupSplitService()
+	// cancelled on shutdown, stops the background jobs of the service
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv, err := setup.SetupSplitService(ctx)
 	if err != nil {
 		logger.Fatal(context.Background(), err).Msg("failed setup")
 	}
 
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error(shutdownCtx, err).Msg("failed shutdown")
+		}
+	}()
+
 	err = srv.ListenAndServe()
-	if err != nil {
+	if !errors.Is(err, http.ErrServerClosed) {
 		logger.Fatal(context.Background(), err).Msg("failed listening and serve")
 	}
+	<-shutdownDone
 }
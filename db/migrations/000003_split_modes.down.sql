@@ -0,0 +1,3 @@
+alter table transaction_targets drop column weight;
+alter table transactions drop column split_mode;
+drop type split_mode;
@@ -0,0 +1,12 @@
+create type split_mode as enum (
+  'Equal',
+  'Shares',
+  'Percentage',
+  'Exact'
+);
+
+alter table transactions
+    add column split_mode split_mode not null default 'Equal';
+
+alter table transaction_targets
+    add column weight INTEGER not null default 1;
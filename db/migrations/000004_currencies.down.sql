@@ -0,0 +1,3 @@
+drop table exchange_rates;
+alter table transactions drop column currency;
+alter table projects drop column currency;
@@ -0,0 +1,12 @@
+alter table projects
+    add column currency char(3) not null default 'EUR';
+
+alter table transactions
+    add column currency char(3) not null default 'EUR';
+
+create table if not exists exchange_rates(
+    from_currency char(3) not null,
+    to_currency char(3) not null,
+    rate double precision not null check (rate > 0),
+    primary key(from_currency, to_currency)
+);
@@ -0,0 +1,2 @@
+drop index if exists idx_transactions_project_created_at;
+alter table transactions drop column created_at;
@@ -0,0 +1,4 @@
+alter table transactions
+    add column created_at timestamptz not null default now();
+
+create index if not exists idx_transactions_project_created_at on transactions(project_id, created_at, id);
@@ -0,0 +1,7 @@
+drop index if exists idx_transactions_project_occurred_at;
+create index if not exists idx_transactions_project_created_at on transactions(project_id, created_at, id);
+
+alter table transactions
+    drop column created_by,
+    drop column updated_at,
+    drop column occurred_at;
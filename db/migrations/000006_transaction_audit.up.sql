@@ -0,0 +1,21 @@
+alter table transactions
+    add column occurred_at date,
+    add column updated_at timestamptz not null default now(),
+    add column created_by text;
+
+-- existing transactions are assumed to have happened when they were created by the person who paid
+update transactions
+    set occurred_at = created_at::date,
+        updated_at = created_at,
+        created_by = source_id;
+
+alter table transactions
+    alter column occurred_at set not null,
+    alter column occurred_at set default current_date,
+    alter column created_by set not null,
+    add constraint fk_created_by
+        foreign key(created_by)
+        references members(id);
+
+drop index if exists idx_transactions_project_created_at;
+create index if not exists idx_transactions_project_occurred_at on transactions(project_id, occurred_at, id);
@@ -0,0 +1,11 @@
+create table if not exists idempotency_keys(
+    user_id text not null,
+    key text not null,
+    fingerprint text not null,
+    -- the response is null as long as the request is in progress
+    status_code integer,
+    content_type text,
+    response_body bytea,
+    created_at timestamptz not null default now(),
+    primary key(user_id, key)
+);
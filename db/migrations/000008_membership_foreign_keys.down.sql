@@ -0,0 +1,10 @@
+alter table transaction_targets
+    drop constraint if exists fk_target_membership,
+    drop constraint if exists fk_transaction_project;
+
+alter table transactions
+    drop constraint if exists fk_source_membership,
+    drop constraint if exists uq_transactions_id_project_id;
+
+alter table transaction_targets
+    drop column if exists project_id;
@@ -0,0 +1,33 @@
+-- sources and targets of a transaction have to be members of its project
+alter table transaction_targets
+    add column project_id UUID;
+
+update transaction_targets as tt
+    set project_id = t.project_id
+    from transactions as t
+    where tt.transaction_id = t.id;
+
+alter table transaction_targets
+    alter column project_id set not null;
+
+alter table transactions
+    add constraint uq_transactions_id_project_id unique (id, project_id);
+
+alter table transaction_targets
+    add constraint fk_transaction_project
+        foreign key(transaction_id, project_id)
+        references transactions(id, project_id);
+
+-- existing rows are not validated, so the migration doesn't fail on old data.
+-- The membership-repair command reports and fixes them and validates the constraints afterwards.
+alter table transactions
+    add constraint fk_source_membership
+        foreign key(project_id, source_id)
+        references project_memberships(project_id, user_id)
+        not valid;
+
+alter table transaction_targets
+    add constraint fk_target_membership
+        foreign key(project_id, user_id)
+        references project_memberships(project_id, user_id)
+        not valid;
@@ -0,0 +1,2 @@
+alter table project_memberships
+    drop column if exists left_at;
@@ -0,0 +1,3 @@
+-- members that left a project keep their membership row, their transactions still reference it
+alter table project_memberships
+    add column left_at timestamptz;
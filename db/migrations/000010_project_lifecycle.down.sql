@@ -0,0 +1,24 @@
+alter table transaction_targets
+    drop constraint fk_transaction_project,
+    add constraint fk_transaction_project
+        foreign key(transaction_id, project_id)
+        references transactions(id, project_id),
+    drop constraint fk_transaction_id,
+    add constraint fk_transaction_id
+        foreign key(transaction_id)
+        references transactions(id);
+
+alter table transactions
+    drop constraint fk_project_id,
+    add constraint fk_project_id
+        foreign key(project_id)
+        references projects(id);
+
+alter table project_memberships
+    drop constraint fk_project_id,
+    add constraint fk_project_id
+        foreign key(project_id)
+        references projects(id);
+
+alter table projects
+    drop column if exists archived_at;
@@ -0,0 +1,29 @@
+alter table projects
+    add column archived_at timestamptz;
+
+-- deleting a project removes its memberships, transactions and their targets
+alter table project_memberships
+    drop constraint fk_project_id,
+    add constraint fk_project_id
+        foreign key(project_id)
+        references projects(id)
+        on delete cascade;
+
+alter table transactions
+    drop constraint fk_project_id,
+    add constraint fk_project_id
+        foreign key(project_id)
+        references projects(id)
+        on delete cascade;
+
+alter table transaction_targets
+    drop constraint fk_transaction_id,
+    add constraint fk_transaction_id
+        foreign key(transaction_id)
+        references transactions(id)
+        on delete cascade,
+    drop constraint fk_transaction_project,
+    add constraint fk_transaction_project
+        foreign key(transaction_id, project_id)
+        references transactions(id, project_id)
+        on delete cascade;
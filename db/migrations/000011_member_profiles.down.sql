@@ -0,0 +1,5 @@
+alter table members
+    drop column if exists preferred_currency,
+    drop column if exists avatar_url,
+    drop column if exists email,
+    drop column if exists display_name;
@@ -0,0 +1,6 @@
+-- empty strings are profiles fields the user didn't fill in
+alter table members
+    add column display_name text not null default '',
+    add column email text not null default '',
+    add column avatar_url text not null default '',
+    add column preferred_currency text not null default '';
@@ -0,0 +1,3 @@
+alter table project_memberships
+    drop column role;
+drop type member_role;
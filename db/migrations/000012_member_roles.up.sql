@@ -0,0 +1,11 @@
+create type member_role as enum (
+  'owner',
+  'editor',
+  'viewer'
+);
+
+-- every member could do everything before, so existing members become owners
+alter table project_memberships
+    add column role member_role not null default 'owner';
+alter table project_memberships
+    alter column role set default 'editor';
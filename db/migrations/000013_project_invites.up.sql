@@ -0,0 +1,12 @@
+-- revoked invites are deleted, expired ones are kept until their project is deleted
+create table if not exists project_invites(
+    id UUID primary key,
+    project_id UUID not null,
+    role member_role not null,
+    created_by text not null,
+    created_at timestamptz not null default now(),
+    expires_at timestamptz not null,
+    constraint fk_project_id
+        foreign key(project_id)
+            references projects(id) on delete cascade
+);
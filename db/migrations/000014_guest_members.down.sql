@@ -0,0 +1,3 @@
+-- remaining guests are kept as regular members
+alter table members
+    drop column guest_project_id;
@@ -0,0 +1,7 @@
+-- guests are name-only members of a single project, they are deleted with it
+alter table members
+    add column guest_project_id UUID,
+    add constraint fk_guest_project_id
+        foreign key(guest_project_id)
+        references projects(id)
+        on delete cascade;
@@ -0,0 +1,3 @@
+drop table if exists recurring_transaction_targets;
+drop table if exists recurring_transactions;
+drop type recurrence_frequency;
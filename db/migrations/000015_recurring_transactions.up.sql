@@ -0,0 +1,50 @@
+create type recurrence_frequency as enum (
+  'daily',
+  'weekly',
+  'monthly'
+);
+
+create table if not exists recurring_transactions(
+    id UUID primary key,
+    project_id UUID not null,
+    name text not null,
+    amount INTEGER not null,
+    currency char(3) not null,
+    source_id text not null,
+    transaction_type transaction_type not null,
+    split_mode split_mode not null,
+    frequency recurrence_frequency not null,
+    interval_count INTEGER not null check (interval_count > 0),
+    start_date date not null,
+    end_date date,
+    -- the first occurrence that was not added as a transaction yet, null once the schedule ended
+    next_occurrence date,
+    created_by text not null,
+    created_at timestamptz not null default now(),
+    constraint uq_recurring_transactions_id_project_id unique (id, project_id),
+    constraint fk_project_id
+        foreign key(project_id)
+        references projects(id)
+        on delete cascade,
+    constraint fk_source_membership
+        foreign key(project_id, source_id)
+        references project_memberships(project_id, user_id)
+);
+
+create table if not exists recurring_transaction_targets(
+    recurring_transaction_id UUID not null,
+    project_id UUID not null,
+    user_id text not null,
+    weight INTEGER not null default 1,
+    constraint fk_recurring_transaction
+        foreign key(recurring_transaction_id, project_id)
+        references recurring_transactions(id, project_id)
+        on delete cascade,
+    constraint fk_target_membership
+        foreign key(project_id, user_id)
+        references project_memberships(project_id, user_id),
+    primary key(recurring_transaction_id, user_id)
+);
+
+create index if not exists idx_recurring_transactions_next_occurrence on recurring_transactions(next_occurrence)
+    where next_occurrence is not null;
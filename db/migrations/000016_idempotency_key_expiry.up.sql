@@ -0,0 +1,2 @@
+-- expired idempotency keys are deleted by their age
+create index if not exists idempotency_keys_created_at on idempotency_keys(created_at);
@@ -0,0 +1 @@
+alter table transaction_targets drop column if exists position;
@@ -0,0 +1,15 @@
+-- the position keeps the order of the targets, the remainder of a split goes to the first ones
+alter table transaction_targets
+    add column if not exists position INTEGER;
+
+-- the order of existing targets was never stored, they are ordered by user id
+update transaction_targets as tt
+set position = ordered.position
+from (
+    select transaction_id, user_id, row_number() over (partition by transaction_id order by user_id) - 1 as position
+    from transaction_targets
+) as ordered
+where tt.transaction_id = ordered.transaction_id and tt.user_id = ordered.user_id;
+
+alter table transaction_targets
+    alter column position set not null;
@@ -0,0 +1,3 @@
+-- the location header of created resources is replayed with the stored response
+alter table idempotency_keys
+    add column if not exists location text;
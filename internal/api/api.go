@@ -1,54 +1,93 @@
 package api
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/diezfx/split-app-backend/internal/apperror"
 	"github.com/diezfx/split-app-backend/internal/config"
 	"github.com/diezfx/split-app-backend/internal/service"
 	"github.com/diezfx/split-app-backend/pkg/auth"
-	"github.com/diezfx/split-app-backend/pkg/logger"
 	"github.com/diezfx/split-app-backend/pkg/middleware"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// NextCursorHeader contains the cursor of the next page for paginated lists
+const NextCursorHeader = "X-Next-Cursor"
+
+const basePath = "/api/v1.0/"
+
 type APIHandler struct {
 	projectService ProjectService
+	userService    UserService
 }
 
-func newAPIHandler(projectService ProjectService) *APIHandler {
-	return &APIHandler{projectService: projectService}
+func newAPIHandler(projectService ProjectService, userService UserService) *APIHandler {
+	return &APIHandler{projectService: projectService, userService: userService}
 }
 
-func InitAPI(cfg *config.Config, projectService ProjectService) *http.Server {
+// InitAPI registers all routes, the auth client is only used outside of the local environment.
+func InitAPI(cfg *config.Config, authClient *auth.Client, projectService ProjectService, userService UserService, idempotencyService IdempotencyService) *http.Server {
 	mr := gin.New()
+	// makes the user id set by the auth middleware available through the gin context
+	mr.ContextWithFallback = true
 	mr.Use(gin.Recovery())
 	mr.Use(middleware.HTTPLoggingMiddleware())
 	mr.Use(cors.New(cors.Config{
-		AllowMethods:     []string{"GET", "PUT", "PATCH", "POST", "OPTION"},
-		AllowHeaders:     []string{"Origin", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length", "Authorization"},
+		AllowMethods:     []string{"GET", "PUT", "PATCH", "POST", "DELETE", "OPTION"},
+		AllowHeaders:     []string{"Origin", "Authorization", auth.LocalUserHeader, IdempotencyKeyHeader},
+		ExposeHeaders:    []string{"Content-Length", "Authorization", "Link", "Location", NextCursorHeader, IdempotentReplayedHeader},
 		AllowCredentials: true,
 		AllowAllOrigins:  true,
 		MaxAge:           12 * time.Hour,
 	}))
-	r := mr.Group("/api/v1.0/")
-	if !cfg.IsLocal() {
-		r.Use(auth.AuthMiddleware(cfg.Auth))
+	r := mr.Group(basePath)
+	if cfg.IsLocal() {
+		r.Use(auth.LocalAuthMiddleware(cfg.Auth))
+	} else {
+		r.Use(auth.AuthMiddleware(authClient))
 	}
-	apiHandler := newAPIHandler(projectService)
+	r.Use(idempotencyMiddleware(idempotencyService))
+	apiHandler := newAPIHandler(projectService, userService)
 	r.GET("projects/:id", apiHandler.getProjectByIDHandler)
+	r.PATCH("projects/:id", apiHandler.updateProjectHandler)
+	r.DELETE("projects/:id", apiHandler.deleteProjectHandler)
+	r.POST("projects/:id/archive", apiHandler.archiveProjectHandler(true))
+	r.POST("projects/:id/unarchive", apiHandler.archiveProjectHandler(false))
 	r.GET("projects", apiHandler.getProjectsHandler)
 	r.POST("projects", apiHandler.addProjectHandler)
+	r.GET("users/me", apiHandler.getCurrentUserHandler)
+	r.PUT("users/me", apiHandler.updateCurrentUserHandler)
 	r.GET("users/:id/costs", apiHandler.getUserCostsHandler)
+	r.GET("projects/:id/transactions", apiHandler.getTransactionsHandler)
 	r.POST("projects/:id/transactions", apiHandler.addTransactionHandler)
+	// custom methods like transactions:batch can't be registered as static routes next to the other project routes
+	r.POST("projects/:id/:method", apiHandler.projectMethodHandler)
+	r.GET("projects/:id/transactions/:txId", apiHandler.getTransactionHandler)
+	r.PUT("projects/:id/transactions/:txId", apiHandler.updateTransactionHandler)
+	r.DELETE("projects/:id/transactions/:txId", apiHandler.deleteTransactionHandler)
 	r.GET("projects/:id/users", apiHandler.getProjectUsersHandler)
 	r.POST("projects/:id/users", apiHandler.addProjectUserHandler)
+	r.DELETE("projects/:id/users/:userId", apiHandler.removeProjectUserHandler)
+	r.PUT("projects/:id/users/:userId/role", apiHandler.updateProjectUserRoleHandler)
+	r.POST("projects/:id/guests", apiHandler.addGuestHandler)
+	r.POST("projects/:id/guests/:guestId/claim", apiHandler.claimGuestHandler)
 	r.GET("projects/:id/costs", apiHandler.getProjectCostsHandler)
+	r.GET("projects/:id/settlements", apiHandler.getSettlementsHandler)
+	r.POST("projects/:id/settlements", apiHandler.addSettlementsHandler)
+	r.GET("projects/:id/export", apiHandler.exportProjectHandler)
+	r.POST("projects/:id/import", apiHandler.importTransactionsHandler)
+	r.GET("projects/:id/recurringTransactions", apiHandler.getRecurringTransactionsHandler)
+	r.POST("projects/:id/recurringTransactions", apiHandler.addRecurringTransactionHandler)
+	r.DELETE("projects/:id/recurringTransactions/:recurringId", apiHandler.deleteRecurringTransactionHandler)
+	r.GET("projects/:id/invites", apiHandler.getInvitesHandler)
+	r.POST("projects/:id/invites", apiHandler.createInviteHandler)
+	r.DELETE("projects/:id/invites/:inviteId", apiHandler.revokeInviteHandler)
+	r.POST("invites/:token/accept", apiHandler.acceptInviteHandler)
 
 	return &http.Server{
 		Handler: mr,
@@ -64,6 +103,7 @@ func (api *APIHandler) getProjectUsersHandler(ctx *gin.Context) {
 	id, err := uuid.Parse(idStr)
 	if err != nil {
 		handleError(ctx, fmt.Errorf("invalid id givens: %w", errInvalidInput))
+		return
 	}
 	users, err := api.projectService.GetProjectUsers(ctx, id)
 	if err != nil {
@@ -71,14 +111,49 @@ func (api *APIHandler) getProjectUsersHandler(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, users)
+	userList := make([]User, 0, len(users))
+	for _, u := range users {
+		userList = append(userList, UserFromService(u))
+	}
+	ctx.JSON(http.StatusOK, userList)
+}
+
+func (api *APIHandler) getCurrentUserHandler(ctx *gin.Context) {
+	user, err := api.userService.GetCurrentUser(ctx)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ProfileFromService(user))
+}
+
+func (api *APIHandler) updateCurrentUserHandler(ctx *gin.Context) {
+	var body UpdateProfile
+	err := ctx.BindJSON(&body)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse profile body: %w: %w", errInvalidInput, err))
+		return
+	}
+	profile, err := body.Validate()
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	user, err := api.userService.UpdateCurrentUser(ctx, profile)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ProfileFromService(user))
 }
 
 func (api *APIHandler) getProjectCostsHandler(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		handleError(ctx, fmt.Errorf("invalid id givens: %w", errInvalidInput))
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
 	}
 	costs, err := api.projectService.GetCostsByProject(ctx, id)
 	if err != nil {
@@ -95,7 +170,23 @@ func (api *APIHandler) getUserCostsHandler(ctx *gin.Context) {
 		handleError(ctx, fmt.Errorf("invalid id given: %w", errInvalidInput))
 		return
 	}
-	users, err := api.projectService.GetCostsByUser(ctx, id)
+	var queryParams GetUserCostsQueryParams
+	err := ctx.BindQuery(&queryParams)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse query params: %w: %w", errInvalidInput, err))
+		return
+	}
+	// without a currency the service falls back to the preferred currency of the user
+	var currency string
+	if queryParams.Currency != "" {
+		currency, err = parseCurrency(queryParams.Currency)
+		if err != nil {
+			handleError(ctx, fmt.Errorf("parse currency: %w: %w", errInvalidInput, err))
+			return
+		}
+	}
+
+	users, err := api.projectService.GetCostsByUser(ctx, id, currency)
 	if err != nil {
 		handleError(ctx, fmt.Errorf("getUsers: %w", err))
 		return
@@ -113,11 +204,15 @@ func (api *APIHandler) addProjectUserHandler(ctx *gin.Context) {
 	}
 	projectUser := User{}
 	err = ctx.BindJSON(&projectUser)
-	if err != nil || projectUser.ID == "" {
+	if err != nil {
 		handleError(ctx, fmt.Errorf("invalid body: %w: %w", err, errInvalidInput))
 		return
 	}
-	err = api.projectService.AddProjectUser(ctx, projectID, projectUser.ID)
+	if err = projectUser.Validate(); err != nil {
+		handleError(ctx, err)
+		return
+	}
+	err = api.projectService.AddProjectUser(ctx, projectID, projectUser.ID, service.ParseRole(projectUser.Role))
 	if err != nil {
 		handleError(ctx, fmt.Errorf("getUsers: %w", err))
 		return
@@ -126,6 +221,60 @@ func (api *APIHandler) addProjectUserHandler(ctx *gin.Context) {
 	ctx.Status(http.StatusCreated)
 }
 
+// removeProjectUserHandler removes a member, with force their balance is settled and the transfers are returned.
+func (api *APIHandler) removeProjectUserHandler(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	var queryParams RemoveProjectUserQueryParams
+	err = ctx.BindQuery(&queryParams)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse query params: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	transactions, err := api.projectService.RemoveProjectUser(ctx, projectID, ctx.Param("userId"), queryParams.Force)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	transactionList := make([]Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		transactionList = append(transactionList, TransactionFromServiceTransaction(t))
+	}
+	ctx.JSON(http.StatusOK, transactionList)
+}
+
+// updateProjectUserRoleHandler changes the role of a member, only owners may do that.
+func (api *APIHandler) updateProjectUserRoleHandler(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	var body ProjectUserRole
+	err = ctx.BindJSON(&body)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse role body: %w: %w", errInvalidInput, err))
+		return
+	}
+	role, err := body.Validate()
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	err = api.projectService.UpdateProjectUserRole(ctx, projectID, ctx.Param("userId"), role)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
 func (api *APIHandler) getProjectsHandler(ctx *gin.Context) {
 	var queryParams GetProjectsQueryParams
 	err := ctx.BindQuery(&queryParams)
@@ -134,17 +283,25 @@ func (api *APIHandler) getProjectsHandler(ctx *gin.Context) {
 		return
 	}
 
-	proj, err := api.projectService.GetProjects(ctx)
+	page, err := api.projectService.GetProjects(ctx, service.ProjectQuery{
+		Limit:  queryParams.Limit,
+		Cursor: queryParams.Cursor,
+		Name:   queryParams.Name,
+		Member: queryParams.Member,
+
+		IncludeArchived: queryParams.IncludeArchived,
+	})
 	if err != nil {
 		handleError(ctx, err)
 		return
 	}
 
-	projectList := make([]Project, 0, len(proj))
-	for _, p := range proj {
+	projectList := make([]Project, 0, len(page.Projects))
+	for _, p := range page.Projects {
 		projectList = append(projectList, ProjectFromServiceProject(p))
 	}
 
+	setNextPageHeaders(ctx, page.NextCursor)
 	ctx.Header("Access-Control-Allow-Origin", "*")
 	ctx.JSON(http.StatusOK, projectList)
 }
@@ -157,14 +314,73 @@ func (api *APIHandler) getProjectByIDHandler(ctx *gin.Context) {
 		return
 	}
 
-	proj, err := api.projectService.GetProjectByID(ctx, id)
+	var queryParams GetProjectQueryParams
+	err = ctx.BindQuery(&queryParams)
 	if err != nil {
-		handleError(ctx, err)
+		handleError(ctx, fmt.Errorf("parse query params: %w: %w", errInvalidInput, err))
 		return
 	}
 
 	ctx.Header("Access-Control-Allow-Origin", "*")
-	ctx.JSON(http.StatusOK, ProjectFromServiceProject(proj))
+	switch queryParams.Transactions {
+	case "", FullProjectView:
+		proj, err := api.projectService.GetProjectByID(ctx, id)
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, ProjectFromServiceProject(proj))
+	case NoneProjectView:
+		proj, err := api.projectService.GetProjectByID(ctx, id)
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, ProjectSummaryFromService(proj))
+	case SummaryProjectView:
+		summary, err := api.projectService.GetProjectSummary(ctx, id)
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, ProjectSummaryWithTransactionsFromService(summary))
+	default:
+		handleError(ctx, fmt.Errorf("unknown project view %q: %w", queryParams.Transactions, errInvalidInput))
+	}
+}
+
+func (api *APIHandler) getTransactionsHandler(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	var queryParams GetTransactionsQueryParams
+	err = ctx.BindQuery(&queryParams)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse query params: %w: %w", errInvalidInput, err))
+		return
+	}
+	query, err := queryParams.Validate()
+	if err != nil {
+		handleError(ctx, fmt.Errorf("validate query params: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	page, err := api.projectService.GetTransactions(ctx, id, query)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	transactions := make([]Transaction, 0, len(page.Transactions))
+	for _, t := range page.Transactions {
+		transactions = append(transactions, TransactionFromServiceTransaction(t))
+	}
+
+	setNextPageHeaders(ctx, page.NextCursor)
+	ctx.JSON(http.StatusOK, transactions)
 }
 
 func (api *APIHandler) addTransactionHandler(ctx *gin.Context) {
@@ -187,13 +403,197 @@ func (api *APIHandler) addTransactionHandler(ctx *gin.Context) {
 		return
 	}
 
-	err = api.projectService.AddTransaction(ctx, id, svcTransaction)
+	added, err := api.projectService.AddTransaction(ctx, id, svcTransaction)
 	if err != nil {
 		handleError(ctx, err)
 		return
 	}
 
-	ctx.Status(http.StatusCreated)
+	ctx.Header("Location", resourceLocation("projects", id.String(), "transactions", added.ID.String()))
+	ctx.JSON(http.StatusCreated, TransactionFromServiceTransaction(added))
+}
+
+func (api *APIHandler) getTransactionHandler(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	txID, err := uuid.Parse(ctx.Param("txId"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid transactionId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	transaction, err := api.projectService.GetTransaction(ctx, id, txID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, TransactionFromServiceTransaction(transaction))
+}
+
+func (api *APIHandler) projectMethodHandler(ctx *gin.Context) {
+	switch ctx.Param("method") {
+	case "transactions:batch":
+		api.addTransactionsHandler(ctx)
+	default:
+		handleError(ctx, fmt.Errorf("unknown method %q: %w", ctx.Param("method"), apperror.NotFound))
+	}
+}
+
+func (api *APIHandler) addTransactionsHandler(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	var body AddTransactions
+	if err = ctx.BindJSON(&body); err != nil {
+		handleError(ctx, fmt.Errorf("parse add transactions body: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	svcTransactions, err := body.Validate()
+	if err != nil {
+		handleError(ctx, fmt.Errorf("validate transactions: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	added, err := api.projectService.AddTransactions(ctx, id, svcTransactions)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	transactionList := make([]Transaction, 0, len(added))
+	for _, t := range added {
+		transactionList = append(transactionList, TransactionFromServiceTransaction(t))
+	}
+	ctx.JSON(http.StatusCreated, transactionList)
+}
+
+func (api *APIHandler) getSettlementsHandler(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	settlements, err := api.projectService.GetSettlements(ctx, id)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("get settlements: %w", err))
+		return
+	}
+
+	settlementList := make([]Settlement, 0, len(settlements))
+	for _, s := range settlements {
+		settlementList = append(settlementList, SettlementFromService(s))
+	}
+
+	ctx.JSON(http.StatusOK, settlementList)
+}
+
+func (api *APIHandler) addSettlementsHandler(ctx *gin.Context) {
+	idStr := ctx.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	var body AddSettlements
+	// an empty body records the suggested settlements
+	if ctx.Request.ContentLength != 0 {
+		if err = ctx.BindJSON(&body); err != nil {
+			handleError(ctx, fmt.Errorf("parse add settlements body: %w: %w", errInvalidInput, err))
+			return
+		}
+	}
+
+	settlements, err := body.Validate()
+	if err != nil {
+		handleError(ctx, fmt.Errorf("validate settlements: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	transactions, err := api.projectService.AddSettlements(ctx, id, settlements)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("add settlements: %w", err))
+		return
+	}
+
+	transactionList := make([]Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		transactionList = append(transactionList, TransactionFromServiceTransaction(t))
+	}
+
+	ctx.JSON(http.StatusCreated, transactionList)
+}
+
+func (api *APIHandler) updateTransactionHandler(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	txID, err := uuid.Parse(ctx.Param("txId"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid transactionId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	var transaction AddTransaction
+	if err = ctx.BindJSON(&transaction); err != nil {
+		handleError(ctx, fmt.Errorf("parse update transaction body: %w: %w", errInvalidInput, err))
+		return
+	}
+	// the id in the body is optional, but has to match the path if given
+	if transaction.ID == "" {
+		transaction.ID = txID.String()
+	}
+	if transaction.ID != txID.String() {
+		handleError(ctx, fmt.Errorf("transaction id in body does not match path: %w", errInvalidInput))
+		return
+	}
+
+	svcTransaction, err := transaction.Validate()
+	if err != nil {
+		handleError(ctx, fmt.Errorf("validate transaction: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	updated, err := api.projectService.UpdateTransaction(ctx, id, svcTransaction)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, TransactionFromServiceTransaction(updated))
+}
+
+func (api *APIHandler) deleteTransactionHandler(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	txID, err := uuid.Parse(ctx.Param("txId"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid transactionId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	err = api.projectService.DeleteTransaction(ctx, id, txID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
 }
 
 func (api *APIHandler) addProjectHandler(ctx *gin.Context) {
@@ -204,42 +604,97 @@ func (api *APIHandler) addProjectHandler(ctx *gin.Context) {
 		return
 	}
 
-	idParsed, err := uuid.Parse(body.ID)
+	// the service generates an id if the client doesn't bring its own
+	project, err := body.Validate()
 	if err != nil {
-		handleError(ctx, fmt.Errorf("parse id in body: %w: %w", errInvalidInput, err))
+		handleError(ctx, err)
 		return
 	}
 
-	project := service.Project{ID: idParsed, Name: body.Name, Members: body.Members}
-
 	proj, err := api.projectService.AddProject(ctx, project)
 	if err != nil {
 		handleError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, proj)
+	ctx.Header("Location", resourceLocation("projects", proj.ID.String()))
+	ctx.JSON(http.StatusCreated, ProjectFromServiceProject(proj))
 }
 
-func handleError(ctx *gin.Context, err error) {
-	switch {
-	case errors.Is(err, errInvalidInput):
-		logger.Info(ctx).Err(err).Msg("request failed with invalid input")
-		ctx.JSON(http.StatusBadRequest, ErrorResponse{
-			ErrorCode: http.StatusBadRequest,
-			Reason:    "invalid input",
-		})
-	case errors.Is(err, service.ErrProjectNotFound):
-		logger.Info(ctx).Err(err).Msg("not found")
-		ctx.JSON(http.StatusBadRequest, ErrorResponse{
-			ErrorCode: http.StatusNotFound,
-			Reason:    "not found",
-		})
-	default:
-		logger.Error(ctx, err).Msg("unexpected error occurred")
-		ctx.JSON(http.StatusBadRequest, ErrorResponse{
-			ErrorCode: http.StatusInternalServerError,
-			Reason:    "unexpected",
-		})
+// resourceLocation returns the path of a resource for the Location header.
+func resourceLocation(segments ...string) string {
+	return basePath + strings.Join(segments, "/")
+}
+
+func (api *APIHandler) updateProjectHandler(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
 	}
+	var body UpdateProject
+	err = ctx.BindJSON(&body)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse update project body: %w: %w", errInvalidInput, err))
+		return
+	}
+	update, err := body.Validate()
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	proj, err := api.projectService.UpdateProject(ctx, id, update)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ProjectFromServiceProject(proj))
+}
+
+// archiveProjectHandler archives or unarchives the project depending on archived.
+func (api *APIHandler) archiveProjectHandler(archived bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+			return
+		}
+
+		proj, err := api.projectService.UpdateProject(ctx, id, service.ProjectUpdate{Archived: &archived})
+		if err != nil {
+			handleError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, ProjectFromServiceProject(proj))
+	}
+}
+
+func (api *APIHandler) deleteProjectHandler(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	err = api.projectService.DeleteProject(ctx, id)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// setNextPageHeaders links the next page of a list, nothing is set on the last page.
+func setNextPageHeaders(ctx *gin.Context, nextCursor string) {
+	if nextCursor == "" {
+		return
+	}
+	nextURL := *ctx.Request.URL
+	query := nextURL.Query()
+	query.Set("cursor", nextCursor)
+	nextURL.RawQuery = query.Encode()
+
+	ctx.Header(NextCursorHeader, nextCursor)
+	ctx.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", nextURL.RequestURI()))
 }
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/diezfx/split-app-backend/internal/config"
+	"github.com/diezfx/split-app-backend/internal/contextutil"
+	"github.com/diezfx/split-app-backend/internal/invite"
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/diezfx/split-app-backend/internal/storage/memory"
+	"github.com/diezfx/split-app-backend/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newTestServer returns the routes backed by the memory storage and a project owned by u1 with the member u2.
+func newTestServer(t *testing.T, signer *invite.Signer) (http.Handler, service.Project) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store := memory.New()
+	svc := service.New(store, store, signer)
+	proj, err := svc.AddProject(contextutil.AddUserIDToCtx(context.Background(), "u1"),
+		service.Project{ID: uuid.New(), Name: "test", Currency: money.EUR, Members: []string{"u1", "u2"}})
+	if err != nil {
+		t.Fatalf("add project: %s", err)
+	}
+	cfg := config.Config{Environment: config.LocalEnv}
+	return InitAPI(&cfg, nil, svc, svc, svc).Handler, proj
+}
+
+func doRequest(handler http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, basePath+path, strings.NewReader(body))
+	req.Header.Set(auth.LocalUserHeader, "u1")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, ProblemContentType) {
+		t.Errorf("expected a problem response got content type %q: %s", contentType, w.Body.String())
+	}
+	var problem Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decode problem %q: %s", w.Body.String(), err)
+	}
+	return problem
+}
+
+func TestInvitesDisabled(t *testing.T) {
+	handler, proj := newTestServer(t, nil)
+
+	for _, r := range []struct{ method, path, body string }{
+		{http.MethodPost, "projects/" + proj.ID.String() + "/invites", `{}`},
+		{http.MethodGet, "projects/" + proj.ID.String() + "/invites", ""},
+		{http.MethodPost, "invites/token/accept", ""},
+	} {
+		w := doRequest(handler, r.method, r.path, r.body)
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("%s %s: expected status %d got %d: %s", r.method, r.path, http.StatusNotImplemented, w.Code, w.Body.String())
+			continue
+		}
+		if problem := decodeProblem(t, w); problem.Status != http.StatusNotImplemented {
+			t.Errorf("%s %s: expected problem status %d got %+v", r.method, r.path, http.StatusNotImplemented, problem)
+		}
+	}
+}
+
+func TestUpdateTransactionReturnsStoredTransaction(t *testing.T) {
+	handler, proj := newTestServer(t, invite.NewSigner("test"))
+	path := "projects/" + proj.ID.String() + "/transactions"
+
+	w := doRequest(handler, http.MethodPost, path,
+		`{"name":"dinner","transactionType":"Expense","amount":10,"sourceId":"u1","targetIds":["u2"],"occurredAt":"2024-03-03"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add transaction: expected status %d got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var added Transaction
+	if err := json.Unmarshal(w.Body.Bytes(), &added); err != nil {
+		t.Fatalf("decode transaction %q: %s", w.Body.String(), err)
+	}
+
+	// the date is omitted, so the stored one is kept
+	w = doRequest(handler, http.MethodPut, path+"/"+added.ID.String(),
+		`{"name":"lunch","transactionType":"Expense","amount":10,"sourceId":"u1","targetIds":["u2"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update transaction: expected status %d got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var updated Transaction
+	if err := json.Unmarshal(w.Body.Bytes(), &updated); err != nil {
+		t.Fatalf("decode transaction %q: %s", w.Body.String(), err)
+	}
+	w = doRequest(handler, http.MethodGet, path+"/"+added.ID.String(), "")
+	var stored Transaction
+	if err := json.Unmarshal(w.Body.Bytes(), &stored); err != nil {
+		t.Fatalf("decode transaction %q: %s", w.Body.String(), err)
+	}
+	if updated.Name != "lunch" || updated.OccurredAt != "2024-03-03" || updated.CreatedBy != stored.CreatedBy ||
+		!updated.CreatedAt.Equal(stored.CreatedAt) || !updated.UpdatedAt.Equal(stored.UpdatedAt) {
+		t.Errorf("expected the stored transaction %+v got %+v", stored, updated)
+	}
+}
+
+func TestIdempotentReplayKeepsLocation(t *testing.T) {
+	handler, proj := newTestServer(t, invite.NewSigner("test"))
+	path := "projects/" + proj.ID.String() + "/transactions"
+	body := `{"name":"dinner","transactionType":"Expense","amount":10,"sourceId":"u1","targetIds":["u2"]}`
+
+	first := doRequest(handler, http.MethodPost, path, body, IdempotencyKeyHeader, "key")
+	if first.Code != http.StatusCreated || first.Header().Get("Location") == "" {
+		t.Fatalf("expected created transaction with location got %d %v: %s", first.Code, first.Header(), first.Body.String())
+	}
+	replayed := doRequest(handler, http.MethodPost, path, body, IdempotencyKeyHeader, "key")
+	if replayed.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("expected replayed response got %v", replayed.Header())
+	}
+	if replayed.Code != first.Code || replayed.Header().Get("Location") != first.Header().Get("Location") ||
+		replayed.Body.String() != first.Body.String() {
+		t.Errorf("expected response %d %q %s got %d %q %s",
+			first.Code, first.Header().Get("Location"), first.Body.String(),
+			replayed.Code, replayed.Header().Get("Location"), replayed.Body.String())
+	}
+}
+
+func TestImportWithInvalidRows(t *testing.T) {
+	handler, proj := newTestServer(t, invite.NewSigner("test"))
+	path := "projects/" + proj.ID.String() + "/import?format=splitwise&member=Anna=u1&member=Ben=u2"
+	csv := "Date,Description,Category,Cost,Currency,Anna,Ben\n" +
+		"2024-05-01,Dinner,Dining out,20.00,EUR,10.00,-10.00\n" +
+		"2024-05-03,Taxi,Transportation,abc,EUR,1.00,-1.00\n"
+
+	w := doRequest(handler, http.MethodPost, path, csv)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	problem := decodeProblem(t, w)
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "lines[3]" || problem.Errors[0].Code != "invalid" {
+		t.Errorf("expected the invalid line in the problem got %+v", problem)
+	}
+
+	w = doRequest(handler, http.MethodGet, "projects/"+proj.ID.String()+"/transactions", "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Dinner") {
+		t.Errorf("expected nothing to be imported got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestProjectCostsWithInvalidID(t *testing.T) {
+	handler, _ := newTestServer(t, invite.NewSigner("test"))
+
+	w := doRequest(handler, http.MethodGet, "projects/not-a-uuid/costs", "")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	// a single problem is written, the handler stops after the invalid id
+	if problem := decodeProblem(t, w); problem.Status != http.StatusBadRequest {
+		t.Errorf("expected problem status %d got %+v", http.StatusBadRequest, problem)
+	}
+}
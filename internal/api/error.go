@@ -1,5 +1,86 @@
 package api
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
 
-var errInvalidInput = errors.New("invalid input")
+	"github.com/diezfx/split-app-backend/internal/apperror"
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/diezfx/split-app-backend/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the content type of error responses as defined in RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+var errInvalidInput = fmt.Errorf("invalid input: %w", apperror.Validation)
+
+// Problem is the body of all error responses, see RFC 7807.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Errors lists the invalid fields of a request
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func fieldErrorsFromAppError(fields []apperror.FieldError) []FieldError {
+	if len(fields) == 0 {
+		return nil
+	}
+	errs := make([]FieldError, 0, len(fields))
+	for _, f := range fields {
+		errs = append(errs, FieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+	}
+	return errs
+}
+
+func statusOf(kind apperror.Kind) int {
+	switch kind {
+	case apperror.Validation:
+		return http.StatusBadRequest
+	case apperror.NotFound:
+		return http.StatusNotFound
+	case apperror.Forbidden:
+		return http.StatusForbidden
+	case apperror.Conflict:
+		return http.StatusConflict
+	case apperror.Disabled:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func handleError(ctx *gin.Context, err error) {
+	kind := apperror.KindOf(err)
+	problem := Problem{Type: "about:blank", Status: statusOf(kind)}
+
+	switch {
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		problem.Status = http.StatusUnprocessableEntity
+		problem.Detail = "idempotency key was used for a different request"
+	case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+		problem.Detail = "request with the idempotency key is still in progress"
+	case kind == apperror.Validation:
+		problem.Errors = fieldErrorsFromAppError(apperror.Fields(err))
+	}
+	problem.Title = http.StatusText(problem.Status)
+
+	if kind == apperror.Internal {
+		logger.Error(ctx, err).Msg("unexpected error occurred")
+	} else {
+		logger.Info(ctx).Err(err).Int("status", problem.Status).Msg("request failed")
+	}
+
+	ctx.Header("Content-Type", ProblemContentType)
+	ctx.JSON(problem.Status, problem)
+}
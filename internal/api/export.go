@@ -0,0 +1,237 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/diezfx/split-app-backend/internal/csvimport"
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/diezfx/split-app-backend/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+type ExportFormat string
+
+const (
+	CSVExportFormat  ExportFormat = "csv"
+	JSONExportFormat ExportFormat = "json"
+)
+
+// ExportTransactionColumns are the columns of the transaction section of a csv export,
+// the export can be imported again with the ledger import format.
+var ExportTransactionColumns = csvimport.LedgerTransactionColumns
+
+// ExportBalanceColumns are the columns of the balance section of a csv export,
+// it follows the transactions after an empty line.
+var ExportBalanceColumns = csvimport.LedgerBalanceColumns
+
+type ExportQueryParams struct {
+	// Format is either csv or json, defaults to csv
+	Format ExportFormat `form:"format"`
+}
+
+type ExportProject struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Currency string    `json:"currency"`
+	Members  []string  `json:"members"`
+}
+
+type ExportTransaction struct {
+	Transaction
+	Shares []ExportShare `json:"shares"`
+}
+
+type ExportShare struct {
+	UserID   string  `json:"userId"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+type ExportBalance struct {
+	UserID string `json:"userId"`
+	Cost
+}
+
+func ExportTransactionFromService(entry service.LedgerEntry) ExportTransaction {
+	shares := make([]ExportShare, 0, len(entry.Shares))
+	for i, share := range entry.Shares {
+		shares = append(shares, ExportShare{
+			UserID:   entry.Transaction.TargetIDs[i],
+			Amount:   share.AsMajorUnits(),
+			Currency: share.Currency().Code,
+		})
+	}
+	return ExportTransaction{Transaction: TransactionFromServiceTransaction(entry.Transaction), Shares: shares}
+}
+
+// exportBalancesFromService returns the balances sorted by user, so exports of the same project are identical.
+func exportBalancesFromService(costs service.ProjectCosts) []ExportBalance {
+	users := maps.Keys(costs.UserCosts)
+	slices.Sort(users)
+	balances := make([]ExportBalance, 0, len(users))
+	for _, user := range users {
+		balances = append(balances, ExportBalance{UserID: user, Cost: CostFromService(costs.UserCosts[user])})
+	}
+	return balances
+}
+
+func (api *APIHandler) exportProjectHandler(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	var queryParams ExportQueryParams
+	err = ctx.BindQuery(&queryParams)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse query params: %w: %w", errInvalidInput, err))
+		return
+	}
+	if queryParams.Format == "" {
+		queryParams.Format = CSVExportFormat
+	}
+	if queryParams.Format != CSVExportFormat && queryParams.Format != JSONExportFormat {
+		handleError(ctx, fmt.Errorf("unknown export format %q: %w", queryParams.Format, errInvalidInput))
+		return
+	}
+
+	response := exportResponse{ctx: ctx, filename: fmt.Sprintf("%s.%s", id, queryParams.Format)}
+	if queryParams.Format == JSONExportFormat {
+		response.contentType = "application/json"
+		response.LedgerWriter = &jsonExportWriter{w: ctx.Writer, encoder: json.NewEncoder(ctx.Writer)}
+	} else {
+		response.contentType = "text/csv"
+		response.LedgerWriter = &csvExportWriter{writer: csv.NewWriter(ctx.Writer)}
+	}
+
+	err = api.projectService.ExportProject(ctx, id, &response)
+	if err != nil && !ctx.Writer.Written() {
+		handleError(ctx, err)
+		return
+	}
+	if err != nil {
+		// the status is already sent, so the error can only be logged
+		logger.Error(ctx, err).Msg("write export")
+	}
+}
+
+// exportResponse sends the headers right before the first part of the export,
+// so errors that happen before, like a missing permission, are still returned as json.
+type exportResponse struct {
+	service.LedgerWriter
+	ctx         *gin.Context
+	contentType string
+	filename    string
+}
+
+func (r *exportResponse) WriteProject(proj service.Project) error {
+	r.ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", r.filename))
+	r.ctx.Header("Content-Type", r.contentType)
+	r.ctx.Status(http.StatusOK)
+	return r.LedgerWriter.WriteProject(proj)
+}
+
+// csvExportWriter writes the transactions and the balances as two csv sections separated by an empty line.
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvExportWriter) WriteProject(_ service.Project) error {
+	if err := w.writer.Write(ExportTransactionColumns); err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvExportWriter) WriteEntry(entry service.LedgerEntry) error {
+	tx := TransactionFromServiceTransaction(entry.Transaction)
+	for i, share := range entry.Shares {
+		weight := ""
+		if i < len(tx.Weights) {
+			weight = formatFloat(tx.Weights[i])
+		}
+		err := w.writer.Write([]string{
+			tx.ID.String(), tx.OccurredAt, tx.Name, string(tx.TransactionType), formatFloat(tx.Amount), tx.Currency,
+			tx.SourceID, string(tx.SplitMode), tx.TargetIDs[i], weight, formatFloat(share.AsMajorUnits()), share.Currency().Code,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// send every transaction as soon as it is written
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvExportWriter) WriteBalances(balances service.ProjectCosts) error {
+	if err := w.writer.Write(nil); err != nil {
+		return err
+	}
+	if err := w.writer.Write(ExportBalanceColumns); err != nil {
+		return err
+	}
+	for _, b := range exportBalancesFromService(balances) {
+		err := w.writer.Write([]string{b.UserID, formatFloat(b.Expenses), formatFloat(b.Income), formatFloat(b.Balance), b.Currency})
+		if err != nil {
+			return err
+		}
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// jsonExportWriter writes the export as one json object, the transactions are encoded one by one.
+type jsonExportWriter struct {
+	w       io.Writer
+	encoder *json.Encoder
+	entries int
+}
+
+func (w *jsonExportWriter) write(s string) error {
+	_, err := io.WriteString(w.w, s)
+	return err
+}
+
+func (w *jsonExportWriter) WriteProject(proj service.Project) error {
+	if err := w.write(`{"project":`); err != nil {
+		return err
+	}
+	err := w.encoder.Encode(ExportProject{ID: proj.ID, Name: proj.Name, Currency: proj.Currency, Members: proj.Members})
+	if err != nil {
+		return err
+	}
+	return w.write(`,"transactions":[`)
+}
+
+func (w *jsonExportWriter) WriteEntry(entry service.LedgerEntry) error {
+	if w.entries > 0 {
+		if err := w.write(","); err != nil {
+			return err
+		}
+	}
+	w.entries++
+	return w.encoder.Encode(ExportTransactionFromService(entry))
+}
+
+func (w *jsonExportWriter) WriteBalances(balances service.ProjectCosts) error {
+	if err := w.write(`],"balances":`); err != nil {
+		return err
+	}
+	if err := w.encoder.Encode(exportBalancesFromService(balances)); err != nil {
+		return err
+	}
+	return w.write("}\n")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/diezfx/split-app-backend/internal/csvimport"
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+func TestCSVExportCanBeImported(t *testing.T) {
+	entries := []service.LedgerEntry{{
+		Transaction: service.Transaction{
+			ID: uuid.New(), Name: "rent", TransactionType: service.ExpenseTransactionType,
+			Amount: money.New(9000, money.EUR), SourceID: "u1", TargetIDs: []string{"u2", "u3"},
+			SplitMode: service.SharesSplitMode, Weights: []int{2, 1},
+			OccurredAt: time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+		},
+		Shares: []*money.Money{money.New(6000, money.EUR), money.New(3000, money.EUR)},
+	}, {
+		// the shares are converted into the project currency, the import has to split the original amount again
+		Transaction: service.Transaction{
+			ID: uuid.New(), Name: "taxi", TransactionType: service.ExpenseTransactionType,
+			Amount: money.New(2000, money.USD), SourceID: "u2", TargetIDs: []string{"u1", "u3"},
+			SplitMode: service.ExactSplitMode, Weights: []int{1500, 500},
+			OccurredAt: time.Date(2024, time.May, 2, 0, 0, 0, 0, time.UTC),
+		},
+		Shares: []*money.Money{money.New(1350, money.EUR), money.New(450, money.EUR)},
+	}}
+
+	var buf bytes.Buffer
+	w := &csvExportWriter{writer: csv.NewWriter(&buf)}
+	if err := w.WriteProject(service.Project{}); err != nil {
+		t.Fatalf("write project: %s", err)
+	}
+	for _, entry := range entries {
+		if err := w.WriteEntry(entry); err != nil {
+			t.Fatalf("write entry: %s", err)
+		}
+	}
+	if err := w.WriteBalances(service.ProjectCosts{Currency: money.EUR}); err != nil {
+		t.Fatalf("write balances: %s", err)
+	}
+
+	result, err := csvimport.Parse(&buf, csvimport.LedgerFormat)
+	if err != nil {
+		t.Fatalf("parse export: %s", err)
+	}
+	if len(result.Errors) != 0 || len(result.Transactions) != len(entries) {
+		t.Fatalf("expected %d transactions without errors got %+v", len(entries), result)
+	}
+	expectedWeights := [][]int{{6000, 3000}, {1500, 500}}
+	for i, imported := range result.Transactions {
+		tx := importedTransactionToService(&imported, nil)
+		expected := entries[i].Transaction
+		if tx.Name != expected.Name || tx.SourceID != expected.SourceID || !tx.OccurredAt.Equal(expected.OccurredAt) ||
+			tx.Amount.Amount() != expected.Amount.Amount() || tx.Amount.Currency().Code != expected.Amount.Currency().Code {
+			t.Errorf("expected transaction %+v got %+v", expected, tx)
+		}
+		if !slices.Equal(tx.TargetIDs, expected.TargetIDs) || !slices.Equal(tx.Weights, expectedWeights[i]) {
+			t.Errorf("expected targets %v with weights %v got %v with %v", expected.TargetIDs, expectedWeights[i], tx.TargetIDs, tx.Weights)
+		}
+	}
+}
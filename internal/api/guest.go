@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/diezfx/split-app-backend/internal/apperror"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AddGuest adds a member without an account, only the name is known.
+type AddGuest struct {
+	DisplayName string `json:"displayName"`
+}
+
+func (g *AddGuest) Validate() (string, error) {
+	name := strings.TrimSpace(g.DisplayName)
+	if name == "" {
+		return "", newRequiredError("displayName")
+	}
+	if utf8.RuneCountInString(name) > maxDisplayNameLength {
+		return "", newFieldError("displayName", apperror.CodeInvalid,
+			fmt.Sprintf("must not be longer than %d characters", maxDisplayNameLength))
+	}
+	return name, nil
+}
+
+func (api *APIHandler) addGuestHandler(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	var body AddGuest
+	err = ctx.BindJSON(&body)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse guest body: %w: %w", errInvalidInput, err))
+		return
+	}
+	name, err := body.Validate()
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	guest, err := api.projectService.AddGuest(ctx, projectID, name)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.Header("Location", resourceLocation("projects", projectID.String(), "users", guest.ID))
+	ctx.JSON(http.StatusCreated, UserFromService(guest))
+}
+
+// claimGuestHandler merges the guest into the calling user and returns the project.
+func (api *APIHandler) claimGuestHandler(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	proj, err := api.projectService.ClaimGuest(ctx, projectID, ctx.Param("guestId"))
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ProjectFromServiceProject(proj))
+}
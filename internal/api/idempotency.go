@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/diezfx/split-app-backend/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader makes POST requests safe to retry, a retry with the same key returns the original response
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses that are replayed from a previous request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+type IdempotencyService interface {
+	BeginIdempotentRequest(ctx context.Context, key, fingerprint string) (service.IdempotencyClaim, *service.IdempotentResponse, error)
+	CompleteIdempotentRequest(ctx context.Context, claim service.IdempotencyClaim, response service.IdempotentResponse) error
+	AbortIdempotentRequest(ctx context.Context, claim service.IdempotencyClaim) error
+}
+
+// recordingWriter keeps a copy of the response body, so it can be stored for retries.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyMiddleware replays the stored response for POST requests with an already used idempotency key.
+// It has to run after the auth middleware, keys are scoped to the calling user.
+func idempotencyMiddleware(idempotencyService IdempotencyService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(IdempotencyKeyHeader)
+		if ctx.Request.Method != http.MethodPost || key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			handleError(ctx, fmt.Errorf("idempotency key too long: %w", errInvalidInput))
+			ctx.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			handleError(ctx, fmt.Errorf("read body: %w: %w", errInvalidInput, err))
+			ctx.Abort()
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		claim, response, err := idempotencyService.BeginIdempotentRequest(ctx, key, requestFingerprint(ctx.Request, body))
+		if err != nil {
+			handleError(ctx, err)
+			ctx.Abort()
+			return
+		}
+		if response != nil {
+			ctx.Header(IdempotentReplayedHeader, "true")
+			if response.Location != "" {
+				ctx.Header("Location", response.Location)
+			}
+			ctx.Data(response.StatusCode, response.ContentType, response.Body)
+			ctx.Abort()
+			return
+		}
+
+		// the key is released or completed even if the client went away, otherwise every retry would be rejected
+		storeCtx := context.WithoutCancel(ctx)
+		completed := false
+		defer func() {
+			// a panicking handler releases the key before the recovery middleware answers the request
+			if !completed {
+				if err := idempotencyService.AbortIdempotentRequest(storeCtx, claim); err != nil {
+					logger.Error(storeCtx, err).String("key", key).Msg("release idempotency key")
+				}
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		ctx.Next()
+		completed = true
+
+		// server errors are not stored, the request can be retried with the same key
+		if writer.Status() >= http.StatusInternalServerError {
+			err = idempotencyService.AbortIdempotentRequest(storeCtx, claim)
+		} else {
+			err = idempotencyService.CompleteIdempotentRequest(storeCtx, claim, service.IdempotentResponse{
+				StatusCode:  writer.Status(),
+				ContentType: writer.Header().Get("Content-Type"),
+				Location:    writer.Header().Get("Location"),
+				Body:        writer.body.Bytes(),
+			})
+		}
+		if err != nil {
+			logger.Error(ctx, err).String("key", key).Msg("store idempotent response")
+		}
+	}
+}
+
+// requestFingerprint identifies a request by its method, path, query and body.
+func requestFingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", req.Method, req.URL.RequestURI())
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/diezfx/split-app-backend/internal/apperror"
+	"github.com/diezfx/split-app-backend/internal/csvimport"
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+type ImportQueryParams struct {
+	// Format is the app the csv was exported from, either splitwise, tricount or ledger for csv exports of this app
+	Format csvimport.Format `form:"format"`
+	// DryRun only reports what would be imported
+	DryRun bool `form:"dryRun"`
+	// Members maps participants of the export to users in the form participant=userId,
+	// participants without a mapping are imported with their name as user id
+	Members []string `form:"member"`
+}
+
+func (q *ImportQueryParams) memberMapping() (map[string]string, error) {
+	mapping := make(map[string]string, len(q.Members))
+	for _, m := range q.Members {
+		participant, userID, ok := strings.Cut(m, "=")
+		if !ok || participant == "" || userID == "" {
+			return nil, NewInvalidArgumentError("member")
+		}
+		mapping[participant] = userID
+	}
+	return mapping, nil
+}
+
+type ImportReport struct {
+	DryRun       bool          `json:"dryRun"`
+	Transactions []Transaction `json:"transactions"`
+	NewMembers   []string      `json:"newMembers"`
+	// Errors lists the rows that can't be read, only a dry run reports them, an import fails with a problem instead
+	Errors []ImportRowError `json:"errors"`
+}
+
+type ImportRowError struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// importedTransactionToService turns a row of the export into an exact split, participants mapped to the same user are merged.
+func importedTransactionToService(tx *csvimport.Transaction, mapping map[string]string) service.Transaction {
+	userID := func(participant string) string {
+		if id, ok := mapping[participant]; ok {
+			return id
+		}
+		return participant
+	}
+
+	targets := make([]string, 0, len(tx.Participants))
+	weights := make([]int, 0, len(tx.Participants))
+	for i, participant := range tx.Participants {
+		target := userID(participant)
+		if index := slices.Index(targets, target); index != -1 {
+			weights[index] += int(tx.Shares[i])
+			continue
+		}
+		targets = append(targets, target)
+		weights = append(weights, int(tx.Shares[i]))
+	}
+
+	transactionType := service.ExpenseTransactionType
+	if tx.Transfer {
+		transactionType = service.TransferTransactionType
+	}
+	return service.Transaction{
+		Name:            tx.Name,
+		TransactionType: transactionType,
+		Amount:          tx.Amount,
+		SourceID:        userID(tx.Payer),
+		TargetIDs:       targets,
+		SplitMode:       service.ExactSplitMode,
+		Weights:         weights,
+		OccurredAt:      tx.OccurredAt,
+	}
+}
+
+func (api *APIHandler) importTransactionsHandler(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	var queryParams ImportQueryParams
+	err = ctx.BindQuery(&queryParams)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse query params: %w: %w", errInvalidInput, err))
+		return
+	}
+	mapping, err := queryParams.memberMapping()
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse member mapping: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	parsed, err := csvimport.Parse(ctx.Request.Body, queryParams.Format)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse csv: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	// nothing is written as long as there are rows that can't be read, only a dry run reports them with the rest of the import
+	if len(parsed.Errors) > 0 && !queryParams.DryRun {
+		handleError(ctx, fmt.Errorf("import csv: %w", rowErrorsToValidationError(parsed.Errors)))
+		return
+	}
+
+	report := ImportReport{DryRun: queryParams.DryRun, Transactions: []Transaction{}, NewMembers: []string{}, Errors: []ImportRowError{}}
+	for _, rowErr := range parsed.Errors {
+		report.Errors = append(report.Errors, ImportRowError{Line: rowErr.Line, Reason: rowErr.Err.Error()})
+	}
+	transactions := make([]service.Transaction, 0, len(parsed.Transactions))
+	for i := range parsed.Transactions {
+		transactions = append(transactions, importedTransactionToService(&parsed.Transactions[i], mapping))
+	}
+
+	result, err := api.projectService.ImportTransactions(ctx, id, transactions, queryParams.DryRun)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	for _, tx := range result.Transactions {
+		report.Transactions = append(report.Transactions, TransactionFromServiceTransaction(tx))
+	}
+	if result.NewMembers != nil {
+		report.NewMembers = result.NewMembers
+	}
+
+	if queryParams.DryRun {
+		ctx.JSON(http.StatusOK, report)
+		return
+	}
+	ctx.JSON(http.StatusCreated, report)
+}
+
+// rowErrorsToValidationError reports every row that can't be read as an invalid field named after its line.
+func rowErrorsToValidationError(rowErrs []csvimport.RowError) error {
+	fields := make([]apperror.FieldError, 0, len(rowErrs))
+	for _, rowErr := range rowErrs {
+		fields = append(fields, apperror.FieldError{
+			Field: fmt.Sprintf("lines[%d]", rowErr.Line), Code: apperror.CodeInvalid, Message: rowErr.Err.Error(),
+		})
+	}
+	return apperror.NewValidationError(fields...)
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/diezfx/split-app-backend/internal/apperror"
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Invite struct {
+	ID        uuid.UUID `json:"id"`
+	ProjectID uuid.UUID `json:"projectId"`
+	Role      string    `json:"role"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Token is redeemed with POST /invites/:token/accept
+	Token string `json:"token"`
+}
+
+func InviteFromService(i service.Invite) Invite {
+	return Invite{
+		ID: i.ID, ProjectID: i.ProjectID, Role: string(i.Role), CreatedBy: i.CreatedBy,
+		CreatedAt: i.CreatedAt, ExpiresAt: i.ExpiresAt, Token: i.Token,
+	}
+}
+
+type CreateInvite struct {
+	// Role of the invited members, defaults to editor
+	Role string `json:"role"`
+	// ValidForHours may be at most 30 days, 0 uses the default of a week
+	ValidForHours int `json:"validForHours"`
+}
+
+func (i *CreateInvite) Validate() (service.Role, time.Duration, error) {
+	role := service.ParseRole(i.Role)
+	if role == service.UndefinedRole {
+		return "", 0, newFieldError("role", apperror.CodeInvalid, "must be owner, editor or viewer")
+	}
+	validFor := time.Duration(i.ValidForHours) * time.Hour
+	if i.ValidForHours < 0 || validFor > service.MaxInviteValidity {
+		return "", 0, newFieldError("validForHours", apperror.CodeInvalid,
+			fmt.Sprintf("must be between 1 and %d, or 0 for the default", int(service.MaxInviteValidity.Hours())))
+	}
+	return role, validFor, nil
+}
+
+func (api *APIHandler) createInviteHandler(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	var body CreateInvite
+	err = ctx.BindJSON(&body)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse invite body: %w: %w", errInvalidInput, err))
+		return
+	}
+	role, validFor, err := body.Validate()
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	created, err := api.projectService.CreateInvite(ctx, projectID, role, validFor)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.Header("Location", resourceLocation("projects", projectID.String(), "invites", created.ID.String()))
+	ctx.JSON(http.StatusCreated, InviteFromService(created))
+}
+
+func (api *APIHandler) getInvitesHandler(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	invites, err := api.projectService.GetInvites(ctx, projectID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	inviteList := make([]Invite, 0, len(invites))
+	for _, i := range invites {
+		inviteList = append(inviteList, InviteFromService(i))
+	}
+	ctx.JSON(http.StatusOK, inviteList)
+}
+
+func (api *APIHandler) revokeInviteHandler(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	inviteID, err := uuid.Parse(ctx.Param("inviteId"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid inviteId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	err = api.projectService.RevokeInvite(ctx, projectID, inviteID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// acceptInviteHandler adds the calling user to the project of the invite and returns the project.
+func (api *APIHandler) acceptInviteHandler(ctx *gin.Context) {
+	proj, err := api.projectService.AcceptInvite(ctx, ctx.Param("token"))
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ProjectFromServiceProject(proj))
+}
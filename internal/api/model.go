@@ -3,68 +3,359 @@ package api
 import (
 	"errors"
 	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/Rhymond/go-money"
+	"github.com/diezfx/split-app-backend/internal/apperror"
+	"github.com/diezfx/split-app-backend/internal/costcalc"
 	"github.com/diezfx/split-app-backend/internal/service"
 	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
 )
 
+// DateFormat is the format of all dates without a time of day, e.g. the day a transaction occurred.
+const DateFormat = time.DateOnly
+
+// InvalidArgumentError is a validation error of a single field of a request.
 type InvalidArgumentError struct {
 	Argument string
+	// Code is one of the apperror codes, defaults to apperror.CodeInvalid
+	Code    string
+	Message string
 }
 
 func (a *InvalidArgumentError) Error() string {
-	return fmt.Sprintf("invalid argument %s", a.Argument)
+	if a.Message == "" {
+		return fmt.Sprintf("invalid argument %s", a.Argument)
+	}
+	return fmt.Sprintf("invalid argument %s: %s", a.Argument, a.Message)
+}
+
+func (a *InvalidArgumentError) Unwrap() error {
+	return apperror.Validation
+}
+
+func (a *InvalidArgumentError) FieldErrors() []apperror.FieldError {
+	message := a.Message
+	if message == "" {
+		message = a.Error()
+	}
+	return []apperror.FieldError{{Field: a.Argument, Code: a.Code, Message: message}}
 }
 
 func NewInvalidArgumentError(arg string) *InvalidArgumentError {
-	return &InvalidArgumentError{Argument: arg}
+	return &InvalidArgumentError{Argument: arg, Code: apperror.CodeInvalid}
+}
+
+func newFieldError(arg, code, message string) *InvalidArgumentError {
+	return &InvalidArgumentError{Argument: arg, Code: code, Message: message}
+}
+
+func newRequiredError(arg string) *InvalidArgumentError {
+	return newFieldError(arg, apperror.CodeRequired, "must not be empty")
+}
+
+// hasMaxDecimals reports whether the major unit amount has no more decimals than the currency has minor units.
+func hasMaxDecimals(amount float64, currency string) bool {
+	fraction := 2
+	if c := money.GetCurrency(currency); c != nil {
+		fraction = c.Fraction
+	}
+	scaled := amount * math.Pow10(fraction)
+	// allow for the error of the float representation, e.g. 0.29 * 100 = 28.999999999999996
+	return math.Abs(scaled-math.Round(scaled)) < 1e-6
 }
 
 type AddProject struct {
+	// ID is generated by the server if it is empty
 	ID      string   `json:"id"`
 	Name    string   `json:"name"`
 	Members []string `json:"members"`
+	// Currency is the base currency all costs of the project are calculated in, defaults to EUR
+	Currency string `json:"currency"`
+}
+
+func (p *AddProject) Validate() (service.Project, error) {
+	var err error
+
+	var id uuid.UUID
+	if p.ID != "" {
+		var idErr error
+		id, idErr = uuid.Parse(p.ID)
+		if idErr != nil {
+			err = errors.Join(err, newFieldError("id", apperror.CodeInvalid, "must be a uuid"))
+		}
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		err = errors.Join(err, newRequiredError("name"))
+	}
+	currency, currencyErr := parseCurrency(p.Currency)
+	if currencyErr != nil {
+		err = errors.Join(err, currencyErr)
+	}
+	for i, member := range p.Members {
+		switch {
+		case member == "":
+			err = errors.Join(err, newRequiredError(fmt.Sprintf("members[%d]", i)))
+		case slices.Index(p.Members, member) < i:
+			err = errors.Join(err, newFieldError(fmt.Sprintf("members[%d]", i), apperror.CodeDuplicate, "member is listed twice"))
+		}
+	}
+
+	return service.Project{ID: id, Name: p.Name, Currency: currency, Members: p.Members}, err
+}
+
+// UpdateProject changes the fields that are set, the others are kept.
+type UpdateProject struct {
+	Name     *string `json:"name"`
+	Archived *bool   `json:"archived"`
+}
+
+func (p *UpdateProject) Validate() (service.ProjectUpdate, error) {
+	if p.Name == nil && p.Archived == nil {
+		return service.ProjectUpdate{}, newFieldError("name", apperror.CodeRequired, "either name or archived has to be set")
+	}
+	if p.Name != nil && strings.TrimSpace(*p.Name) == "" {
+		return service.ProjectUpdate{}, newRequiredError("name")
+	}
+	return service.ProjectUpdate{Name: p.Name, Archived: p.Archived}, nil
 }
 
 type AddTransaction struct {
-	ID              string   `json:"id"`
-	Name            string   `json:"name"`
-	TransactionType string   `json:"transactionType"`
-	Amount          float64  `json:"amount"`
-	SourceID        string   `json:"sourceId"`
-	TargetIDs       []string `json:"targetIds"`
+	// ID is generated by the server if it is empty
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	TransactionType string  `json:"transactionType"`
+	Amount          float64 `json:"amount"`
+	// Currency of the amount, defaults to EUR
+	Currency  string   `json:"currency"`
+	SourceID  string   `json:"sourceId"`
+	TargetIDs []string `json:"targetIds"`
+	// SplitMode defaults to an equal split, all other modes need one weight per target
+	SplitMode string    `json:"splitMode"`
+	Weights   []float64 `json:"weights"`
+	// OccurredAt is the day the transaction happened in DateFormat, defaults to today
+	OccurredAt string `json:"occurredAt"`
 }
 
-type GetProjectsQueryParams struct{}
+// MaxBatchSize is the maximum number of transactions that can be added in one batch.
+const MaxBatchSize = 100
+
+type AddTransactions struct {
+	Transactions []AddTransaction `json:"transactions"`
+}
+
+// Validate validates every transaction, the fields of invalid transactions are prefixed with their index.
+func (t *AddTransactions) Validate() ([]service.Transaction, error) {
+	if len(t.Transactions) == 0 || len(t.Transactions) > MaxBatchSize {
+		return nil, NewInvalidArgumentError("transactions")
+	}
+
+	var fields []apperror.FieldError
+	transactions := make([]service.Transaction, 0, len(t.Transactions))
+	seen := make(map[uuid.UUID]bool, len(t.Transactions))
+	for i := range t.Transactions {
+		tx, err := t.Transactions[i].Validate()
+		if err == nil && tx.ID != uuid.Nil && seen[tx.ID] {
+			err = newFieldError("id", apperror.CodeDuplicate, "id is used by another transaction of the batch")
+		}
+		if err != nil {
+			txFields := apperror.Fields(err)
+			if len(txFields) == 0 {
+				txFields = []apperror.FieldError{{Code: apperror.CodeInvalid, Message: err.Error()}}
+			}
+			for _, f := range txFields {
+				f.Field = strings.TrimSuffix(fmt.Sprintf("transactions[%d].%s", i, f.Field), ".")
+				fields = append(fields, f)
+			}
+			continue
+		}
+		seen[tx.ID] = true
+		transactions = append(transactions, tx)
+	}
+	if len(fields) > 0 {
+		return nil, apperror.NewValidationError(fields...)
+	}
+	return transactions, nil
+}
+
+type GetProjectsQueryParams struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+	// Name filters projects containing the name
+	Name string `form:"name"`
+	// Member filters projects the given user is a member of
+	Member string `form:"member"`
+	// IncludeArchived also returns archived projects, they are hidden by default
+	IncludeArchived bool `form:"includeArchived"`
+}
+
+type ProjectView string
+
+const (
+	FullProjectView    ProjectView = "full"
+	NoneProjectView    ProjectView = "none"
+	SummaryProjectView ProjectView = "summary"
+)
+
+type GetProjectQueryParams struct {
+	// Transactions selects if all transactions (full), none or only a summary are returned, defaults to full
+	Transactions ProjectView `form:"transactions"`
+}
+
+type GetTransactionsQueryParams struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+
+	TransactionType string `form:"type"`
+	SourceID        string `form:"source"`
+	TargetID        string `form:"target"`
+	// MinAmount and MaxAmount are in the project currency, they only match transactions in the project currency
+	MinAmount *float64 `form:"minAmount"`
+	MaxAmount *float64 `form:"maxAmount"`
+	// From and To are inclusive and compared to the day the transaction occurred
+	From *time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To   *time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+
+	// SortBy is either date or amount, defaults to date
+	SortBy string `form:"sort"`
+	// Order is either asc or desc, defaults to asc
+	Order string `form:"order"`
+}
+
+func (q *GetTransactionsQueryParams) Validate() (service.TransactionQuery, error) {
+	var err error
+
+	query := service.TransactionQuery{
+		Limit:     q.Limit,
+		Cursor:    q.Cursor,
+		SourceID:  q.SourceID,
+		TargetID:  q.TargetID,
+		MinAmount: q.MinAmount,
+		MaxAmount: q.MaxAmount,
+		From:      q.From,
+	}
+	if q.To != nil {
+		// the service excludes the end of the range
+		to := q.To.AddDate(0, 0, 1)
+		query.To = &to
+	}
+
+	if q.TransactionType != "" {
+		query.TransactionType = service.ParseTransactionType(q.TransactionType)
+		if query.TransactionType == service.UndefinedTransactionType {
+			err = errors.Join(err, NewInvalidArgumentError("type"))
+		}
+	}
+
+	switch service.TransactionSort(q.SortBy) {
+	case "", service.SortByDate:
+		query.SortBy = service.SortByDate
+	case service.SortByAmount:
+		query.SortBy = service.SortByAmount
+	default:
+		err = errors.Join(err, NewInvalidArgumentError("sort"))
+	}
+
+	switch q.Order {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		err = errors.Join(err, NewInvalidArgumentError("order"))
+	}
+
+	return query, err
+}
+
+type GetUserCostsQueryParams struct {
+	// Currency the total costs are converted into, defaults to the preferred currency of the user or EUR
+	Currency string `form:"currency"`
+}
+
+// parseCurrency returns the ISO code of the given currency, an empty string defaults to service.DefaultCurrency.
+func parseCurrency(code string) (string, error) {
+	if code == "" {
+		return service.DefaultCurrency, nil
+	}
+	code = strings.ToUpper(code)
+	if money.GetCurrency(code) == nil {
+		return "", newFieldError("currency", apperror.CodeInvalid, "unknown currency")
+	}
+	return code, nil
+}
 
 func (t *AddTransaction) Validate() (service.Transaction, error) {
 	var err error
 
-	id, err := uuid.Parse(t.ID)
-	if err != nil {
-		err = errors.Join(err, NewInvalidArgumentError("ID"))
+	var id uuid.UUID
+	if t.ID != "" {
+		var idErr error
+		id, idErr = uuid.Parse(t.ID)
+		if idErr != nil {
+			err = errors.Join(err, newFieldError("id", apperror.CodeInvalid, "must be a uuid"))
+		}
 	}
 
 	if t.Name == "" {
-		err = errors.Join(err, NewInvalidArgumentError("Name"))
+		err = errors.Join(err, newRequiredError("name"))
 	}
 	transactionType := service.ParseTransactionType(t.TransactionType)
 	if transactionType == service.UndefinedTransactionType {
-		err = errors.Join(err, NewInvalidArgumentError("TransactionType"))
+		err = errors.Join(err, newFieldError("transactionType", apperror.CodeInvalid, "unknown transaction type"))
 	}
 
-	if t.Amount <= 0 {
-		err = errors.Join(err, NewInvalidArgumentError("Amount"))
+	currency, currencyErr := parseCurrency(t.Currency)
+	if currencyErr != nil {
+		err = errors.Join(err, currencyErr)
+		currency = service.DefaultCurrency
 	}
-	amount := money.NewFromFloat(t.Amount, money.EUR)
+	switch {
+	case t.Amount <= 0:
+		err = errors.Join(err, newFieldError("amount", apperror.CodeInvalid, "must be positive"))
+	case !hasMaxDecimals(t.Amount, currency):
+		err = errors.Join(err, newFieldError("amount", apperror.CodeTooPrecise, "has more decimals than the currency"))
+	}
+	amount := service.FromMajorUnits(t.Amount, currency)
 
 	if t.SourceID == "" {
-		err = errors.Join(err, NewInvalidArgumentError("SourceID"))
+		err = errors.Join(err, newRequiredError("sourceId"))
 	}
 
 	if len(t.TargetIDs) < 1 {
-		err = errors.Join(err, NewInvalidArgumentError("TargetIDs"))
+		err = errors.Join(err, newRequiredError("targetIds"))
+	}
+	for i, target := range t.TargetIDs {
+		field := fmt.Sprintf("targetIds[%d]", i)
+		switch {
+		case target == "":
+			err = errors.Join(err, newRequiredError(field))
+		case slices.Index(t.TargetIDs, target) < i:
+			err = errors.Join(err, newFieldError(field, apperror.CodeDuplicate, "target is listed twice"))
+		}
+	}
+
+	splitMode := service.ParseSplitMode(t.SplitMode)
+	if splitMode == service.UndefinedSplitMode {
+		err = errors.Join(err, newFieldError("splitMode", apperror.CodeInvalid, "unknown split mode"))
+	}
+	weights, weightsErr := weightsToService(splitMode, t.Weights, len(t.TargetIDs), amount)
+	if weightsErr != nil {
+		err = errors.Join(err, weightsErr)
+	}
+
+	var occurredAt time.Time
+	if t.OccurredAt != "" {
+		var dateErr error
+		occurredAt, dateErr = time.Parse(DateFormat, t.OccurredAt)
+		if dateErr != nil {
+			err = errors.Join(err, newFieldError("occurredAt", apperror.CodeInvalid, "must be a date like 2006-01-02"))
+		}
 	}
 
 	return service.Transaction{
@@ -74,16 +365,101 @@ func (t *AddTransaction) Validate() (service.Transaction, error) {
 		Amount:          amount,
 		SourceID:        t.SourceID,
 		TargetIDs:       t.TargetIDs,
+		SplitMode:       splitMode,
+		Weights:         weights,
+		OccurredAt:      occurredAt,
 	}, err
 }
 
+// weightsToService converts the weights given by the client into the integer representation of the service.
+// Shares have to be whole numbers, percentages are converted to hundredths of a percent and have to add up to 100
+// and exact amounts are converted to minor units and have to add up to the total amount.
+func weightsToService(mode service.SplitMode, weights []float64, targetCount int, amount *money.Money) ([]int, error) {
+	switch mode {
+	case service.EqualSplitMode:
+		if len(weights) != 0 {
+			return nil, newFieldError("weights", apperror.CodeInvalid, "must be empty for an equal split")
+		}
+		return nil, nil
+	case service.UndefinedSplitMode:
+		return nil, nil
+	}
+
+	if len(weights) != targetCount {
+		return nil, newFieldError("weights", apperror.CodeInvalid, "needs one weight per target")
+	}
+
+	converted := make([]int, 0, len(weights))
+	sum := 0
+	for i, w := range weights {
+		field := fmt.Sprintf("weights[%d]", i)
+		if w < 0 {
+			return nil, newFieldError(field, apperror.CodeInvalid, "must not be negative")
+		}
+		var c int
+		switch mode {
+		case service.SharesSplitMode:
+			if w != math.Trunc(w) {
+				return nil, newFieldError(field, apperror.CodeInvalid, "must be a whole number")
+			}
+			c = int(w)
+		case service.PercentageSplitMode:
+			c = int(math.Round(w * costcalc.PercentageBase / 100))
+		case service.ExactSplitMode:
+			if !hasMaxDecimals(w, amount.Currency().Code) {
+				return nil, newFieldError(field, apperror.CodeTooPrecise, "has more decimals than the currency")
+			}
+			c = int(service.FromMajorUnits(w, amount.Currency().Code).Amount())
+		}
+		converted = append(converted, c)
+		sum += c
+	}
+
+	switch {
+	case mode == service.SharesSplitMode && sum == 0:
+		return nil, newFieldError("weights", apperror.CodeInvalid, "must not all be zero")
+	case mode == service.PercentageSplitMode && sum != costcalc.PercentageBase:
+		return nil, newFieldError("weights", apperror.CodeInvalid, "must add up to 100")
+	case mode == service.ExactSplitMode && int64(sum) != amount.Amount():
+		return nil, newFieldError("weights", apperror.CodeInvalid, "must add up to the amount")
+	}
+	return converted, nil
+}
+
+// weightsFromService is the inverse of weightsToService, equal splits have no weights.
+func weightsFromService(t *service.Transaction) []float64 {
+	if t.SplitMode == service.EqualSplitMode {
+		return []float64{}
+	}
+	weights := make([]float64, 0, len(t.Weights))
+	for _, w := range t.Weights {
+		switch t.SplitMode {
+		case service.PercentageSplitMode:
+			weights = append(weights, float64(w)*100/costcalc.PercentageBase)
+		case service.ExactSplitMode:
+			weights = append(weights, money.New(int64(w), t.Amount.Currency().Code).AsMajorUnits())
+		default:
+			weights = append(weights, float64(w))
+		}
+	}
+	return weights
+}
+
 type Transaction struct {
 	ID              uuid.UUID               `json:"id"`
 	Name            string                  `json:"name"`
 	TransactionType service.TransactionType `json:"transactionType"`
 	Amount          float64                 `json:"amount"`
+	Currency        string                  `json:"currency"`
 	SourceID        string                  `json:"sourceId"`
 	TargetIDs       []string                `json:"targetIds"`
+	SplitMode       service.SplitMode       `json:"splitMode"`
+	Weights         []float64               `json:"weights"`
+	// OccurredAt is in DateFormat
+	OccurredAt string    `json:"occurredAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	CreatedBy  string    `json:"createdBy"`
 }
 
 func TransactionFromServiceTransaction(t service.Transaction) Transaction {
@@ -92,16 +468,67 @@ func TransactionFromServiceTransaction(t service.Transaction) Transaction {
 		Name:            t.Name,
 		TransactionType: t.TransactionType,
 		Amount:          t.Amount.AsMajorUnits(),
+		Currency:        t.Amount.Currency().Code,
 		SourceID:        t.SourceID,
 		TargetIDs:       t.TargetIDs,
+		SplitMode:       t.SplitMode,
+		Weights:         weightsFromService(&t),
+		OccurredAt:      t.OccurredAt.Format(DateFormat),
+		CreatedAt:       t.CreatedAt,
+		UpdatedAt:       t.UpdatedAt,
+		CreatedBy:       t.CreatedBy,
 	}
 }
 
+type Settlement struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Amount float64 `json:"amount"`
+	// Currency of the amount, defaults to EUR
+	Currency string `json:"currency"`
+}
+
+func SettlementFromService(s service.Settlement) Settlement {
+	return Settlement{From: s.From, To: s.To, Amount: s.Amount.AsMajorUnits(), Currency: s.Amount.Currency().Code}
+}
+
+// AddSettlements contains the settlements that should be recorded, if empty the suggested settlements are used.
+type AddSettlements struct {
+	Settlements []Settlement `json:"settlements"`
+}
+
+func (a *AddSettlements) Validate() ([]service.Settlement, error) {
+	var err error
+
+	settlements := make([]service.Settlement, 0, len(a.Settlements))
+	for i, s := range a.Settlements {
+		if s.From == "" {
+			err = errors.Join(err, newRequiredError(fmt.Sprintf("settlements[%d].from", i)))
+		}
+		if s.To == "" || s.To == s.From {
+			err = errors.Join(err, NewInvalidArgumentError(fmt.Sprintf("settlements[%d].to", i)))
+		}
+		if s.Amount <= 0 {
+			err = errors.Join(err, NewInvalidArgumentError(fmt.Sprintf("settlements[%d].amount", i)))
+		}
+		currency, currencyErr := parseCurrency(s.Currency)
+		if currencyErr != nil {
+			err = errors.Join(err, NewInvalidArgumentError(fmt.Sprintf("settlements[%d].currency", i)))
+			currency = service.DefaultCurrency
+		}
+		settlements = append(settlements, service.Settlement{From: s.From, To: s.To, Amount: service.FromMajorUnits(s.Amount, currency)})
+	}
+	return settlements, err
+}
+
 type Project struct {
 	ID           uuid.UUID     `json:"id"`
 	Name         string        `json:"name"`
+	Currency     string        `json:"currency"`
 	Transactions []Transaction `json:"transactions"`
-	Members      []string      `json:"members"`
+	Members      []User        `json:"members"`
+	Archived     bool          `json:"archived"`
+	ArchivedAt   *time.Time    `json:"archivedAt,omitempty"`
 }
 
 func ProjectFromServiceProject(p service.Project) Project {
@@ -109,32 +536,185 @@ func ProjectFromServiceProject(p service.Project) Project {
 	for _, t := range p.Transactions {
 		transactions = append(transactions, TransactionFromServiceTransaction(t))
 	}
-	return Project{ID: p.ID, Name: p.Name, Transactions: transactions, Members: p.Members}
+	return Project{
+		ID: p.ID, Name: p.Name, Currency: p.Currency, Transactions: transactions, Members: membersFromService(&p),
+		Archived: p.Archived(), ArchivedAt: archivedAt(&p),
+	}
+}
+
+// membersFromService returns the members with their display name and role, like the member endpoints do.
+func membersFromService(p *service.Project) []User {
+	members := make([]User, 0, len(p.Members))
+	for _, m := range p.Members {
+		displayName, ok := p.DisplayNames[m]
+		if !ok {
+			displayName = m
+		}
+		members = append(members, User{ID: m, DisplayName: displayName, Role: string(p.Roles[m])})
+	}
+	return members
 }
 
+func archivedAt(p *service.Project) *time.Time {
+	if !p.Archived() {
+		return nil
+	}
+	return &p.ArchivedAt
+}
+
+// ProjectSummary is returned instead of a Project if the transactions are not requested.
+type ProjectSummary struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Currency string    `json:"currency"`
+	Members  []User    `json:"members"`
+	Archived bool      `json:"archived"`
+	// TransactionSummary is only set for the summary view
+	TransactionSummary *TransactionSummary `json:"transactionSummary,omitempty"`
+}
+
+type TransactionSummary struct {
+	Count       int     `json:"count"`
+	TotalAmount float64 `json:"totalAmount"`
+}
+
+func ProjectSummaryFromService(p service.Project) ProjectSummary {
+	return ProjectSummary{ID: p.ID, Name: p.Name, Currency: p.Currency, Members: membersFromService(&p), Archived: p.Archived()}
+}
+
+func ProjectSummaryWithTransactionsFromService(p service.ProjectSummary) ProjectSummary {
+	summary := ProjectSummaryFromService(p.Project)
+	summary.TransactionSummary = &TransactionSummary{Count: p.TransactionCount, TotalAmount: p.TotalAmount.AsMajorUnits()}
+	return summary
+}
+
+// User is a project member, the id is the only field needed to add one.
 type User struct {
-	ID string `json:"id"`
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName,omitempty"`
+	AvatarURL   string `json:"avatarUrl,omitempty"`
+	// Role is the role in the project, one of owner, editor or viewer. New members are editors by default
+	Role string `json:"role,omitempty"`
+	// Guest is set for members without an account, they can be claimed with POST projects/:id/guests/:guestId/claim
+	Guest bool `json:"guest,omitempty"`
+}
+
+func UserFromService(u service.User) User {
+	return User{ID: u.ID, DisplayName: u.Name(), AvatarURL: u.AvatarURL, Role: string(u.Role), Guest: u.Guest}
+}
+
+// Profile is the full profile of the calling user.
+type Profile struct {
+	ID                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	Email             string `json:"email"`
+	AvatarURL         string `json:"avatarUrl"`
+	PreferredCurrency string `json:"preferredCurrency"`
+}
+
+func ProfileFromService(u service.User) Profile {
+	return Profile{ID: u.ID, DisplayName: u.DisplayName, Email: u.Email, AvatarURL: u.AvatarURL, PreferredCurrency: u.PreferredCurrency}
+}
+
+const maxDisplayNameLength = 100
+
+// UpdateProfile replaces the profile of the calling user, empty fields are cleared.
+type UpdateProfile struct {
+	DisplayName       string `json:"displayName"`
+	Email             string `json:"email"`
+	AvatarURL         string `json:"avatarUrl"`
+	PreferredCurrency string `json:"preferredCurrency"`
+}
+
+func (p *UpdateProfile) Validate() (service.User, error) {
+	var err error
+
+	if utf8.RuneCountInString(p.DisplayName) > maxDisplayNameLength {
+		err = errors.Join(err, newFieldError("displayName", apperror.CodeInvalid,
+			fmt.Sprintf("must not be longer than %d characters", maxDisplayNameLength)))
+	}
+	if p.Email != "" {
+		if address, mailErr := mail.ParseAddress(p.Email); mailErr != nil || address.Address != p.Email {
+			err = errors.Join(err, newFieldError("email", apperror.CodeInvalid, "must be an email address"))
+		}
+	}
+	if p.AvatarURL != "" {
+		if avatar, urlErr := url.Parse(p.AvatarURL); urlErr != nil || (avatar.Scheme != "https" && avatar.Scheme != "http") || avatar.Host == "" {
+			err = errors.Join(err, newFieldError("avatarUrl", apperror.CodeInvalid, "must be an http or https url"))
+		}
+	}
+	currency := ""
+	if p.PreferredCurrency != "" {
+		var currencyErr error
+		currency, currencyErr = parseCurrency(p.PreferredCurrency)
+		if currencyErr != nil {
+			err = errors.Join(err, newFieldError("preferredCurrency", apperror.CodeInvalid, "unknown currency"))
+		}
+	}
+
+	return service.User{
+		DisplayName:       strings.TrimSpace(p.DisplayName),
+		Email:             p.Email,
+		AvatarURL:         p.AvatarURL,
+		PreferredCurrency: currency,
+	}, err
+}
+
+type RemoveProjectUserQueryParams struct {
+	// Force settles the balance of the member with transfers, without it only members with a zero balance are removed
+	Force bool `form:"force"`
+}
+
+func (u *User) Validate() error {
+	var err error
+	if strings.TrimSpace(u.ID) == "" {
+		err = errors.Join(err, newRequiredError("id"))
+	}
+	if service.ParseRole(u.Role) == service.UndefinedRole {
+		err = errors.Join(err, newFieldError("role", apperror.CodeInvalid, "must be owner, editor or viewer"))
+	}
+	return err
 }
 
-type ErrorResponse struct {
-	ErrorCode int
-	Reason    string
+// ProjectUserRole changes the role of a member.
+type ProjectUserRole struct {
+	Role string `json:"role"`
+}
+
+func (r *ProjectUserRole) Validate() (service.Role, error) {
+	if r.Role == "" {
+		return "", newRequiredError("role")
+	}
+	role := service.ParseRole(r.Role)
+	if role == service.UndefinedRole {
+		return "", newFieldError("role", apperror.CodeInvalid, "must be owner, editor or viewer")
+	}
+	return role, nil
 }
 
 type UserCosts struct {
-	TotalCosts   Cost               `json:"totalCosts"`
-	ProjectCosts map[uuid.UUID]Cost `json:"projectCosts"`
+	Currency   string `json:"currency"`
+	TotalCosts Cost   `json:"totalCosts"`
+	// ProjectCosts are in the currency of the project, ConvertedProjectCosts in the requested currency
+	ProjectCosts          map[uuid.UUID]Cost `json:"projectCosts"`
+	ConvertedProjectCosts map[uuid.UUID]Cost `json:"convertedProjectCosts"`
 }
 
 type ProjectCosts struct {
+	Currency    string          `json:"currency"`
 	TotalCosts  float64         `json:"totalCosts"`
 	CostsByUser map[string]Cost `json:"costsByUser"`
+	// OriginalTotalCosts are the unconverted totals per currency
+	OriginalTotalCosts map[string]float64 `json:"originalTotalCosts"`
 }
 
 type Cost struct {
-	Expenses float64 `json:"expenses"`
-	Income   float64 `json:"income"`
-	Balance  float64 `json:"balance"`
+	// DisplayName is only set for the costs of a user within a project
+	DisplayName string  `json:"displayName,omitempty"`
+	Currency    string  `json:"currency"`
+	Expenses    float64 `json:"expenses"`
+	Income      float64 `json:"income"`
+	Balance     float64 `json:"balance"`
 }
 
 func UserCostsFromService(c service.UserCosts) UserCosts {
@@ -142,27 +722,43 @@ func UserCostsFromService(c service.UserCosts) UserCosts {
 	for p, pc := range c.ProjectCosts {
 		projectCosts[p] = CostFromService(pc)
 	}
+	convertedProjectCosts := make(map[uuid.UUID]Cost, len(c.ConvertedProjectCosts))
+	for p, pc := range c.ConvertedProjectCosts {
+		convertedProjectCosts[p] = CostFromService(pc)
+	}
 
 	return UserCosts{
-		TotalCosts:   CostFromService(c.TotalCost),
-		ProjectCosts: projectCosts,
+		Currency:              c.Currency,
+		TotalCosts:            CostFromService(c.TotalCost),
+		ProjectCosts:          projectCosts,
+		ConvertedProjectCosts: convertedProjectCosts,
 	}
 }
 
 func ProjectCostsFromService(cost service.ProjectCosts) ProjectCosts {
 	userCosts := make(map[string]Cost, len(cost.UserCosts))
 	for u, c := range cost.UserCosts {
-		userCosts[u] = CostFromService(c)
+		userCost := CostFromService(c)
+		userCost.DisplayName = cost.DisplayNames[u]
+		userCosts[u] = userCost
+	}
+
+	originalTotalCosts := make(map[string]float64, len(cost.OriginalTotalCosts))
+	for currency, c := range cost.OriginalTotalCosts {
+		originalTotalCosts[currency] = c.AsMajorUnits()
 	}
 
 	return ProjectCosts{
-		TotalCosts:  cost.TotalCost.AsMajorUnits(),
-		CostsByUser: userCosts,
+		Currency:           cost.Currency,
+		TotalCosts:         cost.TotalCost.AsMajorUnits(),
+		CostsByUser:        userCosts,
+		OriginalTotalCosts: originalTotalCosts,
 	}
 }
 
 func CostFromService(c service.Cost) Cost {
 	return Cost{
+		Currency: c.Balance.Currency().Code,
 		Expenses: c.Expenses.AsMajorUnits(),
 		Income:   c.Income.AsMajorUnits(),
 		Balance:  c.Balance.AsMajorUnits(),
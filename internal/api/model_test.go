@@ -0,0 +1,102 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/diezfx/split-app-backend/internal/apperror"
+	"github.com/diezfx/split-app-backend/internal/service"
+	"golang.org/x/exp/slices"
+)
+
+func TestAddTransactionValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   AddTransaction
+
+		expectedAmount  int64
+		expectedWeights []int
+	}{{
+		name:           "amount is rounded",
+		tx:             AddTransaction{Amount: 19.99, TargetIDs: []string{"u1"}},
+		expectedAmount: 1999,
+	}, {
+		name: "exact split",
+		tx: AddTransaction{
+			Amount: 0.58, TargetIDs: []string{"u1", "u2"},
+			SplitMode: string(service.ExactSplitMode), Weights: []float64{0.29, 0.29},
+		},
+		expectedAmount:  58,
+		expectedWeights: []int{29, 29},
+	}, {
+		name:           "currency without minor units",
+		tx:             AddTransaction{Amount: 1000, Currency: "JPY", TargetIDs: []string{"u1"}},
+		expectedAmount: 1000,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.tx.Name = "test"
+			test.tx.TransactionType = string(service.ExpenseTransactionType)
+			test.tx.SourceID = "u1"
+
+			got, err := test.tx.Validate()
+			if err != nil {
+				t.Fatalf("expected no error got %v", err)
+			}
+			if got.Amount.Amount() != test.expectedAmount {
+				t.Errorf("expected amount %d got %d", test.expectedAmount, got.Amount.Amount())
+			}
+			if !slices.Equal(got.Weights, test.expectedWeights) {
+				t.Errorf("expected weights %v got %v", test.expectedWeights, got.Weights)
+			}
+		})
+	}
+}
+
+func TestAddTransactionsValidate(t *testing.T) {
+	valid := AddTransaction{Name: "test", TransactionType: string(service.ExpenseTransactionType), Amount: 10, SourceID: "u1", TargetIDs: []string{"u2"}}
+	invalid := valid
+	invalid.Amount = -1
+	batch := AddTransactions{Transactions: []AddTransaction{valid, invalid}}
+
+	got, err := batch.Validate()
+	if err == nil {
+		t.Fatalf("expected a validation error got transactions %+v", got)
+	}
+	fields := apperror.Fields(err)
+	if len(fields) == 0 {
+		t.Fatalf("expected field errors got %v", err)
+	}
+	for _, f := range fields {
+		if !strings.HasPrefix(f.Field, "transactions[1].") {
+			t.Errorf("expected only errors of the invalid transaction got %+v", f)
+		}
+	}
+}
+
+func TestAddSettlementsValidate(t *testing.T) {
+	settlements := AddSettlements{Settlements: []Settlement{{From: "u1", To: "u2", Amount: 0.29}}}
+
+	got, err := settlements.Validate()
+	if err != nil {
+		t.Fatalf("expected no error got %v", err)
+	}
+	if got[0].Amount.Amount() != 29 {
+		t.Errorf("expected amount 29 got %d", got[0].Amount.Amount())
+	}
+}
+
+func TestProjectFromServiceProjectMembers(t *testing.T) {
+	proj := service.Project{
+		Members:      []string{"u1", "u2"},
+		Roles:        map[string]service.Role{"u1": service.OwnerRole, "u2": service.ViewerRole},
+		DisplayNames: map[string]string{"u1": "Anna"},
+	}
+
+	got := ProjectFromServiceProject(proj).Members
+	expected := []User{{ID: "u1", DisplayName: "Anna", Role: "owner"}, {ID: "u2", DisplayName: "u2", Role: "viewer"}}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected members %+v got %+v", expected, got)
+	}
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/diezfx/split-app-backend/internal/apperror"
+	"github.com/diezfx/split-app-backend/internal/recurrence"
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxRecurrenceInterval allows schedules up to every 365 days
+const maxRecurrenceInterval = 365
+
+// AddRecurringTransaction is a transaction that is added to the project on every occurrence of its schedule.
+// OccurredAt must be empty, every added transaction occurs on its day of the schedule.
+type AddRecurringTransaction struct {
+	AddTransaction
+	// Frequency is daily, weekly or monthly
+	Frequency string `json:"frequency"`
+	// Interval repeats the transaction every Interval days, weeks or months, defaults to 1
+	Interval int `json:"interval"`
+	// StartDate is the first occurrence in DateFormat, defaults to today
+	StartDate string `json:"startDate"`
+	// EndDate is the last day an occurrence may fall on in DateFormat, without it the transaction repeats forever
+	EndDate string `json:"endDate"`
+}
+
+func (r *AddRecurringTransaction) Validate() (service.RecurringTransaction, error) {
+	template, err := r.AddTransaction.Validate()
+	if r.OccurredAt != "" {
+		err = errors.Join(err, newFieldError("occurredAt", apperror.CodeInvalid, "must be empty, use startDate instead"))
+	}
+
+	frequency := recurrence.ParseFrequency(r.Frequency)
+	if frequency == recurrence.UndefinedFrequency {
+		err = errors.Join(err, newFieldError("frequency", apperror.CodeInvalid, "must be daily, weekly or monthly"))
+	}
+	interval := r.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	if interval < 1 || interval > maxRecurrenceInterval {
+		err = errors.Join(err, newFieldError("interval", apperror.CodeInvalid,
+			fmt.Sprintf("must be between 1 and %d", maxRecurrenceInterval)))
+	}
+
+	start := service.Today()
+	if r.StartDate != "" {
+		var dateErr error
+		start, dateErr = time.Parse(DateFormat, r.StartDate)
+		if dateErr != nil {
+			err = errors.Join(err, newFieldError("startDate", apperror.CodeInvalid, "must be a date like 2006-01-02"))
+		}
+	}
+	var end time.Time
+	if r.EndDate != "" {
+		var dateErr error
+		end, dateErr = time.Parse(DateFormat, r.EndDate)
+		switch {
+		case dateErr != nil:
+			err = errors.Join(err, newFieldError("endDate", apperror.CodeInvalid, "must be a date like 2006-01-02"))
+		case end.Before(start):
+			err = errors.Join(err, newFieldError("endDate", apperror.CodeInvalid, "must not be before startDate"))
+		}
+	}
+
+	return service.RecurringTransaction{
+		ID:       template.ID,
+		Template: template,
+		Schedule: recurrence.Schedule{Frequency: frequency, Interval: interval, Start: start, End: end},
+	}, err
+}
+
+type RecurringTransaction struct {
+	ID              uuid.UUID               `json:"id"`
+	Name            string                  `json:"name"`
+	TransactionType service.TransactionType `json:"transactionType"`
+	Amount          float64                 `json:"amount"`
+	Currency        string                  `json:"currency"`
+	SourceID        string                  `json:"sourceId"`
+	TargetIDs       []string                `json:"targetIds"`
+	SplitMode       service.SplitMode       `json:"splitMode"`
+	Weights         []float64               `json:"weights"`
+	Frequency       recurrence.Frequency    `json:"frequency"`
+	Interval        int                     `json:"interval"`
+	// StartDate, EndDate and NextOccurrence are in DateFormat
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate,omitempty"`
+	// NextOccurrence is empty once the schedule ended
+	NextOccurrence string    `json:"nextOccurrence,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	CreatedBy      string    `json:"createdBy"`
+}
+
+func RecurringTransactionFromService(rt service.RecurringTransaction) RecurringTransaction {
+	template := TransactionFromServiceTransaction(rt.Template)
+	return RecurringTransaction{
+		ID: rt.ID, Name: template.Name, TransactionType: template.TransactionType,
+		Amount: template.Amount, Currency: template.Currency, SourceID: template.SourceID, TargetIDs: template.TargetIDs,
+		SplitMode: template.SplitMode, Weights: template.Weights,
+		Frequency: rt.Schedule.Frequency, Interval: rt.Schedule.Interval,
+		StartDate: rt.Schedule.Start.Format(DateFormat), EndDate: formatOptionalDate(rt.Schedule.End),
+		NextOccurrence: formatOptionalDate(rt.NextOccurrence),
+		CreatedAt:      rt.CreatedAt, CreatedBy: rt.CreatedBy,
+	}
+}
+
+func formatOptionalDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(DateFormat)
+}
+
+func (api *APIHandler) addRecurringTransactionHandler(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	var body AddRecurringTransaction
+	err = ctx.BindJSON(&body)
+	if err != nil {
+		handleError(ctx, fmt.Errorf("parse recurring transaction body: %w: %w", errInvalidInput, err))
+		return
+	}
+	rt, err := body.Validate()
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+
+	created, err := api.projectService.AddRecurringTransaction(ctx, projectID, rt)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.Header("Location", resourceLocation("projects", projectID.String(), "recurringTransactions", created.ID.String()))
+	ctx.JSON(http.StatusCreated, RecurringTransactionFromService(created))
+}
+
+func (api *APIHandler) getRecurringTransactionsHandler(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	recurring, err := api.projectService.GetRecurringTransactions(ctx, projectID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	recurringList := make([]RecurringTransaction, 0, len(recurring))
+	for _, rt := range recurring {
+		recurringList = append(recurringList, RecurringTransactionFromService(rt))
+	}
+	ctx.JSON(http.StatusOK, recurringList)
+}
+
+func (api *APIHandler) deleteRecurringTransactionHandler(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid projectId: %w: %w", errInvalidInput, err))
+		return
+	}
+	recurringID, err := uuid.Parse(ctx.Param("recurringId"))
+	if err != nil {
+		handleError(ctx, fmt.Errorf("invalid recurringId: %w: %w", errInvalidInput, err))
+		return
+	}
+
+	err = api.projectService.DeleteRecurringTransaction(ctx, projectID, recurringID)
+	if err != nil {
+		handleError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"time"
 
 	"github.com/diezfx/split-app-backend/internal/service"
 	"github.com/google/uuid"
@@ -9,15 +10,40 @@ import (
 
 type ProjectService interface {
 	GetProjectByID(ctx context.Context, id uuid.UUID) (service.Project, error)
-	GetProjects(ctx context.Context) ([]service.Project, error)
+	GetProjectSummary(ctx context.Context, id uuid.UUID) (service.ProjectSummary, error)
+	GetProjects(ctx context.Context, query service.ProjectQuery) (service.ProjectPage, error)
 	AddProject(ctx context.Context, proj service.Project) (service.Project, error)
+	UpdateProject(ctx context.Context, id uuid.UUID, update service.ProjectUpdate) (service.Project, error)
+	DeleteProject(ctx context.Context, id uuid.UUID) error
 	GetProjectUsers(ctx context.Context, projectID uuid.UUID) ([]service.User, error)
-	AddProjectUser(ctx context.Context, projID uuid.UUID, userID string) error
-	GetCostsByUser(ctx context.Context, userID string) (service.UserCosts, error)
+	AddProjectUser(ctx context.Context, projID uuid.UUID, userID string, role service.Role) error
+	UpdateProjectUserRole(ctx context.Context, projID uuid.UUID, userID string, role service.Role) error
+	RemoveProjectUser(ctx context.Context, projID uuid.UUID, userID string, force bool) ([]service.Transaction, error)
+	AddGuest(ctx context.Context, projID uuid.UUID, name string) (service.User, error)
+	ClaimGuest(ctx context.Context, projID uuid.UUID, guestID string) (service.Project, error)
+	GetCostsByUser(ctx context.Context, userID, currency string) (service.UserCosts, error)
 	GetCostsByProject(ctx context.Context, projID uuid.UUID) (service.ProjectCosts, error)
-	AddTransaction(ctx context.Context, projID uuid.UUID, transaction service.Transaction) error
+	AddTransaction(ctx context.Context, projID uuid.UUID, transaction service.Transaction) (service.Transaction, error)
+	GetTransaction(ctx context.Context, projID, transactionID uuid.UUID) (service.Transaction, error)
+	AddTransactions(ctx context.Context, projID uuid.UUID, transactions []service.Transaction) ([]service.Transaction, error)
+	UpdateTransaction(ctx context.Context, projID uuid.UUID, transaction service.Transaction) (service.Transaction, error)
+	DeleteTransaction(ctx context.Context, projID, transactionID uuid.UUID) error
+	GetTransactions(ctx context.Context, projID uuid.UUID, query service.TransactionQuery) (service.TransactionPage, error)
+	GetSettlements(ctx context.Context, projID uuid.UUID) ([]service.Settlement, error)
+	AddSettlements(ctx context.Context, projID uuid.UUID, settlements []service.Settlement) ([]service.Transaction, error)
+	ExportProject(ctx context.Context, projID uuid.UUID, w service.LedgerWriter) error
+	ImportTransactions(ctx context.Context, projID uuid.UUID, transactions []service.Transaction, dryRun bool) (service.ImportResult, error)
+	AddRecurringTransaction(ctx context.Context, projID uuid.UUID, rt service.RecurringTransaction) (service.RecurringTransaction, error)
+	GetRecurringTransactions(ctx context.Context, projID uuid.UUID) ([]service.RecurringTransaction, error)
+	DeleteRecurringTransaction(ctx context.Context, projID, id uuid.UUID) error
+	CreateInvite(ctx context.Context, projID uuid.UUID, role service.Role, validFor time.Duration) (service.Invite, error)
+	GetInvites(ctx context.Context, projID uuid.UUID) ([]service.Invite, error)
+	RevokeInvite(ctx context.Context, projID, inviteID uuid.UUID) error
+	AcceptInvite(ctx context.Context, token string) (service.Project, error)
 }
 
+// UserService manages the profile of the calling user.
 type UserService interface {
-	GetUsers(ctx context.Context) ([]service.User, error)
+	GetCurrentUser(ctx context.Context) (service.User, error)
+	UpdateCurrentUser(ctx context.Context, user service.User) (service.User, error)
 }
@@ -0,0 +1,104 @@
+// Package apperror classifies errors independent of the layer they occur in.
+// Storage and service errors wrap one of the kinds, so the api can pick the response without knowing every error.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kind is the class of an error, it is an error itself so it can be wrapped and checked with errors.Is.
+type Kind string
+
+const (
+	NotFound   Kind = "not found"
+	Conflict   Kind = "conflict"
+	Validation Kind = "validation failed"
+	Forbidden  Kind = "forbidden"
+	// Disabled is used for features that are turned off by the configuration of the server
+	Disabled Kind = "disabled"
+	Internal Kind = "internal error"
+)
+
+func (k Kind) Error() string {
+	return string(k)
+}
+
+// KindOf returns the kind the error wraps, errors without a kind are internal.
+func KindOf(err error) Kind {
+	for _, kind := range []Kind{Validation, NotFound, Forbidden, Conflict, Disabled} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return Internal
+}
+
+// Codes of field errors, clients may rely on them.
+const (
+	CodeRequired  = "required"
+	CodeInvalid   = "invalid"
+	CodeDuplicate = "duplicate"
+	// CodeTooPrecise is used for amounts with more decimals than their currency has
+	CodeTooPrecise = "too_precise"
+	CodeNotMember  = "not_member"
+)
+
+// FieldError describes why a single field of a request is invalid.
+type FieldError struct {
+	Field string
+	// Code is a stable machine readable reason, one of the Code constants
+	Code    string
+	Message string
+}
+
+// FieldErrorer is implemented by errors that carry details about invalid fields.
+type FieldErrorer interface {
+	FieldErrors() []FieldError
+}
+
+// ValidationError is a Validation error with details for every invalid field.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+func (e *ValidationError) Error() string {
+	details := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		details = append(details, fmt.Sprintf("%s: %s", f.Field, f.Code))
+	}
+	return fmt.Sprintf("%s: %s", Validation, strings.Join(details, ", "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return Validation
+}
+
+func (e *ValidationError) FieldErrors() []FieldError {
+	return e.Fields
+}
+
+// Fields collects the field errors of all errors in the tree of err.
+func Fields(err error) []FieldError {
+	if err == nil {
+		return nil
+	}
+	var fields []FieldError
+	if fe, ok := err.(FieldErrorer); ok {
+		fields = append(fields, fe.FieldErrors()...)
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			fields = append(fields, Fields(inner)...)
+		}
+	case interface{ Unwrap() error }:
+		fields = append(fields, Fields(e.Unwrap())...)
+	}
+	return fields
+}
@@ -0,0 +1,40 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected Kind
+	}{
+		{"wrapped", fmt.Errorf("get project: %w", fmt.Errorf("project %w", NotFound)), NotFound},
+		{"validation error", fmt.Errorf("validate: %w", NewValidationError(FieldError{Field: "name"})), Validation},
+		{"joined", errors.Join(errors.New("other"), Conflict), Conflict},
+		{"unknown", errors.New("boom"), Internal},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if kind := KindOf(test.err); kind != test.expected {
+				t.Errorf("expected kind %q got %q", test.expected, kind)
+			}
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	err := fmt.Errorf("validate: %w: %w", Validation, errors.Join(
+		NewValidationError(FieldError{Field: "name", Code: "required"}),
+		fmt.Errorf("amount: %w", NewValidationError(FieldError{Field: "amount", Code: "invalid"})),
+	))
+
+	fields := Fields(err)
+	if len(fields) != 2 || fields[0].Field != "name" || fields[1].Field != "amount" {
+		t.Errorf("expected the fields name and amount got %+v", fields)
+	}
+}
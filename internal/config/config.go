@@ -1,6 +1,8 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
 	postgrescfg "github.com/diezfx/split-app-backend/internal/config/postgres"
@@ -17,16 +19,39 @@ const (
 	DevelopmentEnv Environment = "dev"
 )
 
+type StorageBackend string
+
+const (
+	PostgresStorage StorageBackend = "postgres"
+	MemoryStorage   StorageBackend = "memory"
+)
+
 type Config struct {
 	Addr        string
 	Environment Environment
 	LogLevel    string
 	Auth        auth.Config
 	DB          postgres.Config
+	// Storage selects where projects are stored, the memory storage loses everything on restart
+	Storage StorageBackend
+	// ExchangeRatesFile is the json file exchange rates are read from, if empty they are read from the database
+	ExchangeRatesFile string
+	// InviteKey signs the tokens of invite links, changing it invalidates all outstanding invites.
+	// It is read from the secret invites/signing-key, without it the server starts with invites disabled.
+	InviteKey string
 }
 
 func Load() (Config, error) {
 	env := os.Getenv("ENVIRONMENT")
+	exchangeRatesFile := os.Getenv("EXCHANGE_RATES_FILE")
+	storageBackend := StorageBackend(os.Getenv("STORAGE_BACKEND"))
+	switch storageBackend {
+	case "":
+		storageBackend = PostgresStorage
+	case PostgresStorage, MemoryStorage:
+	default:
+		return Config{}, fmt.Errorf("unknown storage backend %q", storageBackend)
+	}
 
 	// read from stuff json
 	loader := configloader.NewFileLoader("/etc/config", "/etc/secrets")
@@ -44,12 +69,22 @@ func Load() (Config, error) {
 			return Config{}, err
 		}
 
+		// the secret is optional, so deployments from before invites keep starting without it
+		inviteKey, err := loader.LoadSecret("invites", "signing-key")
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return Config{}, err
+		}
+
 		return Config{
 			Addr:        ":8080",
 			Environment: DevelopmentEnv,
 			LogLevel:    "debug",
 			DB:          pgDB,
 			Auth:        authCfg,
+			Storage:     storageBackend,
+
+			ExchangeRatesFile: exchangeRatesFile,
+			InviteKey:         inviteKey,
 		}, nil
 	}
 
@@ -62,9 +97,28 @@ func Load() (Config, error) {
 			Username: "postgres", Password: "postgres",
 			MigrationsDir: "db/migrations",
 		},
+		Storage:           storageBackend,
+		Auth:              auth.Config{LocalUserID: "user1"},
+		ExchangeRatesFile: exchangeRatesFile,
+		InviteKey:         "local-invite-key",
 	}, nil
 }
 
+// redacted replaces secrets when the config is printed.
+const redacted = "<redacted>"
+
+// String prints the config without its secrets, so it can be logged.
+func (cfg Config) String() string {
+	// plain has no String method, so printing it doesn't recurse
+	type plain Config
+	for _, secret := range []*string{&cfg.InviteKey, &cfg.Auth.Key, &cfg.DB.Password} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	return fmt.Sprintf("%+v", plain(cfg))
+}
+
 func (cfg *Config) IsLocal() bool {
 	return cfg.Environment == LocalEnv
 }
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/diezfx/split-app-backend/pkg/auth"
+	"github.com/diezfx/split-app-backend/pkg/postgres"
+)
+
+func TestConfigStringHidesSecrets(t *testing.T) {
+	cfg := Config{
+		Addr:      ":8080",
+		InviteKey: "invite-secret",
+		Auth:      auth.Config{Key: "auth-secret"},
+		DB:        postgres.Config{Host: "db", Password: "db-secret"},
+	}
+
+	printed := fmt.Sprint(cfg)
+	for _, secret := range []string{"invite-secret", "auth-secret", "db-secret"} {
+		if strings.Contains(printed, secret) {
+			t.Errorf("expected %q to be redacted got %s", secret, printed)
+		}
+	}
+	if !strings.Contains(printed, ":8080") || !strings.Contains(printed, "db") {
+		t.Errorf("expected the other fields to be printed got %s", printed)
+	}
+	if cfg.InviteKey != "invite-secret" {
+		t.Errorf("expected printing not to change the config got %q", cfg.InviteKey)
+	}
+}
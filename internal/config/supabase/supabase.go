@@ -1,15 +1,61 @@
 package supabase
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
 	"github.com/diezfx/split-app-backend/pkg/auth"
 	"github.com/diezfx/split-app-backend/pkg/configloader"
 )
 
 const defaultNamespace = "supabase"
 
+// fileConfig is the optional supabase.json, without it tokens are validated with the jwt-secret.
+type fileConfig struct {
+	// JWKSURL is https://<project>.supabase.co/auth/v1/.well-known/jwks.json for asymmetric signing keys
+	JWKSURL  string `json:"jwksUrl"`
+	JWKSFile string `json:"jwksFile"`
+	// JWKSRefreshInterval and ClockSkew are durations like 10m
+	JWKSRefreshInterval string `json:"jwksRefreshInterval"`
+	Issuer              string `json:"issuer"`
+	Audience            string `json:"audience"`
+	ClockSkew           string `json:"clockSkew"`
+}
+
 func LoadSupabaseConfig(loader *configloader.Loader) (auth.Config, error) {
 	cfg := auth.Config{}
 
+	content, err := loader.LoadConfig(defaultNamespace)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return cfg, err
+	}
+	if err == nil {
+		var fileCfg fileConfig
+		err = json.Unmarshal(content, &fileCfg)
+		if err != nil {
+			return cfg, fmt.Errorf("unmarshal supabase: %w", err)
+		}
+		cfg.JWKSURL = fileCfg.JWKSURL
+		cfg.JWKSFile = fileCfg.JWKSFile
+		cfg.Issuer = fileCfg.Issuer
+		cfg.Audience = fileCfg.Audience
+		cfg.JWKSRefreshInterval, err = parseDuration(fileCfg.JWKSRefreshInterval)
+		if err != nil {
+			return cfg, fmt.Errorf("parse jwksRefreshInterval: %w", err)
+		}
+		cfg.ClockSkew, err = parseDuration(fileCfg.ClockSkew)
+		if err != nil {
+			return cfg, fmt.Errorf("parse clockSkew: %w", err)
+		}
+	}
+
+	// the shared secret is only needed for projects still signing with HS256
+	if cfg.JWKSURL != "" || cfg.JWKSFile != "" {
+		return cfg, nil
+	}
 	key, err := loader.LoadSecret(defaultNamespace, "jwt-secret")
 	if err != nil {
 		return cfg, err
@@ -17,3 +63,10 @@ func LoadSupabaseConfig(loader *configloader.Loader) (auth.Config, error) {
 	cfg.Key = key
 	return cfg, nil
 }
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
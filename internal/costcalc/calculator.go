@@ -9,7 +9,10 @@ import (
 )
 
 type Calculator struct {
-	edges []Edge
+	currency string
+	edges    []Edge
+	// pairs maps a source and a target to the index of their edge
+	pairs map[[2]string]int
 }
 
 type (
@@ -17,15 +20,43 @@ type (
 	Creditor string
 )
 
-func New(txs []Transaction) *Calculator {
-	return &Calculator{edges: TransformTransactionsToCostEdges(txs)}
+// New creates a calculator for the given transactions, all of them have to be in the given currency.
+// Returns ErrInvalidSplit if one of the transactions can't be split between its targets.
+func New(currency string, txs []Transaction) (*Calculator, error) {
+	c := &Calculator{currency: currency, pairs: map[[2]string]int{}}
+	if _, err := c.Add(txs...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Add splits the transactions and adds them to the calculator, it returns the edges of the added transactions.
+// Edges between the same source and target are merged, so the calculator only grows with the number of users
+// and transactions can be added page by page.
+func (c *Calculator) Add(txs ...Transaction) ([]Edge, error) {
+	edges, err := TransformTransactionsToCostEdges(txs)
+	if err != nil {
+		return nil, err
+	}
+	for _, edge := range edges {
+		pair := [2]string{edge.Source, edge.Target}
+		i, ok := c.pairs[pair]
+		if !ok {
+			c.pairs[pair] = len(c.edges)
+			c.edges = append(c.edges, edge)
+			continue
+		}
+		merged, err := c.edges[i].Amount.Add(edge.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("add edge from %s to %s: %w", edge.Source, edge.Target, err)
+		}
+		c.edges[i].Amount = merged
+	}
+	return edges, nil
 }
 
 func (c *Calculator) CalculateCostForUser(userID string) (*Cost, error) {
-	cost := &Cost{
-		Expenses: money.New(0, money.EUR),
-		Income:   money.New(0, money.EUR),
-		Balance:  money.New(0, money.EUR)}
+	cost := c.zeroCost()
 
 	for _, tx := range c.edges {
 		// add to expense when source
@@ -56,7 +87,7 @@ func (c *Calculator) CalculateCostForUser(userID string) (*Cost, error) {
 }
 
 func (c *Calculator) CalculateCostForAllUsers() (*ProjectCost, error) {
-	totalCost := money.New(0, money.EUR)
+	totalCost := money.New(0, c.currency)
 
 	userCosts := map[string]*Cost{}
 
@@ -69,12 +100,12 @@ func (c *Calculator) CalculateCostForAllUsers() (*ProjectCost, error) {
 
 		source := userCosts[tx.Source]
 		if source == nil {
-			source = &Cost{Expenses: money.New(0, money.EUR), Income: money.New(0, money.EUR), Balance: money.New(0, money.EUR)}
+			source = c.zeroCost()
 			userCosts[tx.Source] = source
 		}
 		target := userCosts[tx.Target]
 		if target == nil {
-			target = &Cost{Expenses: money.New(0, money.EUR), Income: money.New(0, money.EUR), Balance: money.New(0, money.EUR)}
+			target = c.zeroCost()
 			userCosts[tx.Target] = target
 		}
 
@@ -105,7 +136,7 @@ func (c *Calculator) CalculateCostForAllUsers() (*ProjectCost, error) {
 func (c *Calculator) CalculateMinCostFlow() []Edge {
 	optEdges := []Edge{}
 
-	userBalances := calculateBalances(c.edges)
+	userBalances := calculateBalances(c.currency, c.edges)
 
 	for {
 		maxDebitor := getMaxDebitor(userBalances)
@@ -125,15 +156,23 @@ func (c *Calculator) CalculateMinCostFlow() []Edge {
 	}
 }
 
-func calculateBalances(edges []Edge) map[string]*money.Money {
+func (c *Calculator) zeroCost() *Cost {
+	return &Cost{
+		Expenses: money.New(0, c.currency),
+		Income:   money.New(0, c.currency),
+		Balance:  money.New(0, c.currency),
+	}
+}
+
+func calculateBalances(currency string, edges []Edge) map[string]*money.Money {
 	userBalance := map[string]*money.Money{}
 
 	for _, edge := range edges {
 		if userBalance[edge.Source] == nil {
-			userBalance[edge.Source] = money.New(0, money.EUR)
+			userBalance[edge.Source] = money.New(0, currency)
 		}
 		if userBalance[edge.Target] == nil {
-			userBalance[edge.Target] = money.New(0, money.EUR)
+			userBalance[edge.Target] = money.New(0, currency)
 		}
 
 		newMoneyVal, _ := userBalance[edge.Source].Add(edge.Amount)
@@ -1,6 +1,7 @@
 package costcalc
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/Rhymond/go-money"
@@ -35,7 +36,10 @@ func TestCalculator(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			calculator := New(test.transactions)
+			calculator, err := New(money.EUR, test.transactions)
+			if err != nil {
+				t.Fatalf("unexpected error splitting: %s", err)
+			}
 			result, err := calculator.CalculateCostForUser(userID)
 			if err != nil {
 				t.Fatalf("unexpected error calculating: %s", err)
@@ -87,7 +91,10 @@ func TestMinCostFlow(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			calculator := New(test.transactions)
+			calculator, err := New(money.EUR, test.transactions)
+			if err != nil {
+				t.Fatalf("unexpected error splitting: %s", err)
+			}
 			resultFlow := calculator.CalculateMinCostFlow()
 
 			compareEdges(t, resultFlow, test.expectedCashFlow)
@@ -114,3 +121,129 @@ func compareEdges(t *testing.T, actual, expected []Edge) {
 		}
 	}
 }
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name        string
+		transaction Transaction
+
+		expectedSplit []int64
+		expectErr     bool
+	}{{
+		name:          "equal",
+		transaction:   Transaction{TargetIDs: []string{"u1", "u2", "u3"}, Amount: money.New(100, money.EUR)},
+		expectedSplit: []int64{34, 33, 33},
+	}, {
+		name: "shares",
+		transaction: Transaction{
+			TargetIDs: []string{"u1", "u2"}, Amount: money.New(90, money.EUR),
+			SplitMode: SharesSplit, Weights: []int{2, 1},
+		},
+		expectedSplit: []int64{60, 30},
+	}, {
+		name: "percentage",
+		transaction: Transaction{
+			TargetIDs: []string{"u1", "u2"}, Amount: money.New(1000, money.EUR),
+			SplitMode: PercentageSplit, Weights: []int{6000, 4000},
+		},
+		expectedSplit: []int64{600, 400},
+	}, {
+		name: "percentage not 100",
+		transaction: Transaction{
+			TargetIDs: []string{"u1", "u2"}, Amount: money.New(1000, money.EUR),
+			SplitMode: PercentageSplit, Weights: []int{6000, 3000},
+		},
+		expectErr: true,
+	}, {
+		name: "exact",
+		transaction: Transaction{
+			TargetIDs: []string{"u1", "u2"}, Amount: money.New(1000, money.EUR),
+			SplitMode: ExactSplit, Weights: []int{999, 1},
+		},
+		expectedSplit: []int64{999, 1},
+	}, {
+		name: "exact not matching total",
+		transaction: Transaction{
+			TargetIDs: []string{"u1", "u2"}, Amount: money.New(1000, money.EUR),
+			SplitMode: ExactSplit, Weights: []int{999, 2},
+		},
+		expectErr: true,
+	}, {
+		name: "missing weights",
+		transaction: Transaction{
+			TargetIDs: []string{"u1", "u2"}, Amount: money.New(1000, money.EUR),
+			SplitMode: SharesSplit, Weights: []int{1},
+		},
+		expectErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := test.transaction.Split()
+			if test.expectErr {
+				if !errors.Is(err, ErrInvalidSplit) {
+					t.Fatalf("expected invalid split error got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error splitting: %s", err)
+			}
+			if len(result) != len(test.expectedSplit) {
+				t.Fatalf("expected %d parts got %d", len(test.expectedSplit), len(result))
+			}
+			for i, expected := range test.expectedSplit {
+				if result[i].Amount() != expected {
+					t.Errorf("expected amount %d for target %d got %d", expected, i, result[i].Amount())
+				}
+			}
+		})
+	}
+}
+
+func TestNewInvalidSplit(t *testing.T) {
+	txs := []Transaction{
+		{SourceID: "u1", TargetIDs: []string{"u2"}, Amount: money.New(10, money.EUR)},
+		{SourceID: "u1", TargetIDs: []string{"u2"}, Amount: money.New(10, money.EUR), SplitMode: ExactSplit, Weights: []int{9}},
+	}
+
+	_, err := New(money.EUR, txs)
+	if !errors.Is(err, ErrInvalidSplit) {
+		t.Fatalf("expected invalid split error got %v", err)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	txs := []Transaction{
+		{SourceID: "u1", TargetIDs: []string{"u2", "u3"}, Amount: money.New(30, money.EUR)},
+		{SourceID: "u1", TargetIDs: []string{"u2"}, Amount: money.New(10, money.EUR)},
+		{SourceID: "u2", TargetIDs: []string{"u1"}, Amount: money.New(5, money.EUR)},
+	}
+
+	calculator, err := New(money.EUR, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating: %s", err)
+	}
+	for _, tx := range txs {
+		if _, err := calculator.Add(tx); err != nil {
+			t.Fatalf("unexpected error adding: %s", err)
+		}
+	}
+	if len(calculator.edges) != 3 {
+		t.Errorf("expected edges between the same users to be merged got %v", calculator.edges)
+	}
+
+	result, err := calculator.CalculateCostForAllUsers()
+	if err != nil {
+		t.Fatalf("unexpected error calculating: %s", err)
+	}
+	if result.TotalCost.Amount() != 45 {
+		t.Errorf("expected total cost 45 got %d", result.TotalCost.Amount())
+	}
+	expectedBalances := map[string]int64{"u1": 35, "u2": -20, "u3": -15}
+	for user, expected := range expectedBalances {
+		if got := result.CostPerUser[user].Balance.Amount(); got != expected {
+			t.Errorf("expected balance %d for %s got %d", expected, user, got)
+		}
+	}
+}
@@ -1,27 +1,50 @@
 package costcalc
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/Rhymond/go-money"
 	"github.com/google/uuid"
 )
 
+// PercentageBase is the sum the weights of a PercentageSplit have to add up to.
+// Percentages are stored as hundredths of a percent, so 33.5% is represented as 3350.
+const PercentageBase = 10000
+
+var ErrInvalidSplit = errors.New("invalid split")
+
+type SplitMode string
+
+const (
+	EqualSplit      SplitMode = "Equal"
+	SharesSplit     SplitMode = "Shares"
+	PercentageSplit SplitMode = "Percentage"
+	ExactSplit      SplitMode = "Exact"
+)
+
 type Edge struct {
 	Source string
 	Target string
 	Amount *money.Money
 }
 
-func TransformTransactionsToCostEdges(txs []Transaction) []Edge {
+// TransformTransactionsToCostEdges returns one edge per target of every transaction.
+// A transaction that can't be split fails the whole transformation, leaving it out would silently change all balances.
+func TransformTransactionsToCostEdges(txs []Transaction) ([]Edge, error) {
 	edges := []Edge{}
 
 	for _, tx := range txs {
-		splitVals, _ := tx.Amount.Split(len(tx.TargetIDs))
+		splitVals, err := tx.Split()
+		if err != nil {
+			return nil, fmt.Errorf("split transaction %s: %w", tx.ID, err)
+		}
 		for i, splitValue := range splitVals {
 			edges = append(edges, Edge{Source: tx.SourceID, Target: tx.TargetIDs[i], Amount: splitValue})
 		}
 	}
 
-	return edges
+	return edges, nil
 }
 
 type Transaction struct {
@@ -30,6 +53,54 @@ type Transaction struct {
 	Amount    *money.Money
 	SourceID  string
 	TargetIDs []string
+	SplitMode SplitMode
+	// Weights holds one entry per target, the meaning depends on the SplitMode:
+	// number of shares, hundredths of a percent or the exact amount in minor units.
+	Weights []int
+}
+
+// Split divides the amount of the transaction between its targets according to the split mode.
+// The returned slice has the same order as TargetIDs.
+func (tx *Transaction) Split() ([]*money.Money, error) {
+	if tx.SplitMode == "" || tx.SplitMode == EqualSplit {
+		return tx.Amount.Split(len(tx.TargetIDs))
+	}
+
+	if len(tx.Weights) != len(tx.TargetIDs) {
+		return nil, fmt.Errorf("%d weights for %d targets: %w", len(tx.Weights), len(tx.TargetIDs), ErrInvalidSplit)
+	}
+
+	sum := 0
+	for _, w := range tx.Weights {
+		if w < 0 {
+			return nil, fmt.Errorf("negative weight %d: %w", w, ErrInvalidSplit)
+		}
+		sum += w
+	}
+
+	switch tx.SplitMode {
+	case SharesSplit:
+		if sum == 0 {
+			return nil, fmt.Errorf("no shares given: %w", ErrInvalidSplit)
+		}
+		return tx.Amount.Allocate(tx.Weights...)
+	case PercentageSplit:
+		if sum != PercentageBase {
+			return nil, fmt.Errorf("percentages sum up to %d instead of %d: %w", sum, PercentageBase, ErrInvalidSplit)
+		}
+		return tx.Amount.Allocate(tx.Weights...)
+	case ExactSplit:
+		if int64(sum) != tx.Amount.Amount() {
+			return nil, fmt.Errorf("exact amounts sum up to %d instead of %d: %w", sum, tx.Amount.Amount(), ErrInvalidSplit)
+		}
+		splitVals := make([]*money.Money, 0, len(tx.Weights))
+		for _, w := range tx.Weights {
+			splitVals = append(splitVals, money.New(int64(w), tx.Amount.Currency().Code))
+		}
+		return splitVals, nil
+	default:
+		return nil, fmt.Errorf("unknown split mode %q: %w", tx.SplitMode, ErrInvalidSplit)
+	}
 }
 
 type Cost struct {
@@ -0,0 +1,429 @@
+// Package csvimport reads the csv exports of other expense sharing apps.
+//
+// Splitwise exports have the columns Date, Description, Category, Cost, Currency followed by one column per participant
+// holding what the participant paid minus their share. Rows of the category Payment are transfers between two participants.
+//
+// Tricount exports have the columns Title, Amount, Currency, Date (or Date & time), Paid by and an optional Type,
+// followed by one "Impacted to <participant>" column per participant holding their share.
+//
+// Ledger exports are the csv exports of this app, see LedgerTransactionColumns.
+package csvimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+type Format string
+
+const (
+	SplitwiseFormat Format = "splitwise"
+	TricountFormat  Format = "tricount"
+	LedgerFormat    Format = "ledger"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown format")
+	ErrMissingColumn = errors.New("missing column")
+	ErrInvalidRow    = errors.New("invalid row")
+)
+
+const tricountParticipantPrefix = "impacted to "
+
+var dateLayouts = []string{time.DateOnly, time.DateTime, "2006-01-02 15:04", "02/01/2006", "02/01/2006 15:04"}
+
+// Transaction is one expense or transfer read from an export.
+type Transaction struct {
+	// Line is the line in the csv file, the header is line 1
+	Line       int
+	Name       string
+	OccurredAt time.Time
+	Transfer   bool
+	Amount     *money.Money
+	Payer      string
+	// Shares are the amounts in minor units the participants have to pay, aligned with Participants
+	Participants []string
+	Shares       []int64
+}
+
+// RowError is a row that could not be read, the other rows are still returned.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+type Result struct {
+	Transactions []Transaction
+	Errors       []RowError
+	// Participants are all participants of the export in the order of their columns
+	Participants []string
+}
+
+// Parse reads the whole export. An error is only returned if the file itself can't be read,
+// problems with single rows are reported in Result.Errors.
+func Parse(r io.Reader, format Format) (Result, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return Result{}, fmt.Errorf("read header: %w", err)
+	}
+
+	var p parser
+	switch format {
+	case SplitwiseFormat:
+		p, err = newSplitwiseParser(header)
+	case TricountFormat:
+		p, err = newTricountParser(header)
+	case LedgerFormat:
+		p, err = newLedgerParser(header)
+	default:
+		return Result{}, fmt.Errorf("%q: %w", format, ErrUnknownFormat)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+	addResult := func(line int, tx Transaction, ok bool, err error) {
+		var rowErr *RowError
+		switch {
+		case errors.As(err, &rowErr):
+			result.Errors = append(result.Errors, RowError{Line: rowErr.Line, Err: fmt.Errorf("%w: %w", ErrInvalidRow, rowErr.Err)})
+		case err != nil:
+			result.Errors = append(result.Errors, RowError{Line: line, Err: fmt.Errorf("%w: %w", ErrInvalidRow, err)})
+		case ok:
+			if tx.Line == 0 {
+				tx.Line = line
+			}
+			result.Transactions = append(result.Transactions, tx)
+		}
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			result.Errors = append(result.Errors, RowError{Line: parseErr.Line, Err: fmt.Errorf("%w: %w", ErrInvalidRow, err)})
+			continue
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("read row: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if isEmpty(record) {
+			continue
+		}
+
+		tx, ok, err := p.parse(line, record)
+		addResult(line, tx, ok, err)
+	}
+	if g, ok := p.(groupParser); ok {
+		tx, ok, err := g.flush()
+		addResult(0, tx, ok, err)
+	}
+	result.Participants = p.participants()
+	return result, nil
+}
+
+type parser interface {
+	participants() []string
+	// parse returns false for rows that contain no transaction.
+	// Transactions without a Line and errors that are no RowError belong to the given line.
+	parse(line int, record []string) (Transaction, bool, error)
+}
+
+// groupParser is a parser for formats that spread one transaction over several rows,
+// parse returns a transaction once the first row of the next one is read.
+type groupParser interface {
+	parser
+	// flush returns the transaction of the last rows
+	flush() (Transaction, bool, error)
+}
+
+// columns maps the lower case header names to their index.
+type columns map[string]int
+
+func newColumns(header []string) columns {
+	cols := make(columns, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	return cols
+}
+
+// index returns the index of the first of the given names that exists.
+func (c columns) index(names ...string) (int, error) {
+	for _, name := range names {
+		if i, ok := c[name]; ok {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%s: %w", names[0], ErrMissingColumn)
+}
+
+type splitwiseParser struct {
+	date, description, category, cost, currency int
+	participantNames                            []string
+	// participantColumns are aligned with participantNames
+	participantColumns []int
+}
+
+func newSplitwiseParser(header []string) (*splitwiseParser, error) {
+	cols := newColumns(header)
+	var p splitwiseParser
+	var err, colErr error
+	for _, col := range []struct {
+		index *int
+		name  string
+	}{{&p.date, "date"}, {&p.description, "description"}, {&p.category, "category"}, {&p.cost, "cost"}, {&p.currency, "currency"}} {
+		*col.index, colErr = cols.index(col.name)
+		err = errors.Join(err, colErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// all columns after the currency are participants
+	for i := p.currency + 1; i < len(header); i++ {
+		p.participantNames = append(p.participantNames, strings.TrimSpace(header[i]))
+		p.participantColumns = append(p.participantColumns, i)
+	}
+	if len(p.participantNames) == 0 {
+		return nil, fmt.Errorf("participants: %w", ErrMissingColumn)
+	}
+	return &p, nil
+}
+
+func (p *splitwiseParser) participants() []string {
+	return p.participantNames
+}
+
+func (p *splitwiseParser) parse(_ int, record []string) (Transaction, bool, error) {
+	// the last row of an export holds the total balance of every participant
+	if field(record, p.date) == "" && strings.EqualFold(field(record, p.description), "total balance") {
+		return Transaction{}, false, nil
+	}
+
+	tx, err := parseCommon(field(record, p.description), field(record, p.date), field(record, p.cost), field(record, p.currency))
+	if err != nil {
+		return Transaction{}, false, err
+	}
+	tx.Transfer = strings.EqualFold(field(record, p.category), "payment")
+
+	// every participant column holds what they paid minus their share
+	paid := make([]int64, 0, len(p.participantColumns))
+	for i, col := range p.participantColumns {
+		value, err := parseAmount(field(record, col), tx.Amount.Currency())
+		if err != nil {
+			return Transaction{}, false, fmt.Errorf("%s: %w", p.participantNames[i], err)
+		}
+		paid = append(paid, value)
+	}
+
+	payer := -1
+	for i, value := range paid {
+		if value <= 0 {
+			continue
+		}
+		if payer != -1 {
+			return Transaction{}, false, errors.New("more than one participant paid")
+		}
+		payer = i
+	}
+	if payer == -1 {
+		return Transaction{}, false, errors.New("no participant paid")
+	}
+	tx.Payer = p.participantNames[payer]
+
+	for i, value := range paid {
+		share := -value
+		if i == payer {
+			share = tx.Amount.Amount() - value
+		}
+		if share > 0 {
+			tx.Participants = append(tx.Participants, p.participantNames[i])
+			tx.Shares = append(tx.Shares, share)
+		}
+	}
+	return tx, true, checkShares(&tx)
+}
+
+type tricountParser struct {
+	title, amount, currency, date, paidBy int
+	// transactionType is -1 if the export has no type column
+	transactionType    int
+	participantNames   []string
+	participantColumns []int
+}
+
+func newTricountParser(header []string) (*tricountParser, error) {
+	cols := newColumns(header)
+	var p tricountParser
+	var err, colErr error
+	for _, col := range []struct {
+		index *int
+		names []string
+	}{
+		{&p.title, []string{"title"}},
+		{&p.amount, []string{"amount"}},
+		{&p.currency, []string{"currency"}},
+		{&p.date, []string{"date", "date & time"}},
+		{&p.paidBy, []string{"paid by"}},
+	} {
+		*col.index, colErr = cols.index(col.names...)
+		err = errors.Join(err, colErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.transactionType, colErr = cols.index("type")
+	if colErr != nil {
+		p.transactionType = -1
+	}
+
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if strings.HasPrefix(strings.ToLower(name), tricountParticipantPrefix) {
+			p.participantNames = append(p.participantNames, strings.TrimSpace(name[len(tricountParticipantPrefix):]))
+			p.participantColumns = append(p.participantColumns, i)
+		}
+	}
+	if len(p.participantNames) == 0 {
+		return nil, fmt.Errorf("%s<participant>: %w", tricountParticipantPrefix, ErrMissingColumn)
+	}
+	return &p, nil
+}
+
+func (p *tricountParser) participants() []string {
+	return p.participantNames
+}
+
+func (p *tricountParser) parse(_ int, record []string) (Transaction, bool, error) {
+	tx, err := parseCommon(field(record, p.title), field(record, p.date), field(record, p.amount), field(record, p.currency))
+	if err != nil {
+		return Transaction{}, false, err
+	}
+	if p.transactionType != -1 {
+		tx.Transfer = strings.EqualFold(field(record, p.transactionType), "money transfer")
+	}
+
+	tx.Payer = field(record, p.paidBy)
+	if tx.Payer == "" {
+		return Transaction{}, false, errors.New("no participant paid")
+	}
+
+	for i, col := range p.participantColumns {
+		share, err := parseAmount(field(record, col), tx.Amount.Currency())
+		if err != nil {
+			return Transaction{}, false, fmt.Errorf("%s: %w", p.participantNames[i], err)
+		}
+		// expenses are negative in some exports
+		if share < 0 {
+			share = -share
+		}
+		if share > 0 {
+			tx.Participants = append(tx.Participants, p.participantNames[i])
+			tx.Shares = append(tx.Shares, share)
+		}
+	}
+	return tx, true, checkShares(&tx)
+}
+
+// parseCommon reads the fields every format has.
+func parseCommon(name, date, amount, currencyCode string) (Transaction, error) {
+	if name == "" {
+		return Transaction{}, errors.New("empty name")
+	}
+	occurredAt, err := parseDate(date)
+	if err != nil {
+		return Transaction{}, err
+	}
+	currency := money.GetCurrency(strings.ToUpper(currencyCode))
+	if currency == nil {
+		return Transaction{}, fmt.Errorf("unknown currency %q", currencyCode)
+	}
+	value, err := parseAmount(amount, currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+	if value < 0 {
+		value = -value
+	}
+	if value == 0 {
+		return Transaction{}, errors.New("amount is zero")
+	}
+	return Transaction{Name: name, OccurredAt: occurredAt, Amount: money.New(value, currency.Code)}, nil
+}
+
+func parseDate(date string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, date)
+		if err == nil {
+			return t.Truncate(24 * time.Hour), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", date)
+}
+
+// parseAmount returns the amount in minor units of the currency, empty fields are zero.
+func parseAmount(amount string, currency *money.Currency) (int64, error) {
+	if amount == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	return int64(math.Round(f * math.Pow10(currency.Fraction))), nil
+}
+
+func checkShares(tx *Transaction) error {
+	if tx.Transfer && len(tx.Participants) != 1 {
+		return fmt.Errorf("transfer to %d participants", len(tx.Participants))
+	}
+	var sum int64
+	for _, share := range tx.Shares {
+		sum += share
+	}
+	if sum != tx.Amount.Amount() {
+		return fmt.Errorf("shares add up to %d instead of %d", sum, tx.Amount.Amount())
+	}
+	return nil
+}
+
+func field(record []string, i int) string {
+	if i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func isEmpty(record []string) bool {
+	for _, f := range record {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
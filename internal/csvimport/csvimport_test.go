@@ -0,0 +1,139 @@
+package csvimport
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		csv    string
+
+		expected       []Transaction
+		expectedErrors []int
+	}{{
+		name:   "splitwise",
+		format: SplitwiseFormat,
+		csv: `Date,Description,Category,Cost,Currency,Anna,Ben,Carl
+
+2024-05-01,Dinner,Dining out,30.00,EUR,20.00,-10.00,-10.00
+2024-05-02,Settle up,Payment,10.00,EUR,-10.00,10.00,0.00
+2024-05-03,Taxi,Transportation,abc,EUR,1.00,-1.00,0.00
+ ,Total balance, , ,EUR,10.00,0.00,-10.00
+`,
+		expected: []Transaction{{
+			Line: 3, Name: "Dinner", Payer: "Anna",
+			Participants: []string{"Anna", "Ben", "Carl"}, Shares: []int64{1000, 1000, 1000},
+		}, {
+			Line: 4, Name: "Settle up", Payer: "Ben", Transfer: true,
+			Participants: []string{"Anna"}, Shares: []int64{1000},
+		}},
+		expectedErrors: []int{5},
+	}, {
+		name:   "splitwise multiple payers",
+		format: SplitwiseFormat,
+		csv: `Date,Description,Category,Cost,Currency,Anna,Ben
+2024-05-01,Dinner,Dining out,30.00,EUR,15.00,15.00
+`,
+		expectedErrors: []int{2},
+	}, {
+		name:   "tricount",
+		format: TricountFormat,
+		csv: `Title,Amount,Currency,Date & time,Paid by,Type,Impacted to Anna,Impacted to Ben
+Groceries,-12.50,EUR,2024-05-01 18:30:00,Ben,Normal,-6.25,-6.25
+Refund,5,EUR,2024-05-02,Anna,Money transfer,0,5
+Hotel,100,EUR,2024-05-03,Anna,Normal,40,40
+`,
+		expected: []Transaction{{
+			Line: 2, Name: "Groceries", Payer: "Ben",
+			Participants: []string{"Anna", "Ben"}, Shares: []int64{625, 625},
+		}, {
+			Line: 3, Name: "Refund", Payer: "Anna", Transfer: true,
+			Participants: []string{"Ben"}, Shares: []int64{500},
+		}},
+		expectedErrors: []int{4},
+	}, {
+		name:   "ledger",
+		format: LedgerFormat,
+		csv: `transaction_id,occurred_at,name,transaction_type,amount,currency,source_id,split_mode,target_id,weight,share,share_currency
+t1,2024-05-01,Rent,Expense,90,EUR,u1,Shares,u2,2,60,EUR
+t1,2024-05-01,Rent,Expense,90,EUR,u1,Shares,u3,1,30,EUR
+t2,2024-05-02,Dinner,Expense,10,EUR,u2,Equal,u1,,3.34,EUR
+t2,2024-05-02,Dinner,Expense,10,EUR,u2,Equal,u2,,3.33,EUR
+t2,2024-05-02,Dinner,Expense,10,EUR,u2,Equal,u3,,3.33,EUR
+t3,2024-05-03,Taxi,Expense,20,EUR,u3,Exact,u1,15,15,EUR
+t3,2024-05-03,Taxi,Expense,20,EUR,u3,Exact,u2,1,1,EUR
+t4,2024-05-04,Hotel,Expense,1000,JPY,u1,Percentage,u2,25,1.5,EUR
+t4,2024-05-04,Hotel,Expense,1000,JPY,u1,Percentage,u3,75,4.5,EUR
+t5,2024-05-05,Settle up,Transfer,60,EUR,u2,Equal,u1,,60,EUR
+
+user_id,expenses,income,balance,currency
+u1,96,63.34,32.66,EUR
+`,
+		expected: []Transaction{{
+			Line: 2, Name: "Rent", Payer: "u1",
+			Participants: []string{"u2", "u3"}, Shares: []int64{6000, 3000},
+		}, {
+			Line: 4, Name: "Dinner", Payer: "u2",
+			Participants: []string{"u1", "u2", "u3"}, Shares: []int64{334, 333, 333},
+		}, {
+			Line: 9, Name: "Hotel", Payer: "u1",
+			Participants: []string{"u2", "u3"}, Shares: []int64{250, 750},
+		}, {
+			Line: 11, Name: "Settle up", Payer: "u2", Transfer: true,
+			Participants: []string{"u1"}, Shares: []int64{6000},
+		}},
+		expectedErrors: []int{7},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Parse(strings.NewReader(test.csv), test.format)
+			if err != nil {
+				t.Fatalf("unexpected error parsing: %s", err)
+			}
+
+			if len(result.Transactions) != len(test.expected) {
+				t.Fatalf("expected %d transactions got %+v", len(test.expected), result.Transactions)
+			}
+			for i, tx := range result.Transactions {
+				expected := test.expected[i]
+				if tx.Line != expected.Line || tx.Name != expected.Name || tx.Payer != expected.Payer || tx.Transfer != expected.Transfer ||
+					!slices.Equal(tx.Participants, expected.Participants) || !slices.Equal(tx.Shares, expected.Shares) {
+					t.Errorf("expected transaction %+v got %+v", expected, tx)
+				}
+				if tx.OccurredAt.Hour() != 0 || tx.OccurredAt.Year() != 2024 {
+					t.Errorf("expected the day of the transaction got %s", tx.OccurredAt.Format(time.DateTime))
+				}
+			}
+
+			lines := make([]int, 0, len(result.Errors))
+			for _, rowErr := range result.Errors {
+				if !errors.Is(&rowErr, ErrInvalidRow) {
+					t.Errorf("expected invalid row error got %s", &rowErr)
+				}
+				lines = append(lines, rowErr.Line)
+			}
+			if !slices.Equal(lines, test.expectedErrors) {
+				t.Errorf("expected errors in lines %v got %v", test.expectedErrors, result.Errors)
+			}
+		})
+	}
+}
+
+func TestParseInvalidHeader(t *testing.T) {
+	_, err := Parse(strings.NewReader("Date,Description,Cost\n"), SplitwiseFormat)
+	if !errors.Is(err, ErrMissingColumn) {
+		t.Errorf("expected missing column error got %v", err)
+	}
+	_, err = Parse(strings.NewReader("Title\n"), Format("unknown"))
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected unknown format error got %v", err)
+	}
+}
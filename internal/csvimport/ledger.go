@@ -0,0 +1,228 @@
+package csvimport
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/diezfx/split-app-backend/internal/costcalc"
+	"golang.org/x/exp/slices"
+)
+
+// LedgerTransactionColumns are the columns of the transaction section of a ledger export.
+// There is one row per target, the transaction fields are repeated for every target.
+// New columns are only ever appended, so existing exports stay readable.
+//
+// The weight holds the number of shares, the percentage or the exact amount depending on the split mode.
+// The share is the resolved part of the target in the share currency, the currency of the project.
+// It is not read on import, the shares are split again from the weights in the currency of the transaction.
+var LedgerTransactionColumns = []string{
+	"transaction_id", "occurred_at", "name", "transaction_type", "amount", "currency",
+	"source_id", "split_mode", "target_id", "weight", "share", "share_currency",
+}
+
+// LedgerBalanceColumns are the columns of the balance section of a ledger export,
+// it follows the transactions after an empty line and is skipped on import.
+var LedgerBalanceColumns = []string{"user_id", "expenses", "income", "balance", "currency"}
+
+type ledgerParser struct {
+	id, occurredAt, name, transactionType, amount, currency, sourceID, splitMode, targetID, weight int
+	participantNames                                                                               []string
+	// pending is the transaction of the previous rows
+	pending *ledgerTransaction
+	// balances is set once the balance section is reached, all following rows are ignored
+	balances bool
+}
+
+type ledgerTransaction struct {
+	id        string
+	tx        Transaction
+	splitMode costcalc.SplitMode
+	weights   []int
+	// err is the first row of the transaction that could not be read
+	err *RowError
+}
+
+func newLedgerParser(header []string) (*ledgerParser, error) {
+	cols := newColumns(header)
+	var p ledgerParser
+	var err, colErr error
+	for _, col := range []struct {
+		index *int
+		name  string
+	}{
+		{&p.id, "transaction_id"},
+		{&p.occurredAt, "occurred_at"},
+		{&p.name, "name"},
+		{&p.transactionType, "transaction_type"},
+		{&p.amount, "amount"},
+		{&p.currency, "currency"},
+		{&p.sourceID, "source_id"},
+		{&p.splitMode, "split_mode"},
+		{&p.targetID, "target_id"},
+		{&p.weight, "weight"},
+	} {
+		*col.index, colErr = cols.index(col.name)
+		err = errors.Join(err, colErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *ledgerParser) participants() []string {
+	return p.participantNames
+}
+
+func (p *ledgerParser) addParticipant(name string) {
+	if !slices.Contains(p.participantNames, name) {
+		p.participantNames = append(p.participantNames, name)
+	}
+}
+
+func (p *ledgerParser) parse(line int, record []string) (Transaction, bool, error) {
+	if p.balances {
+		return Transaction{}, false, nil
+	}
+	if strings.EqualFold(field(record, p.id), LedgerBalanceColumns[0]) {
+		p.balances = true
+		return p.flush()
+	}
+
+	id := field(record, p.id)
+	if p.pending != nil && p.pending.id == id {
+		p.pending.addTarget(line, p.targetFields(record))
+		return Transaction{}, false, nil
+	}
+
+	tx, ok, err := p.flush()
+	p.pending = p.newTransaction(line, record)
+	return tx, ok, err
+}
+
+// newTransaction reads the fields of the transaction from its first row.
+func (p *ledgerParser) newTransaction(line int, record []string) *ledgerTransaction {
+	lt := &ledgerTransaction{id: field(record, p.id)}
+	if lt.id == "" {
+		lt.err = &RowError{Line: line, Err: errors.New("empty transaction_id")}
+		return lt
+	}
+	tx, err := parseCommon(field(record, p.name), field(record, p.occurredAt), field(record, p.amount), field(record, p.currency))
+	if err != nil {
+		lt.err = &RowError{Line: line, Err: err}
+		return lt
+	}
+	tx.Line = line
+
+	switch transactionType := field(record, p.transactionType); {
+	case strings.EqualFold(transactionType, "transfer"):
+		tx.Transfer = true
+	case !strings.EqualFold(transactionType, "expense"):
+		lt.err = &RowError{Line: line, Err: fmt.Errorf("unknown transaction_type %q", transactionType)}
+		return lt
+	}
+
+	tx.Payer = field(record, p.sourceID)
+	if tx.Payer == "" {
+		lt.err = &RowError{Line: line, Err: errors.New("no participant paid")}
+		return lt
+	}
+	p.addParticipant(tx.Payer)
+
+	lt.splitMode = costcalc.SplitMode(field(record, p.splitMode))
+	switch lt.splitMode {
+	case "", costcalc.EqualSplit, costcalc.SharesSplit, costcalc.PercentageSplit, costcalc.ExactSplit:
+	default:
+		lt.err = &RowError{Line: line, Err: fmt.Errorf("unknown split_mode %q", lt.splitMode)}
+		return lt
+	}
+	lt.tx = tx
+	lt.addTarget(line, p.targetFields(record))
+	return lt
+}
+
+type ledgerTarget struct {
+	id, weight string
+}
+
+func (p *ledgerParser) targetFields(record []string) ledgerTarget {
+	target := ledgerTarget{id: field(record, p.targetID), weight: field(record, p.weight)}
+	if target.id != "" {
+		p.addParticipant(target.id)
+	}
+	return target
+}
+
+// addTarget adds the target of a row, rows of a transaction that already failed are skipped.
+func (lt *ledgerTransaction) addTarget(line int, target ledgerTarget) {
+	if lt.err != nil {
+		return
+	}
+	if target.id == "" {
+		lt.err = &RowError{Line: line, Err: errors.New("empty target_id")}
+		return
+	}
+	weight, err := lt.parseWeight(target.weight)
+	if err != nil {
+		lt.err = &RowError{Line: line, Err: err}
+		return
+	}
+	lt.tx.Participants = append(lt.tx.Participants, target.id)
+	lt.weights = append(lt.weights, weight)
+}
+
+// parseWeight converts the weight of the export back into the integer weight of the split mode.
+func (lt *ledgerTransaction) parseWeight(weight string) (int, error) {
+	switch lt.splitMode {
+	case "", costcalc.EqualSplit:
+		return 0, nil
+	case costcalc.ExactSplit:
+		value, err := parseAmount(weight, lt.tx.Amount.Currency())
+		return int(value), err
+	}
+	f, err := strconv.ParseFloat(weight, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid weight %q", weight)
+	}
+	if lt.splitMode == costcalc.PercentageSplit {
+		f = f * costcalc.PercentageBase / 100
+	}
+	return int(math.Round(f)), nil
+}
+
+// flush splits the pending transaction between its targets.
+func (p *ledgerParser) flush() (Transaction, bool, error) {
+	lt := p.pending
+	p.pending = nil
+	if lt == nil {
+		return Transaction{}, false, nil
+	}
+	if lt.err != nil {
+		return Transaction{}, false, lt.err
+	}
+
+	split := costcalc.Transaction{Amount: lt.tx.Amount, TargetIDs: lt.tx.Participants, SplitMode: lt.splitMode, Weights: lt.weights}
+	if lt.splitMode == costcalc.EqualSplit || lt.splitMode == "" {
+		split.Weights = nil
+	}
+	shares, err := split.Split()
+	if err != nil {
+		return Transaction{}, false, &RowError{Line: lt.tx.Line, Err: err}
+	}
+
+	tx := lt.tx
+	tx.Participants = nil
+	for i, share := range shares {
+		if share.Amount() > 0 {
+			tx.Participants = append(tx.Participants, lt.tx.Participants[i])
+			tx.Shares = append(tx.Shares, share.Amount())
+		}
+	}
+	if err := checkShares(&tx); err != nil {
+		return Transaction{}, false, &RowError{Line: tx.Line, Err: err}
+	}
+	return tx, true, nil
+}
@@ -0,0 +1,71 @@
+package exchangerate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// FileProvider serves exchange rates from a json file of the form
+// {"base": "EUR", "rates": {"USD": 1.09, "CHF": 0.95}}
+// where every rate is the amount of the currency you get for one unit of the base currency.
+type FileProvider struct {
+	base  string
+	rates map[string]float64
+}
+
+type rateFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+func NewFileProvider(path string) (*FileProvider, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read exchange rate file %s: %w", path, err)
+	}
+
+	var rates rateFile
+	err = json.Unmarshal(content, &rates)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal exchange rates: %w", err)
+	}
+	if rates.Base == "" {
+		return nil, fmt.Errorf("exchange rate file %s has no base currency", path)
+	}
+	for currency, rate := range rates.Rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("invalid rate %f for %s", rate, currency)
+		}
+	}
+
+	return &FileProvider{base: rates.Base, rates: rates.Rates}, nil
+}
+
+// GetExchangeRate returns how many units of to you get for one unit of from.
+func (p *FileProvider) GetExchangeRate(_ context.Context, from, to string) (float64, error) {
+	fromRate, err := p.rate(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := p.rate(to)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+func (p *FileProvider) rate(currency string) (float64, error) {
+	if currency == p.base {
+		return 1, nil
+	}
+	rate, ok := p.rates[currency]
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", currency, ErrRateNotFound)
+	}
+	return rate, nil
+}
@@ -0,0 +1,46 @@
+package invite
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestVerify(t *testing.T) {
+	signer := NewSigner("secret")
+	id := uuid.New()
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+	token := signer.Sign(id, now.Add(time.Hour))
+	tampered := []byte(token)
+	tampered[0] ^= 1
+
+	tests := []struct {
+		name     string
+		signer   *Signer
+		token    string
+		now      time.Time
+		expected error
+	}{
+		{name: "valid", signer: signer, token: token, now: now},
+		{name: "expired", signer: signer, token: token, now: now.Add(time.Hour), expected: ErrExpiredToken},
+		{name: "other key", signer: NewSigner("other"), token: token, now: now, expected: ErrInvalidToken},
+		{name: "tampered", signer: signer, token: string(tampered), now: now, expected: ErrInvalidToken},
+		{name: "no signature", signer: signer, token: strings.Split(token, ".")[0], now: now, expected: ErrInvalidToken},
+		{name: "garbage", signer: signer, token: "not.a-token", now: now, expected: ErrInvalidToken},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.signer.Verify(test.token, test.now)
+			if !errors.Is(err, test.expected) {
+				t.Fatalf("expected error %v got %v", test.expected, err)
+			}
+			if test.expected == nil && got != id {
+				t.Errorf("expected invite %s got %s", id, got)
+			}
+		})
+	}
+}
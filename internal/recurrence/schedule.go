@@ -0,0 +1,89 @@
+// Package recurrence calculates the occurrences of repeating schedules like "every month on the 1st".
+// All dates are days at midnight UTC, like the OccurredAt of transactions.
+package recurrence
+
+import (
+	"time"
+)
+
+type Frequency string
+
+const (
+	UndefinedFrequency Frequency = "Undefined"
+	Daily              Frequency = "daily"
+	Weekly             Frequency = "weekly"
+	Monthly            Frequency = "monthly"
+)
+
+func ParseFrequency(frequency string) Frequency {
+	switch frequency {
+	case string(Daily):
+		return Daily
+	case string(Weekly):
+		return Weekly
+	case string(Monthly):
+		return Monthly
+	default:
+		return UndefinedFrequency
+	}
+}
+
+// Schedule repeats every Interval days, weeks or months from Start until End.
+// Monthly schedules that start on a day a month doesn't have occur on the last day of that month,
+// e.g. a schedule starting on January 31st occurs on February 28th or 29th and then on March 31st.
+type Schedule struct {
+	Frequency Frequency
+	// Interval is at least 1
+	Interval int
+	// Start is the first occurrence
+	Start time.Time
+	// End is the last day an occurrence may fall on, a zero End repeats forever
+	End time.Time
+}
+
+// Next returns the first occurrence on or after day, false if the schedule ended before.
+func (s *Schedule) Next(day time.Time) (time.Time, bool) {
+	if day.Before(s.Start) {
+		day = s.Start
+	}
+
+	var n int
+	switch s.Frequency {
+	case Daily, Weekly:
+		step := s.Interval
+		if s.Frequency == Weekly {
+			step *= 7
+		}
+		days := int(day.Sub(s.Start).Hours() / 24)
+		n = (days + step - 1) / step
+	case Monthly:
+		months := (day.Year()-s.Start.Year())*12 + int(day.Month()-s.Start.Month())
+		n = months / s.Interval
+		for s.occurrence(n).Before(day) {
+			n++
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	next := s.occurrence(n)
+	if !s.End.IsZero() && next.After(s.End) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// occurrence returns the n-th occurrence, the 0th is Start.
+func (s *Schedule) occurrence(n int) time.Time {
+	switch s.Frequency {
+	case Daily:
+		return s.Start.AddDate(0, 0, n*s.Interval)
+	case Weekly:
+		return s.Start.AddDate(0, 0, 7*n*s.Interval)
+	default:
+		year, month := s.Start.Year(), s.Start.Month()+time.Month(n*s.Interval)
+		// day 0 of the following month is the last day of the month
+		lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		return time.Date(year, month, min(s.Start.Day(), lastDay), 0, 0, 0, 0, time.UTC)
+	}
+}
@@ -0,0 +1,85 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		day      time.Time
+		expected time.Time
+		ok       bool
+	}{
+		{
+			name:     "before start",
+			schedule: Schedule{Frequency: Monthly, Interval: 1, Start: day(2024, time.March, 1)},
+			day:      day(2024, time.January, 15), expected: day(2024, time.March, 1), ok: true,
+		},
+		{
+			name:     "monthly on the day",
+			schedule: Schedule{Frequency: Monthly, Interval: 1, Start: day(2024, time.January, 1)},
+			day:      day(2024, time.June, 1), expected: day(2024, time.June, 1), ok: true,
+		},
+		{
+			name:     "monthly after the day",
+			schedule: Schedule{Frequency: Monthly, Interval: 1, Start: day(2024, time.January, 15)},
+			day:      day(2024, time.December, 16), expected: day(2025, time.January, 15), ok: true,
+		},
+		{
+			name:     "monthly end of month in february",
+			schedule: Schedule{Frequency: Monthly, Interval: 1, Start: day(2024, time.January, 31)},
+			day:      day(2024, time.February, 1), expected: day(2024, time.February, 29), ok: true,
+		},
+		{
+			name:     "monthly end of month after a short month",
+			schedule: Schedule{Frequency: Monthly, Interval: 1, Start: day(2024, time.January, 31)},
+			day:      day(2024, time.March, 1), expected: day(2024, time.March, 31), ok: true,
+		},
+		{
+			name:     "every three months",
+			schedule: Schedule{Frequency: Monthly, Interval: 3, Start: day(2024, time.January, 10)},
+			day:      day(2024, time.February, 1), expected: day(2024, time.April, 10), ok: true,
+		},
+		{
+			name:     "every other week",
+			schedule: Schedule{Frequency: Weekly, Interval: 2, Start: day(2024, time.January, 1)},
+			day:      day(2024, time.January, 9), expected: day(2024, time.January, 15), ok: true,
+		},
+		{
+			name:     "every three days",
+			schedule: Schedule{Frequency: Daily, Interval: 3, Start: day(2024, time.February, 27)},
+			day:      day(2024, time.February, 28), expected: day(2024, time.March, 1), ok: true,
+		},
+		{
+			name:     "on the end date",
+			schedule: Schedule{Frequency: Weekly, Interval: 1, Start: day(2024, time.January, 1), End: day(2024, time.January, 8)},
+			day:      day(2024, time.January, 2), expected: day(2024, time.January, 8), ok: true,
+		},
+		{
+			name:     "after the end date",
+			schedule: Schedule{Frequency: Weekly, Interval: 1, Start: day(2024, time.January, 1), End: day(2024, time.January, 14)},
+			day:      day(2024, time.January, 9),
+		},
+		{
+			name:     "undefined frequency",
+			schedule: Schedule{Frequency: UndefinedFrequency, Interval: 1, Start: day(2024, time.January, 1)},
+			day:      day(2024, time.January, 1),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := test.schedule.Next(test.day)
+			if ok != test.ok || !got.Equal(test.expected) {
+				t.Errorf("expected %s, %t got %s, %t", test.expected, test.ok, got, ok)
+			}
+		})
+	}
+}
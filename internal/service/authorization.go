@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/diezfx/split-app-backend/internal/contextutil"
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// authorizeProject checks that the calling user is a member of the project whose role includes the given role.
+// Projects that don't exist are treated the same way to not leak their existence.
+func (s *Service) authorizeProject(ctx context.Context, projID uuid.UUID, role Role) error {
+	userID := contextutil.GetUserIDFromCtx(ctx)
+	if userID == "" {
+		return fmt.Errorf("no user in context: %w", ErrForbidden)
+	}
+
+	memberRole, err := s.projStorage.GetProjectRole(ctx, projID, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("user %s is not a member of project %s: %w", userID, projID, ErrForbidden)
+	}
+	if err != nil {
+		return fmt.Errorf("check membership: %w", err)
+	}
+	if !ParseRole(memberRole).Includes(role) {
+		return fmt.Errorf("user %s is %s of project %s, %s required: %w", userID, memberRole, projID, role, ErrForbidden)
+	}
+	return nil
+}
+
+// authorizeUser checks that the calling user is the given user.
+func authorizeUser(ctx context.Context, userID string) error {
+	callerID := contextutil.GetUserIDFromCtx(ctx)
+	if callerID == "" || callerID != userID {
+		return fmt.Errorf("user %q is not allowed to access user %s: %w", callerID, userID, ErrForbidden)
+	}
+	return nil
+}
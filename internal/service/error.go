@@ -1,5 +1,40 @@
 package service
 
-import "errors"
+import (
+	"fmt"
 
-var ErrProjectNotFound = errors.New("project not found")
+	"github.com/diezfx/split-app-backend/internal/apperror"
+)
+
+var (
+	ErrProjectNotFound     = fmt.Errorf("project %w", apperror.NotFound)
+	ErrTransactionNotFound = fmt.Errorf("transaction %w", apperror.NotFound)
+	ErrProjectExists       = fmt.Errorf("project already exists: %w", apperror.Conflict)
+	ErrMemberNotFound      = fmt.Errorf("member %w", apperror.NotFound)
+	// ErrRecurringTransactionNotFound is returned for recurring transactions that don't exist or were deleted
+	ErrRecurringTransactionNotFound = fmt.Errorf("recurring transaction %w", apperror.NotFound)
+	// ErrGuestNotFound is returned for guests that don't exist or belong to another project
+	ErrGuestNotFound = fmt.Errorf("guest %w", apperror.NotFound)
+	// ErrGuestOfOtherProject is returned when a guest should become a member of another project than their own
+	ErrGuestOfOtherProject = fmt.Errorf("user is a guest of another project: %w", apperror.Conflict)
+	// ErrInviteNotFound is returned for invites that don't exist, are revoked or expired
+	ErrInviteNotFound = fmt.Errorf("invite %w", apperror.NotFound)
+	// ErrInvitesDisabled is returned for invites if the server has no key to sign them
+	ErrInvitesDisabled = fmt.Errorf("invites are %w, no signing key is configured", apperror.Disabled)
+	// ErrProjectArchived is returned for changes to an archived project
+	ErrProjectArchived = fmt.Errorf("project is archived: %w", apperror.Conflict)
+	// ErrMemberHasBalance is returned when a member with open debts or credits should be removed without settling them
+	ErrMemberHasBalance = fmt.Errorf("member has a balance: %w", apperror.Conflict)
+	// ErrMemberInRecurringTransaction is returned when a member should be removed who is still charged by a recurring transaction
+	ErrMemberInRecurringTransaction = fmt.Errorf("member is part of a recurring transaction: %w", apperror.Conflict)
+	// ErrLastOwner is returned when the last owner of a project would be removed or lose their role
+	ErrLastOwner = fmt.Errorf("project needs an owner: %w", apperror.Conflict)
+	// ErrForbidden is returned when the calling user is not allowed to access the resource
+	ErrForbidden error = apperror.Forbidden
+	// ErrIdempotencyKeyReused is returned when an idempotency key is used again for a different request
+	ErrIdempotencyKeyReused = fmt.Errorf("idempotency key reused: %w", apperror.Conflict)
+	// ErrIdempotencyKeyInProgress is returned when the first request with an idempotency key is not finished yet
+	ErrIdempotencyKeyInProgress = fmt.Errorf("idempotency key in progress: %w", apperror.Conflict)
+	// ErrIdempotencyKeyTakenOver is returned when an abandoned request tries to finish after another request claimed its key
+	ErrIdempotencyKeyTakenOver = fmt.Errorf("idempotency key taken over: %w", apperror.Conflict)
+)
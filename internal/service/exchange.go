@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/Rhymond/go-money"
+	"github.com/diezfx/split-app-backend/internal/costcalc"
+)
+
+// DefaultCurrency is used for projects and transactions that don't specify a currency.
+const DefaultCurrency = money.EUR
+
+// FromMajorUnits returns the amount given in major units, e.g. 19.99 EUR, rounded to the minor units of the currency.
+// Unlike money.NewFromFloat it rounds instead of truncating, so the float error of 19.99 doesn't turn it into 19.98.
+func FromMajorUnits(amount float64, currency string) *money.Money {
+	fraction := 2
+	if c := money.GetCurrency(currency); c != nil {
+		fraction = c.Fraction
+	}
+	return money.New(int64(math.Round(amount*math.Pow10(fraction))), currency)
+}
+
+type ExchangeRateProvider interface {
+	// GetExchangeRate returns how many units of to you get for one unit of from.
+	GetExchangeRate(ctx context.Context, from, to string) (float64, error)
+}
+
+// convert returns the amount in the given currency, taking the different fractions of the currencies into account.
+func (s *Service) convert(ctx context.Context, amount *money.Money, currency string) (*money.Money, error) {
+	fromCurrency := amount.Currency()
+	if fromCurrency.Code == currency {
+		return amount, nil
+	}
+
+	rate, err := s.rates.GetExchangeRate(ctx, fromCurrency.Code, currency)
+	if err != nil {
+		return nil, fmt.Errorf("get exchange rate from %s to %s: %w", fromCurrency.Code, currency, err)
+	}
+
+	toCurrency := money.GetCurrency(currency)
+	if toCurrency == nil {
+		return nil, fmt.Errorf("unknown currency %s", currency)
+	}
+
+	converted := float64(amount.Amount()) * rate * math.Pow10(toCurrency.Fraction-fromCurrency.Fraction)
+	return money.New(int64(math.Round(converted)), currency), nil
+}
+
+// convertTransaction returns the transaction with its amount in the given currency.
+// Exact splits are turned into shares of the original amounts, so the split parts still add up to the converted amount.
+func (s *Service) convertTransaction(ctx context.Context, tx Transaction, currency string) (Transaction, error) {
+	converted, err := s.convert(ctx, tx.Amount, currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+	if converted == tx.Amount {
+		return tx, nil
+	}
+
+	tx.Amount = converted
+	if tx.SplitMode == ExactSplitMode {
+		tx.SplitMode = SharesSplitMode
+	}
+	return tx, nil
+}
+
+func (s *Service) convertCost(ctx context.Context, cost Cost, currency string) (Cost, error) {
+	expenses, err := s.convert(ctx, cost.Expenses, currency)
+	if err != nil {
+		return Cost{}, err
+	}
+	income, err := s.convert(ctx, cost.Income, currency)
+	if err != nil {
+		return Cost{}, err
+	}
+	balance, err := expenses.Subtract(income)
+	if err != nil {
+		return Cost{}, err
+	}
+	return Cost{Expenses: expenses, Income: income, Balance: balance}, nil
+}
+
+// costCalcTransactions converts all transactions of the project into the project currency.
+func (s *Service) costCalcTransactions(ctx context.Context, proj *Project) ([]costcalc.Transaction, error) {
+	costCalcTransactions := make([]costcalc.Transaction, 0, len(proj.Transactions))
+	for _, tx := range proj.Transactions {
+		converted, err := s.convertTransaction(ctx, tx, proj.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("convert transaction %s: %w", tx.ID, err)
+		}
+		costCalcTransactions = append(costCalcTransactions, converted.ToCostCalc())
+	}
+	return costCalcTransactions, nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Rhymond/go-money"
+	"github.com/diezfx/split-app-backend/internal/costcalc"
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// exportPageSize is the number of transactions read from the storage at once while exporting.
+const exportPageSize = MaxPageSize
+
+// LedgerWriter receives an export part by part: first the project, then every entry sorted by
+// the day the transaction occurred and finally the balances of all members.
+type LedgerWriter interface {
+	// WriteProject gets the project without its transactions
+	WriteProject(proj Project) error
+	WriteEntry(entry LedgerEntry) error
+	WriteBalances(balances ProjectCosts) error
+}
+
+// LedgerEntry is a transaction with the part every target has to pay.
+type LedgerEntry struct {
+	Transaction Transaction
+	// Shares are aligned with Transaction.TargetIDs and in the currency of the project
+	Shares []*money.Money
+}
+
+// ExportProject writes all transactions of the project with their resolved splits and the balances of all members.
+// The transactions are read page by page and handed to the writer one by one, so the whole ledger is never held in memory.
+func (s *Service) ExportProject(ctx context.Context, projID uuid.UUID, w LedgerWriter) error {
+	if err := s.authorizeProject(ctx, projID, ViewerRole); err != nil {
+		return err
+	}
+	header, err := s.projStorage.GetProjectHeader(ctx, projID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrProjectNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("get project: %w", err)
+	}
+	proj := FromStorageProject(header)
+	if err := w.WriteProject(proj); err != nil {
+		return fmt.Errorf("write project: %w", err)
+	}
+
+	calculator, err := costcalc.New(proj.Currency, nil)
+	if err != nil {
+		return fmt.Errorf("calc costs: %w", err)
+	}
+	filter := storage.TransactionFilter{ProjectID: projID, SortBy: storage.SortByDate, Limit: exportPageSize}
+	for {
+		txs, err := s.projStorage.GetTransactions(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("get transactions: %w", err)
+		}
+		for _, storageTx := range txs {
+			tx := FromStorageTransaction(storageTx)
+			converted, err := s.convertTransaction(ctx, tx, proj.Currency)
+			if err != nil {
+				return fmt.Errorf("convert transaction %s: %w", tx.ID, err)
+			}
+			edges, err := calculator.Add(converted.ToCostCalc())
+			if err != nil {
+				return fmt.Errorf("calc costs: %w", err)
+			}
+			shares := make([]*money.Money, 0, len(edges))
+			for _, edge := range edges {
+				shares = append(shares, edge.Amount)
+			}
+			if err := w.WriteEntry(LedgerEntry{Transaction: tx, Shares: shares}); err != nil {
+				return fmt.Errorf("write transaction %s: %w", tx.ID, err)
+			}
+		}
+		if len(txs) < exportPageSize {
+			break
+		}
+		last := txs[len(txs)-1]
+		filter.After = &storage.TransactionCursor{ID: last.ID, Amount: last.Amount, OccurredAt: last.OccurredAt}
+	}
+
+	allCosts, err := calculator.CalculateCostForAllUsers()
+	if err != nil {
+		return fmt.Errorf("calc costs: %w", err)
+	}
+	balances := FromCostCalcProjectCost(*allCosts)
+	balances.Currency = proj.Currency
+	if err := w.WriteBalances(balances); err != nil {
+		return fmt.Errorf("write balances: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/diezfx/split-app-backend/internal/contextutil"
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/google/uuid"
+)
+
+// guestIDPrefix keeps the ids of guests apart from the ids of users with an account.
+const guestIDPrefix = "guest-"
+
+// AddGuest adds a member without an account to the project, only owners may add guests.
+// Guests can't sign in, so they are viewers until a user claims them.
+func (s *Service) AddGuest(ctx context.Context, projID uuid.UUID, name string) (User, error) {
+	if _, err := s.getWritableProject(ctx, projID, OwnerRole); err != nil {
+		return User{}, err
+	}
+
+	guest := storage.User{
+		ID: guestIDPrefix + NewID().String(), DisplayName: name,
+		GuestProjectID: uuid.NullUUID{UUID: projID, Valid: true},
+	}
+	err := s.projStorage.AddGuest(ctx, guest, string(ViewerRole))
+	if err != nil {
+		return User{}, fmt.Errorf("add guest: %w", err)
+	}
+	user := FromStorageUser(guest)
+	user.Role = ViewerRole
+	return user, nil
+}
+
+// ClaimGuest lets the calling member take over the identity of a guest of the project.
+// The transactions of the guest become transactions of the caller and the guest is removed.
+// This can't be undone, so viewers who may not change transactions may not claim guests either.
+func (s *Service) ClaimGuest(ctx context.Context, projID uuid.UUID, guestID string) (Project, error) {
+	if _, err := s.getWritableProject(ctx, projID, EditorRole); err != nil {
+		return Project{}, err
+	}
+
+	err := s.projStorage.ClaimGuest(ctx, projID, guestID, contextutil.GetUserIDFromCtx(ctx))
+	if errors.Is(err, storage.ErrNotFound) {
+		return Project{}, ErrGuestNotFound
+	}
+	if err != nil {
+		return Project{}, fmt.Errorf("claim guest: %w", err)
+	}
+	proj, err := s.getProject(ctx, projID)
+	if err != nil {
+		return Project{}, err
+	}
+	return proj, s.addDisplayNames(ctx, &proj)
+}
+
+// checkNoGuest returns ErrGuestOfOtherProject if the user is a guest of another project than projID.
+func checkNoGuest(user *storage.User, projID uuid.UUID) error {
+	if user.GuestProjectID.Valid && user.GuestProjectID.UUID != projID {
+		return fmt.Errorf("user %s: %w", user.ID, ErrGuestOfOtherProject)
+	}
+	return nil
+}
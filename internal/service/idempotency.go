@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/diezfx/split-app-backend/internal/contextutil"
+	"github.com/diezfx/split-app-backend/internal/storage"
+)
+
+const (
+	// IdempotencyKeyTimeout is how long a request may be in progress, afterwards it is considered abandoned,
+	// e.g. because the process crashed, and the key can be used again.
+	IdempotencyKeyTimeout = 5 * time.Minute
+	// IdempotencyKeyRetention is how long keys and their responses are kept, retries after that are executed again.
+	IdempotencyKeyRetention = 24 * time.Hour
+)
+
+// IdempotentResponse is the response of the first request made with an idempotency key.
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	// Location is the location header of created resources
+	Location string
+	Body     []byte
+}
+
+// IdempotencyClaim identifies the request that reserved an idempotency key.
+// Once the request is abandoned another request can take over the key, the claim of the first one is then no longer valid.
+type IdempotencyClaim struct {
+	Key         string
+	Fingerprint string
+	CreatedAt   time.Time
+}
+
+// BeginIdempotentRequest reserves the idempotency key of the calling user for the request with the given fingerprint.
+// If the key was already used for the same request, its response is returned and the request must not be executed again.
+// Otherwise the returned claim completes or aborts the request.
+func (s *Service) BeginIdempotentRequest(ctx context.Context, key, fingerprint string) (IdempotencyClaim, *IdempotentResponse, error) {
+	userID := contextutil.GetUserIDFromCtx(ctx)
+	if userID == "" {
+		return IdempotencyClaim{}, nil, fmt.Errorf("no user in context: %w", ErrForbidden)
+	}
+
+	// the storage keeps microseconds, the claim has to match the stored time exactly
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	claim := IdempotencyClaim{Key: key, Fingerprint: fingerprint, CreatedAt: now}
+	err := s.projStorage.AddIdempotencyRecord(ctx, claim.record(userID), now.Add(-IdempotencyKeyTimeout))
+	if err == nil {
+		return claim, nil, nil
+	}
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		return IdempotencyClaim{}, nil, fmt.Errorf("add idempotency key: %w", err)
+	}
+
+	record, err := s.projStorage.GetIdempotencyRecord(ctx, userID, key)
+	if err != nil {
+		return IdempotencyClaim{}, nil, fmt.Errorf("get idempotency key: %w", err)
+	}
+	if record.Fingerprint != fingerprint {
+		return IdempotencyClaim{}, nil, fmt.Errorf("key %q: %w", key, ErrIdempotencyKeyReused)
+	}
+	if record.StatusCode == 0 {
+		return IdempotencyClaim{}, nil, fmt.Errorf("key %q: %w", key, ErrIdempotencyKeyInProgress)
+	}
+	return IdempotencyClaim{}, &IdempotentResponse{
+		StatusCode: record.StatusCode, ContentType: record.ContentType, Location: record.Location, Body: record.ResponseBody,
+	}, nil
+}
+
+// CompleteIdempotentRequest stores the response, so it can be returned for retries of the request.
+// Returns ErrIdempotencyKeyTakenOver if another request claimed the key in the meantime.
+func (s *Service) CompleteIdempotentRequest(ctx context.Context, claim IdempotencyClaim, response IdempotentResponse) error {
+	record := claim.record(contextutil.GetUserIDFromCtx(ctx))
+	record.StatusCode = response.StatusCode
+	record.ContentType = response.ContentType
+	record.Location = response.Location
+	record.ResponseBody = response.Body
+	err := s.projStorage.CompleteIdempotencyRecord(ctx, record)
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("key %q: %w", claim.Key, ErrIdempotencyKeyTakenOver)
+	}
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// AbortIdempotentRequest releases the key, so the request can be retried.
+// Returns ErrIdempotencyKeyTakenOver if another request claimed the key in the meantime.
+func (s *Service) AbortIdempotentRequest(ctx context.Context, claim IdempotencyClaim) error {
+	err := s.projStorage.DeleteIdempotencyRecord(ctx, claim.record(contextutil.GetUserIDFromCtx(ctx)))
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("key %q: %w", claim.Key, ErrIdempotencyKeyTakenOver)
+	}
+	if err != nil {
+		return fmt.Errorf("delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (c IdempotencyClaim) record(userID string) storage.IdempotencyRecord {
+	return storage.IdempotencyRecord{UserID: userID, Key: c.Key, Fingerprint: c.Fingerprint, CreatedAt: c.CreatedAt}
+}
+
+// deleteExpiredIdempotencyRecords deletes the keys that are older than the IdempotencyKeyRetention.
+func (s *Service) deleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int, error) {
+	deleted, err := s.projStorage.DeleteIdempotencyRecords(ctx, now.Add(-IdempotencyKeyRetention))
+	if err != nil {
+		return 0, fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+	return deleted, nil
+}
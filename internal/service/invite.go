@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/diezfx/split-app-backend/internal/contextutil"
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	DefaultInviteValidity = 7 * 24 * time.Hour
+	MaxInviteValidity     = 30 * 24 * time.Hour
+)
+
+// Invite lets everyone with the token join the project with the role until it expires or is revoked.
+type Invite struct {
+	ID        uuid.UUID
+	ProjectID uuid.UUID
+	Role      Role
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// Token is the signed secret of the invite link
+	Token string
+}
+
+// CreateInvite creates an invite that is valid for the given duration, 0 uses the DefaultInviteValidity.
+// Only owners may invite new members.
+func (s *Service) CreateInvite(ctx context.Context, projID uuid.UUID, role Role, validFor time.Duration) (Invite, error) {
+	if s.invites == nil {
+		return Invite{}, ErrInvitesDisabled
+	}
+	if _, err := s.getWritableProject(ctx, projID, OwnerRole); err != nil {
+		return Invite{}, err
+	}
+	if validFor == 0 {
+		validFor = DefaultInviteValidity
+	}
+
+	now := time.Now().UTC()
+	// the token only holds full seconds
+	invite := Invite{
+		ID: NewID(), ProjectID: projID, Role: role, CreatedBy: contextutil.GetUserIDFromCtx(ctx),
+		CreatedAt: now, ExpiresAt: now.Add(validFor).Truncate(time.Second),
+	}
+	err := s.projStorage.AddInvite(ctx, toStorageInvite(invite))
+	if err != nil {
+		return Invite{}, fmt.Errorf("add invite: %w", err)
+	}
+	invite.Token = s.invites.Sign(invite.ID, invite.ExpiresAt)
+	return invite, nil
+}
+
+// GetInvites returns the invites of the project that didn't expire yet, only owners may see them.
+func (s *Service) GetInvites(ctx context.Context, projID uuid.UUID) ([]Invite, error) {
+	if s.invites == nil {
+		return nil, ErrInvitesDisabled
+	}
+	if err := s.authorizeProject(ctx, projID, OwnerRole); err != nil {
+		return nil, err
+	}
+	storageInvites, err := s.projStorage.GetInvites(ctx, projID)
+	if err != nil {
+		return nil, fmt.Errorf("get invites: %w", err)
+	}
+
+	now := time.Now()
+	invites := make([]Invite, 0, len(storageInvites))
+	for _, i := range storageInvites {
+		if !now.Before(i.ExpiresAt) {
+			continue
+		}
+		invite := fromStorageInvite(i)
+		invite.Token = s.invites.Sign(invite.ID, invite.ExpiresAt)
+		invites = append(invites, invite)
+	}
+	return invites, nil
+}
+
+// RevokeInvite deletes the invite, its token can't be used anymore. Only owners may revoke invites.
+func (s *Service) RevokeInvite(ctx context.Context, projID, inviteID uuid.UUID) error {
+	if err := s.authorizeProject(ctx, projID, OwnerRole); err != nil {
+		return err
+	}
+	err := s.projStorage.DeleteInvite(ctx, projID, inviteID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrInviteNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("delete invite: %w", err)
+	}
+	return nil
+}
+
+// AcceptInvite adds the calling user to the project of the invite with its role and returns the project.
+// Accepting an invite to a project the user is already a member of doesn't change their role.
+func (s *Service) AcceptInvite(ctx context.Context, token string) (Project, error) {
+	userID := contextutil.GetUserIDFromCtx(ctx)
+	if userID == "" {
+		return Project{}, fmt.Errorf("no user in context: %w", ErrForbidden)
+	}
+
+	if s.invites == nil {
+		return Project{}, ErrInvitesDisabled
+	}
+	inviteID, err := s.invites.Verify(token, time.Now())
+	if err != nil {
+		return Project{}, fmt.Errorf("%w: %w", ErrInviteNotFound, err)
+	}
+	invite, err := s.projStorage.GetInvite(ctx, inviteID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Project{}, ErrInviteNotFound
+	}
+	if err != nil {
+		return Project{}, fmt.Errorf("get invite: %w", err)
+	}
+
+	proj, err := s.getProject(ctx, invite.ProjectID)
+	if err != nil {
+		return Project{}, err
+	}
+	if proj.Archived() {
+		return Project{}, fmt.Errorf("project %s: %w", proj.ID, ErrProjectArchived)
+	}
+	if !slices.Contains(proj.Members, userID) {
+		err = s.addProjectUser(ctx, proj.ID, userID, ParseRole(invite.Role))
+		if err != nil {
+			return Project{}, err
+		}
+		proj, err = s.getProject(ctx, proj.ID)
+		if err != nil {
+			return Project{}, err
+		}
+	}
+	return proj, s.addDisplayNames(ctx, &proj)
+}
+
+func toStorageInvite(i Invite) storage.Invite {
+	return storage.Invite{
+		ID: i.ID, ProjectID: i.ProjectID, Role: string(i.Role), CreatedBy: i.CreatedBy,
+		CreatedAt: i.CreatedAt, ExpiresAt: i.ExpiresAt,
+	}
+}
+
+func fromStorageInvite(i storage.Invite) Invite {
+	return Invite{
+		ID: i.ID, ProjectID: i.ProjectID, Role: ParseRole(i.Role), CreatedBy: i.CreatedBy,
+		CreatedAt: i.CreatedAt, ExpiresAt: i.ExpiresAt,
+	}
+}
@@ -1,6 +1,10 @@
 package service
 
 import (
+	"database/sql"
+	"fmt"
+	"time"
+
 	"github.com/Rhymond/go-money"
 	"github.com/diezfx/split-app-backend/internal/costcalc"
 	"github.com/diezfx/split-app-backend/internal/storage"
@@ -26,18 +30,114 @@ func ParseTransactionType(trans string) TransactionType {
 	}
 }
 
+type SplitMode string
+
+const (
+	UndefinedSplitMode  SplitMode = "Undefined"
+	EqualSplitMode      SplitMode = "Equal"
+	SharesSplitMode     SplitMode = "Shares"
+	PercentageSplitMode SplitMode = "Percentage"
+	ExactSplitMode      SplitMode = "Exact"
+)
+
+// ParseSplitMode returns the split mode for the given string, an empty string defaults to an equal split.
+func ParseSplitMode(mode string) SplitMode {
+	switch mode {
+	case "", string(EqualSplitMode):
+		return EqualSplitMode
+	case string(SharesSplitMode):
+		return SharesSplitMode
+	case string(PercentageSplitMode):
+		return PercentageSplitMode
+	case string(ExactSplitMode):
+		return ExactSplitMode
+	default:
+		return UndefinedSplitMode
+	}
+}
+
+// Role decides what a member may do in a project.
+// Owners manage the project and its members, editors add and change transactions and viewers only read.
+type Role string
+
+const (
+	UndefinedRole Role = "Undefined"
+	OwnerRole     Role = "owner"
+	EditorRole    Role = "editor"
+	ViewerRole    Role = "viewer"
+)
+
+// ParseRole returns the role for the given string, an empty string defaults to an editor.
+func ParseRole(role string) Role {
+	switch role {
+	case "", string(EditorRole):
+		return EditorRole
+	case string(OwnerRole):
+		return OwnerRole
+	case string(ViewerRole):
+		return ViewerRole
+	default:
+		return UndefinedRole
+	}
+}
+
+var roleRanks = map[Role]int{ViewerRole: 1, EditorRole: 2, OwnerRole: 3}
+
+// Includes reports whether the role may do everything the other role may.
+func (r Role) Includes(other Role) bool {
+	return roleRanks[r] >= roleRanks[other] && roleRanks[r] > 0
+}
+
+// User is a member with the profile they set themselves, empty fields are not set.
 type User struct {
-	ID string `json:"id"`
+	ID          string
+	DisplayName string
+	Email       string
+	AvatarURL   string
+	// PreferredCurrency is used for the total costs of the user if no other currency is requested
+	PreferredCurrency string
+	// Role is only set for the users of a project
+	Role Role
+	// Guest is set for members without an account, they only belong to one project and can be claimed by a user
+	Guest bool
+}
+
+// Name is the display name of the user, or their id if they have none.
+func (u *User) Name() string {
+	if u.DisplayName == "" {
+		return u.ID
+	}
+	return u.DisplayName
+}
+
+func FromStorageUser(u storage.User) User {
+	return User{
+		ID: u.ID, DisplayName: u.DisplayName, Email: u.Email, AvatarURL: u.AvatarURL, PreferredCurrency: u.PreferredCurrency,
+		Guest: u.GuestProjectID.Valid,
+	}
+}
+
+func ToStorageUser(u User) storage.User {
+	return storage.User{ID: u.ID, DisplayName: u.DisplayName, Email: u.Email, AvatarURL: u.AvatarURL, PreferredCurrency: u.PreferredCurrency}
 }
 
 type UserCosts struct {
-	TotalCost    Cost
-	ProjectCosts map[uuid.UUID]Cost
+	// Currency of the total costs and the converted project costs
+	Currency  string
+	TotalCost Cost
+	// ProjectCosts are in the currency of each project
+	ProjectCosts          map[uuid.UUID]Cost
+	ConvertedProjectCosts map[uuid.UUID]Cost
 }
 
 type ProjectCosts struct {
+	Currency  string
 	TotalCost *money.Money
 	UserCosts map[string]Cost
+	// OriginalTotalCosts are the unconverted totals per currency used in the project
+	OriginalTotalCosts map[string]*money.Money
+	// DisplayNames contains the name of every user in UserCosts
+	DisplayNames map[string]string
 }
 
 type Cost struct {
@@ -54,6 +154,30 @@ type Transaction struct {
 	Amount          *money.Money
 	SourceID        string
 	TargetIDs       []string
+	SplitMode       SplitMode
+	// Weights are aligned with TargetIDs, see costcalc.Transaction for their meaning per split mode
+	Weights []int
+	// OccurredAt is the day the transaction happened as given by the user
+	OccurredAt time.Time
+	// CreatedAt, UpdatedAt and CreatedBy are set by the server
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy string
+}
+
+// NewID returns a new time ordered id for a project or transaction.
+func NewID() uuid.UUID {
+	return uuid.Must(uuid.NewV7())
+}
+
+// Today returns the current day as it is stored in Transaction.OccurredAt.
+func Today() time.Time {
+	return ToDate(time.Now())
+}
+
+// ToDate strips the time of day, the date is taken in UTC.
+func ToDate(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
 }
 
 func (t *Transaction) ToCostCalc() costcalc.Transaction {
@@ -62,14 +186,159 @@ func (t *Transaction) ToCostCalc() costcalc.Transaction {
 		ID:        t.ID,
 		Amount:    t.Amount,
 		SourceID:  t.SourceID, TargetIDs: t.TargetIDs,
+		SplitMode: costcalc.SplitMode(t.SplitMode),
+		Weights:   t.Weights,
+	}
+}
+
+type ImportResult struct {
+	Transactions []Transaction
+	// NewMembers are the users that were added to the project by the import
+	NewMembers []string
+}
+
+// Settlement is a payment from a debtor to a creditor that settles (part of) their debts.
+type Settlement struct {
+	From   string
+	To     string
+	Amount *money.Money
+}
+
+func FromCostCalcEdge(edge costcalc.Edge) Settlement {
+	return Settlement{From: edge.Source, To: edge.Target, Amount: edge.Amount}
+}
+
+// ToTransferTransaction turns the settlement into a transfer, so it is taken into account for all future cost calculations.
+func (s *Settlement) ToTransferTransaction(projectID uuid.UUID) Transaction {
+	return Transaction{
+		ProjectID:       projectID,
+		ID:              NewID(),
+		Name:            fmt.Sprintf("Settlement %s to %s", s.From, s.To),
+		TransactionType: TransferTransactionType,
+		Amount:          s.Amount,
+		SourceID:        s.From,
+		TargetIDs:       []string{s.To},
+		SplitMode:       EqualSplitMode,
+		OccurredAt:      Today(),
 	}
 }
 
 type Project struct {
 	ID           uuid.UUID
 	Name         string
+	Currency     string
 	Transactions []Transaction
 	Members      []string
+	// Roles maps every member to their role
+	Roles map[string]Role
+	// DisplayNames contains the name of every member, it is only set for projects returned to the caller
+	DisplayNames map[string]string
+	// ArchivedAt is zero for active projects, archived projects are read-only
+	ArchivedAt time.Time
+}
+
+func (p *Project) Archived() bool {
+	return !p.ArchivedAt.IsZero()
+}
+
+// ProjectUpdate contains the changes to a project, nil fields are not changed.
+type ProjectUpdate struct {
+	Name     *string
+	Archived *bool
+}
+
+type ProjectQuery struct {
+	// Limit is the maximum page size, 0 uses the DefaultPageSize
+	Limit int
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+	Name   string
+	Member string
+	// IncludeArchived also returns archived projects
+	IncludeArchived bool
+}
+
+// ProjectSummary is a project without its transactions.
+type ProjectSummary struct {
+	Project
+	TransactionCount int
+	// TotalAmount is the sum of all transactions in the project currency
+	TotalAmount *money.Money
+}
+
+type TransactionQuery struct {
+	// Limit is the maximum page size, 0 uses the DefaultPageSize
+	Limit int
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+
+	// TransactionType filters by type, UndefinedTransactionType or an empty string return all types
+	TransactionType TransactionType
+	SourceID        string
+	TargetID        string
+	// MinAmount and MaxAmount are inclusive and given in major units of the project currency, e.g. 19.99.
+	// Transactions in other currencies never match, their amounts can't be compared without converting them.
+	MinAmount *float64
+	MaxAmount *float64
+	// From is inclusive, To is exclusive
+	From *time.Time
+	To   *time.Time
+
+	SortBy     TransactionSort
+	Descending bool
+}
+
+type TransactionSort string
+
+const (
+	SortByDate   TransactionSort = "date"
+	SortByAmount TransactionSort = "amount"
+)
+
+type TransactionPage struct {
+	Transactions []Transaction
+	// NextCursor is empty if this is the last page
+	NextCursor string
+}
+
+// toStorageFilter returns the filter of the query, currency is the currency of the project.
+func (q *TransactionQuery) toStorageFilter(projectID uuid.UUID, currency string) storage.TransactionFilter {
+	filter := storage.TransactionFilter{
+		ProjectID:  projectID,
+		SourceID:   q.SourceID,
+		TargetID:   q.TargetID,
+		SortBy:     storage.SortByDate,
+		Descending: q.Descending,
+	}
+	if q.TransactionType != "" && q.TransactionType != UndefinedTransactionType {
+		filter.TransactionType = string(q.TransactionType)
+	}
+	// amounts in different currencies can't be compared, so only transactions in the project currency match
+	if q.MinAmount != nil || q.MaxAmount != nil {
+		filter.Currency = currency
+	}
+	if q.MinAmount != nil {
+		filter.MinAmount = sql.NullInt64{Int64: FromMajorUnits(*q.MinAmount, currency).Amount(), Valid: true}
+	}
+	if q.MaxAmount != nil {
+		filter.MaxAmount = sql.NullInt64{Int64: FromMajorUnits(*q.MaxAmount, currency).Amount(), Valid: true}
+	}
+	if q.From != nil {
+		filter.From = sql.NullTime{Time: *q.From, Valid: true}
+	}
+	if q.To != nil {
+		filter.To = sql.NullTime{Time: *q.To, Valid: true}
+	}
+	if q.SortBy == SortByAmount {
+		filter.SortBy = storage.SortByAmount
+	}
+	return filter
+}
+
+type ProjectPage struct {
+	Projects []Project
+	// NextCursor is empty if this is the last page
+	NextCursor string
 }
 
 func FromStorageProject(project storage.Project) Project {
@@ -78,11 +347,19 @@ func FromStorageProject(project storage.Project) Project {
 		transactions[i] = FromStorageTransaction(t)
 	}
 
+	roles := make(map[string]Role, len(project.Members))
+	for _, m := range project.Members {
+		roles[m] = ParseRole(project.Roles[m])
+	}
+
 	return Project{
 		ID:           project.ID,
 		Name:         project.Name,
+		Currency:     orDefaultCurrency(project.Currency),
 		Transactions: transactions,
 		Members:      project.Members,
+		Roles:        roles,
+		ArchivedAt:   project.ArchivedAt.Time,
 	}
 }
 
@@ -92,33 +369,58 @@ func ToStorageProject(proj Project) storage.Project {
 		transactions[i] = ToStorageTransaction(t)
 	}
 
+	roles := make(map[string]string, len(proj.Roles))
+	for member, role := range proj.Roles {
+		roles[member] = string(role)
+	}
+
 	return storage.Project{
 		ID:           proj.ID,
 		Name:         proj.Name,
+		Currency:     orDefaultCurrency(proj.Currency),
 		Transactions: transactions,
 		Members:      proj.Members,
+		Roles:        roles,
+		ArchivedAt:   sql.NullTime{Time: proj.ArchivedAt, Valid: proj.Archived()},
 	}
 }
 
 func ToStorageTransaction(trans Transaction) storage.Transaction {
 	return storage.Transaction{
 		ID:   trans.ID,
-		Name: trans.Name, Amount: int(trans.Amount.Amount()),
+		Name: trans.Name, Amount: int(trans.Amount.Amount()), Currency: trans.Amount.Currency().Code,
 		SourceID:        trans.SourceID,
 		TargetIDs:       trans.TargetIDs,
 		TransactionType: string(trans.TransactionType),
+		SplitMode:       string(trans.SplitMode),
+		Weights:         trans.Weights,
+		OccurredAt:      trans.OccurredAt,
+		CreatedBy:       trans.CreatedBy,
 	}
 }
 
 func FromStorageTransaction(trans storage.Transaction) Transaction {
 	return Transaction{
 		ID:   trans.ID,
-		Name: trans.Name, Amount: money.New(int64(trans.Amount), money.EUR),
+		Name: trans.Name, Amount: money.New(int64(trans.Amount), orDefaultCurrency(trans.Currency)),
 		SourceID:        trans.SourceID,
 		TargetIDs:       trans.TargetIDs,
 		TransactionType: ParseTransactionType(trans.TransactionType),
 		ProjectID:       trans.ProjectID,
+		SplitMode:       ParseSplitMode(trans.SplitMode),
+		Weights:         trans.Weights,
+		OccurredAt:      ToDate(trans.OccurredAt),
+		CreatedAt:       trans.CreatedAt,
+		UpdatedAt:       trans.UpdatedAt,
+		CreatedBy:       trans.CreatedBy,
+	}
+}
+
+func orDefaultCurrency(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
 	}
+	return currency
 }
 
 func FromCostCalcCost(cost costcalc.Cost) Cost {
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/diezfx/split-app-backend/internal/apperror"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 100
+)
+
+var ErrInvalidCursor = apperror.NewValidationError(apperror.FieldError{Field: "cursor", Code: "invalid", Message: "invalid cursor"})
+
+// pageSize returns the limit bounded by MaxPageSize, a limit of 0 returns the DefaultPageSize.
+func pageSize(limit int) int {
+	switch {
+	case limit <= 0:
+		return DefaultPageSize
+	case limit > MaxPageSize:
+		return MaxPageSize
+	default:
+		return limit
+	}
+}
+
+// encodeCursor hides the position the next page starts after, clients should treat cursors as opaque.
+func encodeCursor(position any) string {
+	// the positions are plain structs and ids, marshalling them can't fail
+	raw, _ := json.Marshal(position)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor fills position from the cursor and reports whether a cursor was given at all.
+func decodeCursor(cursor string, position any) (bool, error) {
+	if cursor == "" {
+		return false, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return false, fmt.Errorf("decode %q: %w: %w", cursor, ErrInvalidCursor, err)
+	}
+	err = json.Unmarshal(raw, position)
+	if err != nil {
+		return false, fmt.Errorf("unmarshal %q: %w: %w", cursor, ErrInvalidCursor, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/diezfx/split-app-backend/internal/contextutil"
+	"github.com/diezfx/split-app-backend/internal/recurrence"
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+// RecurringTransaction adds a copy of its template to the project on every occurrence of its schedule.
+type RecurringTransaction struct {
+	ID        uuid.UUID
+	ProjectID uuid.UUID
+	// Template holds the fields of the added transactions, its id and dates are ignored
+	Template Transaction
+	Schedule recurrence.Schedule
+	// NextOccurrence is the day the next transaction is added, it is zero once the schedule ended
+	NextOccurrence time.Time
+	CreatedBy      string
+	CreatedAt      time.Time
+}
+
+// Occurrence returns the transaction for the occurrence on day. Its id is derived from the recurring transaction
+// and the day, so adding the same occurrence twice fails instead of creating a duplicate.
+func (rt *RecurringTransaction) Occurrence(day time.Time) Transaction {
+	tx := rt.Template
+	tx.ID = uuid.NewSHA1(rt.ID, []byte(day.Format(time.DateOnly)))
+	tx.ProjectID = rt.ProjectID
+	tx.TargetIDs = slices.Clone(rt.Template.TargetIDs)
+	tx.Weights = slices.Clone(rt.Template.Weights)
+	tx.OccurredAt = day
+	tx.CreatedBy = rt.CreatedBy
+	return tx
+}
+
+// AddRecurringTransaction adds the recurring transaction, its first transaction is added on the start of its schedule.
+// Starts in the past are caught up on by the Scheduler.
+func (s *Service) AddRecurringTransaction(ctx context.Context, projID uuid.UUID, rt RecurringTransaction) (RecurringTransaction, error) {
+	proj, err := s.getWritableProject(ctx, projID, EditorRole)
+	if err != nil {
+		return RecurringTransaction{}, err
+	}
+	if err := checkMembers(proj.Members, &rt.Template); err != nil {
+		return RecurringTransaction{}, err
+	}
+
+	if rt.ID == uuid.Nil {
+		rt.ID = NewID()
+	}
+	rt.ProjectID = projID
+	rt.CreatedBy = contextutil.GetUserIDFromCtx(ctx)
+	rt.CreatedAt = time.Now().UTC()
+	rt.NextOccurrence, _ = rt.Schedule.Next(rt.Schedule.Start)
+	err = s.projStorage.AddRecurringTransaction(ctx, toStorageRecurringTransaction(rt))
+	if err != nil {
+		return RecurringTransaction{}, fmt.Errorf("add recurring transaction: %w", err)
+	}
+	return rt, nil
+}
+
+// GetRecurringTransactions returns the recurring transactions of the project, including the ones that ended.
+func (s *Service) GetRecurringTransactions(ctx context.Context, projID uuid.UUID) ([]RecurringTransaction, error) {
+	if err := s.authorizeProject(ctx, projID, ViewerRole); err != nil {
+		return nil, err
+	}
+	storageRecurring, err := s.projStorage.GetRecurringTransactions(ctx, projID)
+	if err != nil {
+		return nil, fmt.Errorf("get recurring transactions: %w", err)
+	}
+	recurring := make([]RecurringTransaction, 0, len(storageRecurring))
+	for _, rt := range storageRecurring {
+		recurring = append(recurring, fromStorageRecurringTransaction(rt))
+	}
+	return recurring, nil
+}
+
+// DeleteRecurringTransaction stops the recurring transaction, the transactions it already added are kept.
+func (s *Service) DeleteRecurringTransaction(ctx context.Context, projID, id uuid.UUID) error {
+	if _, err := s.getWritableProject(ctx, projID, EditorRole); err != nil {
+		return err
+	}
+	err := s.projStorage.DeleteRecurringTransaction(ctx, projID, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrRecurringTransactionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("delete recurring transaction: %w", err)
+	}
+	return nil
+}
+
+// checkNoRecurringTransactions returns ErrMemberInRecurringTransaction if the user is part of a recurring transaction
+// of the project that has occurrences left.
+func (s *Service) checkNoRecurringTransactions(ctx context.Context, projID uuid.UUID, userID string) error {
+	recurring, err := s.projStorage.GetRecurringTransactions(ctx, projID)
+	if err != nil {
+		return fmt.Errorf("get recurring transactions: %w", err)
+	}
+	for _, rt := range recurring {
+		if rt.NextOccurrence.Valid && (rt.SourceID == userID || slices.Contains(rt.TargetIDs, userID)) {
+			return fmt.Errorf("recurring transaction %s: %w", rt.ID, ErrMemberInRecurringTransaction)
+		}
+	}
+	return nil
+}
+
+// addDueRecurringTransactions adds the transactions of all occurrences up to and including the day of now
+// and returns how many were added. A failing recurring transaction doesn't stop the others, it is retried on the next call.
+func (s *Service) addDueRecurringTransactions(ctx context.Context, now time.Time) (int, error) {
+	today := ToDate(now)
+	due, err := s.projStorage.GetDueRecurringTransactions(ctx, today)
+	if err != nil {
+		return 0, fmt.Errorf("get due recurring transactions: %w", err)
+	}
+
+	added := 0
+	var errs error
+	for _, rt := range due {
+		count, err := s.addOccurrences(ctx, fromStorageRecurringTransaction(rt), today)
+		added += count
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("recurring transaction %s: %w", rt.ID, err))
+		}
+	}
+	return added, errs
+}
+
+// addOccurrences adds the transactions from the next occurrence up to today and moves the next occurrence after today.
+// Occurrences that were already added, e.g. before a crash or by another instance, are skipped.
+// Nothing is added while the source or a target is no member of the project anymore.
+func (s *Service) addOccurrences(ctx context.Context, rt RecurringTransaction, today time.Time) (int, error) {
+	users, err := s.projStorage.GetProjectUsers(ctx, rt.ProjectID)
+	if err != nil {
+		return 0, fmt.Errorf("get members: %w", err)
+	}
+	members := make([]string, 0, len(users))
+	for _, u := range users {
+		members = append(members, u.ID)
+	}
+	if err := checkMembers(members, &rt.Template); err != nil {
+		return 0, err
+	}
+
+	added := 0
+	next, ok := rt.NextOccurrence, !rt.NextOccurrence.IsZero()
+	for ok && !next.After(today) {
+		err := s.projStorage.AddTransaction(ctx, rt.ProjectID, ToStorageTransaction(rt.Occurrence(next)))
+		switch {
+		case errors.Is(err, storage.ErrAlreadyExists):
+		case err != nil:
+			return added, fmt.Errorf("add occurrence on %s: %w", next.Format(time.DateOnly), err)
+		default:
+			added++
+		}
+		next, ok = rt.Schedule.Next(next.AddDate(0, 0, 1))
+	}
+
+	err = s.projStorage.SetNextOccurrence(ctx, rt.ID, sql.NullTime{Time: next, Valid: ok})
+	// the recurring transaction was deleted in the meantime
+	if errors.Is(err, storage.ErrNotFound) {
+		return added, nil
+	}
+	if err != nil {
+		return added, fmt.Errorf("set next occurrence: %w", err)
+	}
+	return added, nil
+}
+
+func toStorageRecurringTransaction(rt RecurringTransaction) storage.RecurringTransaction {
+	tx := ToStorageTransaction(rt.Template)
+	return storage.RecurringTransaction{
+		ID: rt.ID, ProjectID: rt.ProjectID, Name: tx.Name, TransactionType: tx.TransactionType,
+		Amount: tx.Amount, Currency: tx.Currency, SourceID: tx.SourceID, TargetIDs: tx.TargetIDs,
+		SplitMode: tx.SplitMode, Weights: tx.Weights,
+		Frequency: string(rt.Schedule.Frequency), Interval: rt.Schedule.Interval, StartDate: rt.Schedule.Start,
+		EndDate:        sql.NullTime{Time: rt.Schedule.End, Valid: !rt.Schedule.End.IsZero()},
+		NextOccurrence: sql.NullTime{Time: rt.NextOccurrence, Valid: !rt.NextOccurrence.IsZero()},
+		CreatedBy:      rt.CreatedBy, CreatedAt: rt.CreatedAt,
+	}
+}
+
+func fromStorageRecurringTransaction(rt storage.RecurringTransaction) RecurringTransaction {
+	recurring := RecurringTransaction{
+		ID: rt.ID, ProjectID: rt.ProjectID,
+		Template: Transaction{
+			ProjectID: rt.ProjectID, Name: rt.Name, TransactionType: ParseTransactionType(rt.TransactionType),
+			Amount: money.New(int64(rt.Amount), orDefaultCurrency(rt.Currency)), SourceID: rt.SourceID,
+			TargetIDs: rt.TargetIDs, SplitMode: ParseSplitMode(rt.SplitMode), Weights: rt.Weights,
+		},
+		Schedule: recurrence.Schedule{
+			Frequency: recurrence.ParseFrequency(rt.Frequency), Interval: rt.Interval, Start: ToDate(rt.StartDate),
+		},
+		CreatedBy: rt.CreatedBy, CreatedAt: rt.CreatedAt,
+	}
+	if rt.EndDate.Valid {
+		recurring.Schedule.End = ToDate(rt.EndDate.Time)
+	}
+	if rt.NextOccurrence.Valid {
+		recurring.NextOccurrence = ToDate(rt.NextOccurrence.Time)
+	}
+	return recurring
+}
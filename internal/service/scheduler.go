@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/diezfx/split-app-backend/pkg/logger"
+)
+
+// DefaultSchedulerInterval is how often the Scheduler runs.
+const DefaultSchedulerInterval = 10 * time.Minute
+
+// Clock tells the Scheduler what time it is.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock of the running system.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Scheduler adds the transactions of recurring transactions once they are due and deletes expired idempotency keys.
+type Scheduler struct {
+	service  *Service
+	clock    Clock
+	interval time.Duration
+}
+
+func NewScheduler(service *Service, clock Clock, interval time.Duration) *Scheduler {
+	return &Scheduler{service: service, clock: clock, interval: interval}
+}
+
+// Run adds the due transactions right away and then every interval until the context is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce adds the transactions that are due at the time of the clock and returns how many were added.
+// Errors are logged, the failed occurrences are retried on the next run.
+func (s *Scheduler) RunOnce(ctx context.Context) int {
+	now := s.clock.Now()
+	added, err := s.service.addDueRecurringTransactions(ctx, now)
+	if err != nil {
+		logger.Error(ctx, err).Int("added", added).Msg("add recurring transactions")
+	}
+	if added > 0 {
+		logger.Info(ctx).Int("added", added).Msg("added recurring transactions")
+	}
+
+	deleted, err := s.service.deleteExpiredIdempotencyRecords(ctx, now)
+	if err != nil {
+		logger.Error(ctx, err).Msg("delete expired idempotency keys")
+	}
+	if deleted > 0 {
+		logger.Info(ctx).Int("deleted", deleted).Msg("deleted expired idempotency keys")
+	}
+	return added
+}
@@ -4,21 +4,37 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Rhymond/go-money"
+	"github.com/diezfx/split-app-backend/internal/apperror"
+	"github.com/diezfx/split-app-backend/internal/contextutil"
 	"github.com/diezfx/split-app-backend/internal/costcalc"
+	"github.com/diezfx/split-app-backend/internal/invite"
 	"github.com/diezfx/split-app-backend/internal/storage"
 	"github.com/google/uuid"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 )
 
 type Service struct {
 	projStorage ProjectStorage
+	rates       ExchangeRateProvider
+	invites     *invite.Signer
 }
 
-// AddProjectUser implements api.ProjectService.
-func (s *Service) AddProjectUser(ctx context.Context, projID uuid.UUID, userID string) error {
-	if _, err := s.projStorage.GetUser(ctx, userID); err != nil {
+// AddProjectUser adds the user with the role to the project, only owners may add members.
+func (s *Service) AddProjectUser(ctx context.Context, projID uuid.UUID, userID string, role Role) error {
+	if _, err := s.getWritableProject(ctx, projID, OwnerRole); err != nil {
+		return err
+	}
+	return s.addProjectUser(ctx, projID, userID, role)
+}
+
+// addProjectUser adds the user to the project without checking the permissions of the caller.
+// Users that don't exist yet are created.
+func (s *Service) addProjectUser(ctx context.Context, projID uuid.UUID, userID string, role Role) error {
+	if user, err := s.projStorage.GetUser(ctx, userID); err != nil {
 		if err != nil && !errors.Is(err, storage.ErrNotFound) {
 			return fmt.Errorf("get user: %w", err)
 		}
@@ -28,17 +44,118 @@ func (s *Service) AddProjectUser(ctx context.Context, projID uuid.UUID, userID s
 				return fmt.Errorf("add user:%w", err)
 			}
 		}
+	} else if err := checkNoGuest(&user, projID); err != nil {
+		return err
 	}
 
-	err := s.projStorage.AddProjectUser(ctx, projID, userID)
+	err := s.projStorage.AddProjectUser(ctx, projID, userID, string(role))
 	if err != nil {
 		return fmt.Errorf("add project user: %w", err)
 	}
 	return err
 }
 
-// GetProjectUsers implements api.ProjectService.
+// RemoveProjectUser removes the user from the project, their past transactions still reference them.
+// A user with a balance is only removed with force, their balance is then settled with transfers which are returned.
+// Recurring transactions the user is part of have to be deleted first, otherwise they would keep charging them.
+func (s *Service) RemoveProjectUser(ctx context.Context, projID uuid.UUID, userID string, force bool) ([]Transaction, error) {
+	proj, err := s.getWritableProject(ctx, projID, OwnerRole)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(proj.Members, userID) {
+		return nil, ErrMemberNotFound
+	}
+	if err := s.checkNoRecurringTransactions(ctx, projID, userID); err != nil {
+		return nil, err
+	}
+
+	settlements, err := s.memberSettlements(ctx, &proj, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(settlements) > 0 && !force {
+		return nil, ErrMemberHasBalance
+	}
+
+	transactions := make([]Transaction, 0, len(settlements))
+	storageTransactions := make([]storage.Transaction, 0, len(settlements))
+	for i := range settlements {
+		tx := settlements[i].ToTransferTransaction(projID)
+		stampTransaction(ctx, &tx)
+		transactions = append(transactions, tx)
+		storageTransactions = append(storageTransactions, ToStorageTransaction(tx))
+	}
+
+	err = s.projStorage.RemoveProjectUser(ctx, projID, userID, storageTransactions)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrMemberNotFound
+	}
+	if errors.Is(err, storage.ErrLastOwner) {
+		return nil, fmt.Errorf("remove %s: %w", userID, ErrLastOwner)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("remove project user: %w", err)
+	}
+	return transactions, nil
+}
+
+// UpdateProjectUserRole changes the role of a member, only owners may change roles.
+func (s *Service) UpdateProjectUserRole(ctx context.Context, projID uuid.UUID, userID string, role Role) error {
+	proj, err := s.getWritableProject(ctx, projID, OwnerRole)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(proj.Members, userID) {
+		return ErrMemberNotFound
+	}
+
+	err = s.projStorage.UpdateProjectUserRole(ctx, projID, userID, string(role))
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrMemberNotFound
+	}
+	if errors.Is(err, storage.ErrLastOwner) {
+		return fmt.Errorf("change role of %s: %w", userID, ErrLastOwner)
+	}
+	if err != nil {
+		return fmt.Errorf("update role: %w", err)
+	}
+	return nil
+}
+
+// memberSettlements returns the settlements that bring the balance of the user to zero, none if it already is.
+func (s *Service) memberSettlements(ctx context.Context, proj *Project, userID string) ([]Settlement, error) {
+	costCalcTransactions, err := s.costCalcTransactions(ctx, proj)
+	if err != nil {
+		return nil, err
+	}
+	calculator, err := costcalc.New(proj.Currency, costCalcTransactions)
+	if err != nil {
+		return nil, fmt.Errorf("calc costs: %w", err)
+	}
+	cost, err := calculator.CalculateCostForUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("calc costs: %w", err)
+	}
+	if cost.Balance.IsZero() {
+		return nil, nil
+	}
+
+	var settlements []Settlement
+	for _, edge := range calculator.CalculateMinCostFlow() {
+		if edge.Source == userID || edge.Target == userID {
+			settlements = append(settlements, FromCostCalcEdge(edge))
+		}
+	}
+	return settlements, nil
+}
+
+// GetProjectUsers returns the members of the project with their role.
 func (s *Service) GetProjectUsers(ctx context.Context, projectID uuid.UUID) ([]User, error) {
+	if err := s.authorizeProject(ctx, projectID, ViewerRole); err != nil {
+		return nil, err
+	}
+
 	sUsers, err := s.projStorage.GetProjectUsers(ctx, projectID)
 	if err != nil {
 		return nil, fmt.Errorf("getProjectUsers: %w", err)
@@ -47,32 +164,303 @@ func (s *Service) GetProjectUsers(ctx context.Context, projectID uuid.UUID) ([]U
 	users := make([]User, 0, len(sUsers))
 
 	for _, u := range sUsers {
-		users = append(users, User{ID: u.ID})
+		user := FromStorageUser(u.User)
+		user.Role = ParseRole(u.Role)
+		users = append(users, user)
 	}
 	return users, nil
 }
 
-// AddTransaction implements api.ProjectService.
-func (s *Service) AddTransaction(ctx context.Context, projID uuid.UUID, transaction Transaction) error {
-	_, err := s.projStorage.GetProjectByID(ctx, projID)
+// GetCurrentUser returns the profile of the calling user, users that never set it get an empty profile.
+func (s *Service) GetCurrentUser(ctx context.Context) (User, error) {
+	userID := contextutil.GetUserIDFromCtx(ctx)
+	if userID == "" {
+		return User{}, fmt.Errorf("no user in context: %w", ErrForbidden)
+	}
+
+	user, err := s.projStorage.GetUser(ctx, userID)
 	if errors.Is(err, storage.ErrNotFound) {
-		return ErrProjectNotFound
+		return User{ID: userID}, nil
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("get user: %w", err)
+	}
+	return FromStorageUser(user), nil
+}
+
+// UpdateCurrentUser replaces the profile of the calling user, the id of the given user is ignored.
+func (s *Service) UpdateCurrentUser(ctx context.Context, user User) (User, error) {
+	userID := contextutil.GetUserIDFromCtx(ctx)
+	if userID == "" {
+		return User{}, fmt.Errorf("no user in context: %w", ErrForbidden)
 	}
+
+	user.ID = userID
+	err := s.projStorage.UpdateUser(ctx, ToStorageUser(user))
+	if err != nil {
+		return User{}, fmt.Errorf("update user: %w", err)
+	}
+	return user, nil
+}
+
+// displayNames returns the name of every given user, see User.Name.
+func (s *Service) displayNames(ctx context.Context, userIDs []string) (map[string]string, error) {
+	users, err := s.projStorage.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get users: %w", err)
+	}
+	names := make(map[string]string, len(userIDs))
+	for _, id := range userIDs {
+		names[id] = id
+	}
+	for _, u := range users {
+		user := FromStorageUser(u)
+		names[user.ID] = user.Name()
+	}
+	return names, nil
+}
+
+// addDisplayNames sets the display names of the members of all projects, the users are loaded at once.
+func (s *Service) addDisplayNames(ctx context.Context, projects ...*Project) error {
+	var members []string
+	for _, p := range projects {
+		members = append(members, p.Members...)
+	}
+	slices.Sort(members)
+	names, err := s.displayNames(ctx, slices.Compact(members))
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		p.DisplayNames = make(map[string]string, len(p.Members))
+		for _, m := range p.Members {
+			p.DisplayNames[m] = names[m]
+		}
+	}
+	return nil
+}
+
+// AddTransaction adds the transaction and returns it with the fields set by the server.
+func (s *Service) AddTransaction(ctx context.Context, projID uuid.UUID, transaction Transaction) (Transaction, error) {
+	proj, err := s.getWritableProject(ctx, projID, EditorRole)
 	if err != nil {
-		return fmt.Errorf("get project:%w", err)
+		return Transaction{}, err
+	}
+	if err := checkMembers(proj.Members, &transaction); err != nil {
+		return Transaction{}, err
 	}
+	transaction.ProjectID = projID
+	stampTransaction(ctx, &transaction)
 	err = s.projStorage.AddTransaction(ctx, projID, ToStorageTransaction(transaction))
 	if err != nil {
-		return fmt.Errorf("add transaction: %w", err)
+		return Transaction{}, fmt.Errorf("add transaction: %w", err)
+	}
+	return transaction, nil
+}
+
+// GetTransaction returns a single transaction of the project.
+func (s *Service) GetTransaction(ctx context.Context, projID, transactionID uuid.UUID) (Transaction, error) {
+	if err := s.authorizeProject(ctx, projID, ViewerRole); err != nil {
+		return Transaction{}, err
+	}
+
+	txs, err := s.projStorage.GetTransactions(ctx, storage.TransactionFilter{
+		ProjectID: projID,
+		ID:        uuid.NullUUID{UUID: transactionID, Valid: true},
+		Limit:     1,
+	})
+	if err != nil {
+		return Transaction{}, fmt.Errorf("get transaction: %w", err)
+	}
+	if len(txs) == 0 {
+		return Transaction{}, ErrTransactionNotFound
+	}
+	return FromStorageTransaction(txs[0]), nil
+}
+
+// AddTransactions adds all transactions or none of them if one fails.
+func (s *Service) AddTransactions(ctx context.Context, projID uuid.UUID, transactions []Transaction) ([]Transaction, error) {
+	proj, err := s.getWritableProject(ctx, projID, EditorRole)
+	if err != nil {
+		return nil, err
+	}
+	var fields []apperror.FieldError
+	for i := range transactions {
+		fields = append(fields, memberFieldErrors(fmt.Sprintf("transactions[%d].", i), &transactions[i], proj.Members)...)
+	}
+	if len(fields) > 0 {
+		return nil, apperror.NewValidationError(fields...)
+	}
+
+	storageTransactions := make([]storage.Transaction, 0, len(transactions))
+	for i := range transactions {
+		transactions[i].ProjectID = projID
+		stampTransaction(ctx, &transactions[i])
+		storageTransactions = append(storageTransactions, ToStorageTransaction(transactions[i]))
+	}
+	err = s.projStorage.AddTransactions(ctx, projID, storageTransactions)
+	if err != nil {
+		return nil, fmt.Errorf("add transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// stampTransaction sets the fields that are decided by the server when a transaction is created.
+// Clients may choose the id themselves, otherwise one is generated.
+func stampTransaction(ctx context.Context, transaction *Transaction) {
+	if transaction.ID == uuid.Nil {
+		transaction.ID = NewID()
+	}
+	transaction.CreatedBy = contextutil.GetUserIDFromCtx(ctx)
+	if transaction.OccurredAt.IsZero() {
+		transaction.OccurredAt = Today()
+	}
+}
+
+// UpdateTransaction implements api.ProjectService.
+func (s *Service) UpdateTransaction(ctx context.Context, projID uuid.UUID, transaction Transaction) (Transaction, error) {
+	proj, err := s.getWritableProject(ctx, projID, EditorRole)
+	if err != nil {
+		return Transaction{}, err
+	}
+	// members that left the project can stay on the transactions they were part of
+	members := proj.Members
+	for _, tx := range proj.Transactions {
+		if tx.ID == transaction.ID {
+			members = append(slices.Clone(members), tx.SourceID)
+			members = append(members, tx.TargetIDs...)
+			// a missing date keeps the stored one
+			if transaction.OccurredAt.IsZero() {
+				transaction.OccurredAt = tx.OccurredAt
+			}
+		}
+	}
+	if err := checkMembers(members, &transaction); err != nil {
+		return Transaction{}, err
+	}
+	err = s.projStorage.UpdateTransaction(ctx, projID, ToStorageTransaction(transaction))
+	if errors.Is(err, storage.ErrNotFound) {
+		return Transaction{}, ErrTransactionNotFound
+	}
+	if err != nil {
+		return Transaction{}, fmt.Errorf("update transaction: %w", err)
+	}
+
+	// the storage keeps the creation stamps and sets the update time, so the stored transaction is returned
+	txs, err := s.projStorage.GetTransactions(ctx, storage.TransactionFilter{
+		ProjectID: projID,
+		ID:        uuid.NullUUID{UUID: transaction.ID, Valid: true},
+		Limit:     1,
+	})
+	if err != nil {
+		return Transaction{}, fmt.Errorf("get updated transaction: %w", err)
+	}
+	if len(txs) == 0 {
+		return Transaction{}, ErrTransactionNotFound
+	}
+	return FromStorageTransaction(txs[0]), nil
+}
+
+// DeleteTransaction implements api.ProjectService.
+func (s *Service) DeleteTransaction(ctx context.Context, projID, transactionID uuid.UUID) error {
+	_, err := s.getWritableProject(ctx, projID, EditorRole)
+	if err != nil {
+		return err
+	}
+	err = s.projStorage.DeleteTransaction(ctx, projID, transactionID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrTransactionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("delete transaction: %w", err)
 	}
 	return nil
 }
 
-func New(projStorage ProjectStorage) *Service {
-	return &Service{projStorage: projStorage}
+// New creates the service, invites are disabled if no signer is given.
+func New(projStorage ProjectStorage, rates ExchangeRateProvider, invites *invite.Signer) *Service {
+	return &Service{projStorage: projStorage, rates: rates, invites: invites}
 }
 
 func (s *Service) GetProjectByID(ctx context.Context, id uuid.UUID) (Project, error) {
+	if err := s.authorizeProject(ctx, id, ViewerRole); err != nil {
+		return Project{}, err
+	}
+	proj, err := s.getProject(ctx, id)
+	if err != nil {
+		return Project{}, err
+	}
+	return proj, s.addDisplayNames(ctx, &proj)
+}
+
+// getWritableProject returns the project if the caller has the role to change it, archived projects are read-only.
+func (s *Service) getWritableProject(ctx context.Context, id uuid.UUID, role Role) (Project, error) {
+	if err := s.authorizeProject(ctx, id, role); err != nil {
+		return Project{}, err
+	}
+	proj, err := s.getProject(ctx, id)
+	if err != nil {
+		return Project{}, err
+	}
+	if proj.Archived() {
+		return Project{}, fmt.Errorf("project %s: %w", id, ErrProjectArchived)
+	}
+	return proj, nil
+}
+
+// UpdateProject renames, archives or unarchives the project. An archived project can only be renamed together with unarchiving it.
+// Only owners may change the project.
+func (s *Service) UpdateProject(ctx context.Context, id uuid.UUID, update ProjectUpdate) (Project, error) {
+	if err := s.authorizeProject(ctx, id, OwnerRole); err != nil {
+		return Project{}, err
+	}
+	proj, err := s.getProject(ctx, id)
+	if err != nil {
+		return Project{}, err
+	}
+	unarchive := update.Archived != nil && !*update.Archived
+	if proj.Archived() && update.Name != nil && !unarchive {
+		return Project{}, fmt.Errorf("rename project %s: %w", id, ErrProjectArchived)
+	}
+
+	if update.Name != nil {
+		proj.Name = *update.Name
+	}
+	switch {
+	case update.Archived == nil:
+	case *update.Archived && !proj.Archived():
+		proj.ArchivedAt = time.Now().UTC()
+	case !*update.Archived:
+		proj.ArchivedAt = time.Time{}
+	}
+
+	err = s.projStorage.UpdateProject(ctx, ToStorageProject(proj))
+	if errors.Is(err, storage.ErrNotFound) {
+		return Project{}, ErrProjectNotFound
+	}
+	if err != nil {
+		return Project{}, fmt.Errorf("update project: %w", err)
+	}
+	return proj, s.addDisplayNames(ctx, &proj)
+}
+
+// DeleteProject removes the project together with all of its transactions and memberships, only owners may delete it.
+func (s *Service) DeleteProject(ctx context.Context, id uuid.UUID) error {
+	if err := s.authorizeProject(ctx, id, OwnerRole); err != nil {
+		return err
+	}
+	err := s.projStorage.DeleteProject(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrProjectNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("delete project: %w", err)
+	}
+	return nil
+}
+
+// getProject returns the project without checking the permissions of the caller.
+func (s *Service) getProject(ctx context.Context, id uuid.UUID) (Project, error) {
 	proj, err := s.projStorage.GetProjectByID(ctx, id)
 	if errors.Is(err, storage.ErrNotFound) {
 		return Project{}, ErrProjectNotFound
@@ -83,30 +471,78 @@ func (s *Service) GetProjectByID(ctx context.Context, id uuid.UUID) (Project, er
 	return FromStorageProject(proj), nil
 }
 
-func (s *Service) GetProjects(ctx context.Context) ([]Project, error) {
-	projs, err := s.projStorage.GetProjects(ctx)
-	if errors.Is(err, storage.ErrNotFound) {
-		return nil, ErrProjectNotFound
+// GetProjects returns one page of the projects the calling user is a member of.
+func (s *Service) GetProjects(ctx context.Context, query ProjectQuery) (ProjectPage, error) {
+	var err error
+	userID := contextutil.GetUserIDFromCtx(ctx)
+	if userID == "" {
+		return ProjectPage{}, fmt.Errorf("no user in context: %w", ErrForbidden)
 	}
+
+	var after uuid.NullUUID
+	after.Valid, err = decodeCursor(query.Cursor, &after.UUID)
 	if err != nil {
-		return nil, fmt.Errorf("get project:%w", err)
+		return ProjectPage{}, err
+	}
+	limit := pageSize(query.Limit)
+
+	// fetch one more to know if there is a next page
+	projs, err := s.projStorage.GetProjects(ctx, storage.ProjectFilter{
+		UserID: userID,
+		Member: query.Member,
+		Name:   query.Name,
+		After:  after,
+		Limit:  limit + 1,
+
+		IncludeArchived: query.IncludeArchived,
+	})
+	if err != nil {
+		return ProjectPage{}, fmt.Errorf("get project:%w", err)
 	}
 
-	projectList := make([]Project, 0, len(projs))
+	page := ProjectPage{Projects: make([]Project, 0, len(projs))}
+	if len(projs) > limit {
+		projs = projs[:limit]
+		page.NextCursor = encodeCursor(projs[limit-1].ID)
+	}
 	for _, p := range projs {
-		projectList = append(projectList, FromStorageProject(p))
+		page.Projects = append(page.Projects, FromStorageProject(p))
+	}
+	projects := make([]*Project, 0, len(page.Projects))
+	for i := range page.Projects {
+		projects = append(projects, &page.Projects[i])
+	}
+	if err := s.addDisplayNames(ctx, projects...); err != nil {
+		return ProjectPage{}, err
 	}
 
-	return projectList, nil
+	return page, nil
 }
 
+// AddProject creates the project, the calling user always becomes its owner and the other members editors.
 func (s *Service) AddProject(ctx context.Context, project Project) (Project, error) {
+	userID := contextutil.GetUserIDFromCtx(ctx)
+	if userID == "" {
+		return Project{}, fmt.Errorf("no user in context: %w", ErrForbidden)
+	}
+	if !slices.Contains(project.Members, userID) {
+		project.Members = append(project.Members, userID)
+	}
+	project.Roles = make(map[string]Role, len(project.Members))
+	for _, member := range project.Members {
+		project.Roles[member] = EditorRole
+	}
+	project.Roles[userID] = OwnerRole
+	if project.ID == uuid.Nil {
+		project.ID = NewID()
+	}
+
 	_, err := s.projStorage.GetProjectByID(ctx, project.ID)
 	if err != nil && !errors.Is(err, storage.ErrNotFound) {
 		return Project{}, fmt.Errorf("add project: %w", err)
 	}
 	if !errors.Is(err, storage.ErrNotFound) {
-		return Project{}, fmt.Errorf("add project: %w", err)
+		return Project{}, fmt.Errorf("project %s: %w", project.ID, ErrProjectExists)
 	}
 
 	users, err := s.projStorage.GetUsers(ctx)
@@ -115,11 +551,16 @@ func (s *Service) AddProject(ctx context.Context, project Project) (Project, err
 	}
 
 	for _, member := range project.Members {
-		if slices.IndexFunc(users, func(u storage.User) bool { return member == u.ID }) == -1 {
-			err = s.projStorage.AddUser(ctx, storage.User{ID: member})
-			if err != nil {
-				return Project{}, fmt.Errorf("add new user for project: %w", err)
+		index := slices.IndexFunc(users, func(u storage.User) bool { return member == u.ID })
+		if index != -1 {
+			if err := checkNoGuest(&users[index], project.ID); err != nil {
+				return Project{}, err
 			}
+			continue
+		}
+		err = s.projStorage.AddUser(ctx, storage.User{ID: member})
+		if err != nil {
+			return Project{}, fmt.Errorf("add new user for project: %w", err)
 		}
 	}
 
@@ -128,73 +569,83 @@ func (s *Service) AddProject(ctx context.Context, project Project) (Project, err
 		return Project{}, fmt.Errorf("add project: %w", err)
 	}
 
-	return FromStorageProject(proj), nil
+	added := FromStorageProject(proj)
+	return added, s.addDisplayNames(ctx, &added)
 }
 
 // GetCostsByUser implements api.ProjectService.
-// TODO improve implementation use pointers
-func (s *Service) GetCostsByUser(ctx context.Context, userID string) (UserCosts, error) {
+// The costs per project are in the currency of the project, the total costs are converted into the given currency.
+// Without a currency the preferred currency of the user is used.
+func (s *Service) GetCostsByUser(ctx context.Context, userID, currency string) (UserCosts, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return UserCosts{}, err
+	}
+	if currency == "" {
+		user, err := s.GetCurrentUser(ctx)
+		if err != nil {
+			return UserCosts{}, err
+		}
+		currency = orDefaultCurrency(user.PreferredCurrency)
+	}
+
 	incomeSt, err := s.projStorage.GetAllIncomingTransactionsByUserID(ctx, userID)
 	if err != nil {
 		return UserCosts{}, fmt.Errorf("get incoming transactions: %w", err)
 	}
-	income := make([]Transaction, 0, len(incomeSt))
-	for _, tx := range incomeSt {
-		income = append(income, FromStorageTransaction(tx))
-	}
 
 	outgoingSt, err := s.projStorage.GetAllOutgoingTransactionsByUserID(ctx, userID)
 	if err != nil {
 		return UserCosts{}, fmt.Errorf("get outgoing transactions: %w", err)
 	}
-	outgoing := make([]Transaction, 0, len(outgoingSt))
-	for _, tx := range outgoingSt {
-		fmt.Printf("\n%+v\n", tx)
-		outgoing = append(outgoing, FromStorageTransaction(tx))
-	}
 
-	totalCost := Cost{
-		Expenses: money.New(0, money.EUR),
-		Income:   money.New(0, money.EUR),
-		Balance:  money.New(0, money.EUR),
-	}
-	projectCosts := map[uuid.UUID]Cost{}
-
-	for _, tx := range income {
-		if _, ok := projectCosts[tx.ProjectID]; !ok {
-			projectCosts[tx.ProjectID] = Cost{
-				Expenses: money.New(0, money.EUR),
-				Income:   money.New(0, money.EUR),
-				Balance:  money.New(0, money.EUR),
-			}
+	projectIDs := []uuid.UUID{}
+	for _, tx := range append(incomeSt, outgoingSt...) {
+		if !slices.Contains(projectIDs, tx.ProjectID) {
+			projectIDs = append(projectIDs, tx.ProjectID)
 		}
-		totalIncome, _ := totalCost.Income.Add(tx.Amount)
-		totalCost.Income = totalIncome
+	}
 
-		projectCost := projectCosts[tx.ProjectID]
-		newProjectIncome, _ := projectCost.Income.Add(tx.Amount)
-		projectCost.Income = newProjectIncome
-		projectCosts[tx.ProjectID] = projectCost
+	totalCost := Cost{
+		Expenses: money.New(0, currency),
+		Income:   money.New(0, currency),
+		Balance:  money.New(0, currency),
 	}
+	projectCosts := make(map[uuid.UUID]Cost, len(projectIDs))
+	convertedProjectCosts := make(map[uuid.UUID]Cost, len(projectIDs))
 
-	for _, tx := range outgoing {
-		if _, ok := projectCosts[tx.ProjectID]; !ok {
-			projectCosts[tx.ProjectID] = Cost{
-				Expenses: money.New(0, money.EUR),
-				Income:   money.New(0, money.EUR),
-				Balance:  money.New(0, money.EUR),
-			}
+	for _, projectID := range projectIDs {
+		proj, err := s.getProject(ctx, projectID)
+		if err != nil {
+			return UserCosts{}, fmt.Errorf("get project %s: %w", projectID, err)
+		}
+		txs, err := s.costCalcTransactions(ctx, &proj)
+		if err != nil {
+			return UserCosts{}, err
+		}
+
+		calculator, err := costcalc.New(proj.Currency, txs)
+		if err != nil {
+			return UserCosts{}, fmt.Errorf("calc costs for project %s: %w", projectID, err)
 		}
-		totalExpenses, _ := totalCost.Expenses.Add(tx.Amount)
-		totalCost.Expenses = totalExpenses
+		projectCost, err := calculator.CalculateCostForUser(userID)
+		if err != nil {
+			return UserCosts{}, fmt.Errorf("calc costs for project %s: %w", projectID, err)
+		}
+		projectCosts[projectID] = FromCostCalcCost(*projectCost)
 
-		projectCost := projectCosts[tx.ProjectID]
-		newProjectExpenses, _ := projectCost.Expenses.Add(tx.Amount)
-		projectCost.Expenses = newProjectExpenses
-		projectCosts[tx.ProjectID] = projectCost
+		convertedCost, err := s.convertCost(ctx, projectCosts[projectID], currency)
+		if err != nil {
+			return UserCosts{}, fmt.Errorf("convert costs of project %s: %w", projectID, err)
+		}
+		convertedProjectCosts[projectID] = convertedCost
 
+		totalCost.Expenses, err = totalCost.Expenses.Add(convertedCost.Expenses)
 		if err != nil {
-			return UserCosts{}, fmt.Errorf("add to totalExpeses: %w", err)
+			return UserCosts{}, fmt.Errorf("add to total expenses: %w", err)
+		}
+		totalCost.Income, err = totalCost.Income.Add(convertedCost.Income)
+		if err != nil {
+			return UserCosts{}, fmt.Errorf("add to total income: %w", err)
 		}
 	}
 
@@ -202,18 +653,14 @@ func (s *Service) GetCostsByUser(ctx context.Context, userID string) (UserCosts,
 	if err != nil {
 		return UserCosts{}, fmt.Errorf("calc balance: %w", err)
 	}
-
-	for pID, c := range projectCosts {
-		balance, _ = c.Expenses.Subtract(c.Income)
-		c.Balance = balance
-		projectCosts[pID] = c
-	}
 	totalCost.Balance = balance
-	userCosts := UserCosts{
-		TotalCost:    totalCost,
-		ProjectCosts: projectCosts,
-	}
-	return userCosts, nil
+
+	return UserCosts{
+		Currency:              currency,
+		TotalCost:             totalCost,
+		ProjectCosts:          projectCosts,
+		ConvertedProjectCosts: convertedProjectCosts,
+	}, nil
 }
 
 func (s *Service) GetCostsByProject(ctx context.Context, projID uuid.UUID) (ProjectCosts, error) {
@@ -225,18 +672,222 @@ func (s *Service) GetCostsByProject(ctx context.Context, projID uuid.UUID) (Proj
 		return ProjectCosts{}, fmt.Errorf("get project: %w", err)
 	}
 
-	costCalcTransactions := make([]costcalc.Transaction, 0, len(proj.Transactions))
-
-	for _, tx := range proj.Transactions {
-		costCalcTransactions = append(costCalcTransactions, tx.ToCostCalc())
+	costCalcTransactions, err := s.costCalcTransactions(ctx, &proj)
+	if err != nil {
+		return ProjectCosts{}, err
 	}
 
-	costCalculator := costcalc.New(costCalcTransactions)
+	costCalculator, err := costcalc.New(proj.Currency, costCalcTransactions)
+	if err != nil {
+		return ProjectCosts{}, fmt.Errorf("calc costs: %w", err)
+	}
 
 	allCosts, err := costCalculator.CalculateCostForAllUsers()
 	if err != nil {
 		return ProjectCosts{}, fmt.Errorf("calc costs: %w", err)
 	}
 
-	return FromCostCalcProjectCost(*allCosts), nil
+	// the totals per original currency, before any conversion
+	originalTotalCosts := map[string]*money.Money{}
+	for _, tx := range proj.Transactions {
+		code := tx.Amount.Currency().Code
+		if originalTotalCosts[code] == nil {
+			originalTotalCosts[code] = money.New(0, code)
+		}
+		originalTotalCosts[code], err = originalTotalCosts[code].Add(tx.Amount)
+		if err != nil {
+			return ProjectCosts{}, fmt.Errorf("add to original total costs: %w", err)
+		}
+	}
+
+	projectCosts := FromCostCalcProjectCost(*allCosts)
+	projectCosts.Currency = proj.Currency
+	projectCosts.OriginalTotalCosts = originalTotalCosts
+	projectCosts.DisplayNames, err = s.displayNames(ctx, maps.Keys(projectCosts.UserCosts))
+	if err != nil {
+		return ProjectCosts{}, err
+	}
+	return projectCosts, nil
+}
+
+// GetSettlements returns the minimal list of payments that settles all debts in the project.
+// The amounts are in the currency of the project.
+func (s *Service) GetSettlements(ctx context.Context, projID uuid.UUID) ([]Settlement, error) {
+	proj, err := s.GetProjectByID(ctx, projID)
+	if err != nil {
+		return nil, fmt.Errorf("get project: %w", err)
+	}
+
+	costCalcTransactions, err := s.costCalcTransactions(ctx, &proj)
+	if err != nil {
+		return nil, err
+	}
+
+	calculator, err := costcalc.New(proj.Currency, costCalcTransactions)
+	if err != nil {
+		return nil, fmt.Errorf("calc settlements: %w", err)
+	}
+	edges := calculator.CalculateMinCostFlow()
+
+	settlements := make([]Settlement, 0, len(edges))
+	for _, edge := range edges {
+		settlements = append(settlements, FromCostCalcEdge(edge))
+	}
+	return settlements, nil
+}
+
+// AddSettlements records the given settlements as transfers.
+// If no settlements are given the suggested settlements from GetSettlements are used.
+func (s *Service) AddSettlements(ctx context.Context, projID uuid.UUID, settlements []Settlement) ([]Transaction, error) {
+	proj, err := s.getWritableProject(ctx, projID, EditorRole)
+	if err != nil {
+		return nil, fmt.Errorf("get project: %w", err)
+	}
+	if len(settlements) == 0 {
+		settlements, err = s.GetSettlements(ctx, projID)
+		if err != nil {
+			return nil, fmt.Errorf("get settlements: %w", err)
+		}
+	} else if err := checkSettlementMembers(proj.Members, settlements); err != nil {
+		return nil, err
+	}
+
+	transactions := make([]Transaction, 0, len(settlements))
+	storageTransactions := make([]storage.Transaction, 0, len(settlements))
+	for i := range settlements {
+		tx := settlements[i].ToTransferTransaction(projID)
+		stampTransaction(ctx, &tx)
+		transactions = append(transactions, tx)
+		storageTransactions = append(storageTransactions, ToStorageTransaction(tx))
+	}
+
+	if len(storageTransactions) == 0 {
+		return transactions, nil
+	}
+
+	err = s.projStorage.AddTransactions(ctx, projID, storageTransactions)
+	if err != nil {
+		return nil, fmt.Errorf("add settlement transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// ImportTransactions adds transactions read from another app. Sources and targets that are not members of the project
+// yet become members, like in AddProject. Only owners may add members, editors can only import transactions
+// of existing members. With dryRun the transactions are only prepared but not stored.
+func (s *Service) ImportTransactions(ctx context.Context, projID uuid.UUID, transactions []Transaction, dryRun bool) (ImportResult, error) {
+	proj, err := s.getWritableProject(ctx, projID, EditorRole)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	mayAddMembers := proj.Roles[contextutil.GetUserIDFromCtx(ctx)].Includes(OwnerRole)
+
+	result := ImportResult{Transactions: make([]Transaction, 0, len(transactions))}
+	storageTransactions := make([]storage.Transaction, 0, len(transactions))
+	var fields []apperror.FieldError
+	for i, tx := range transactions {
+		if !mayAddMembers {
+			fields = append(fields, memberFieldErrors(fmt.Sprintf("transactions[%d].", i), &tx, proj.Members)...)
+		}
+		for _, userID := range append([]string{tx.SourceID}, tx.TargetIDs...) {
+			if !slices.Contains(proj.Members, userID) && !slices.Contains(result.NewMembers, userID) {
+				result.NewMembers = append(result.NewMembers, userID)
+			}
+		}
+		tx.ProjectID = projID
+		stampTransaction(ctx, &tx)
+		result.Transactions = append(result.Transactions, tx)
+		storageTransactions = append(storageTransactions, ToStorageTransaction(tx))
+	}
+	if len(fields) > 0 {
+		return ImportResult{}, apperror.NewValidationError(fields...)
+	}
+
+	existingUsers, err := s.projStorage.GetUsersByIDs(ctx, result.NewMembers)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("get users: %w", err)
+	}
+	for i := range existingUsers {
+		if err := checkNoGuest(&existingUsers[i], projID); err != nil {
+			return ImportResult{}, err
+		}
+	}
+
+	if dryRun || len(storageTransactions) == 0 {
+		return result, nil
+	}
+	err = s.projStorage.ImportTransactions(ctx, projID, result.NewMembers, storageTransactions)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("import transactions: %w", err)
+	}
+	return result, nil
+}
+
+// GetProjectSummary returns the project without its transactions, but with their count and total.
+func (s *Service) GetProjectSummary(ctx context.Context, projID uuid.UUID) (ProjectSummary, error) {
+	proj, err := s.GetProjectByID(ctx, projID)
+	if err != nil {
+		return ProjectSummary{}, err
+	}
+
+	total := money.New(0, proj.Currency)
+	for _, tx := range proj.Transactions {
+		var converted *money.Money
+		converted, err = s.convert(ctx, tx.Amount, proj.Currency)
+		if err != nil {
+			return ProjectSummary{}, fmt.Errorf("convert transaction %s: %w", tx.ID, err)
+		}
+		total, err = total.Add(converted)
+		if err != nil {
+			return ProjectSummary{}, fmt.Errorf("add to total: %w", err)
+		}
+	}
+
+	summary := ProjectSummary{Project: proj, TransactionCount: len(proj.Transactions), TotalAmount: total}
+	summary.Transactions = nil
+	return summary, nil
+}
+
+// GetTransactions returns one page of the transactions of the project matching the query.
+func (s *Service) GetTransactions(ctx context.Context, projID uuid.UUID, query TransactionQuery) (TransactionPage, error) {
+	if err := s.authorizeProject(ctx, projID, ViewerRole); err != nil {
+		return TransactionPage{}, err
+	}
+
+	currency := DefaultCurrency
+	if query.MinAmount != nil || query.MaxAmount != nil {
+		proj, err := s.getProject(ctx, projID)
+		if err != nil {
+			return TransactionPage{}, err
+		}
+		currency = proj.Currency
+	}
+	filter := query.toStorageFilter(projID, currency)
+	var after storage.TransactionCursor
+	hasCursor, err := decodeCursor(query.Cursor, &after)
+	if err != nil {
+		return TransactionPage{}, err
+	}
+	if hasCursor {
+		filter.After = &after
+	}
+	limit := pageSize(query.Limit)
+	// fetch one more to know if there is a next page
+	filter.Limit = limit + 1
+
+	txs, err := s.projStorage.GetTransactions(ctx, filter)
+	if err != nil {
+		return TransactionPage{}, fmt.Errorf("get transactions: %w", err)
+	}
+
+	page := TransactionPage{Transactions: make([]Transaction, 0, len(txs))}
+	if len(txs) > limit {
+		txs = txs[:limit]
+		last := txs[limit-1]
+		page.NextCursor = encodeCursor(storage.TransactionCursor{ID: last.ID, Amount: last.Amount, OccurredAt: last.OccurredAt})
+	}
+	for _, tx := range txs {
+		page.Transactions = append(page.Transactions, FromStorageTransaction(tx))
+	}
+	return page, nil
 }
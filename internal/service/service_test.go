@@ -0,0 +1,1036 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/diezfx/split-app-backend/internal/apperror"
+	"github.com/diezfx/split-app-backend/internal/contextutil"
+	"github.com/diezfx/split-app-backend/internal/invite"
+	"github.com/diezfx/split-app-backend/internal/recurrence"
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/diezfx/split-app-backend/internal/storage/memory"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+func newTestService(t *testing.T, members ...string) (*service.Service, service.Project) {
+	t.Helper()
+	store := memory.New()
+	svc := service.New(store, store, invite.NewSigner("test"))
+
+	ctx := contextutil.AddUserIDToCtx(context.Background(), members[0])
+	proj, err := svc.AddProject(ctx, service.Project{ID: uuid.New(), Name: "test", Currency: money.EUR, Members: members})
+	if err != nil {
+		t.Fatalf("add project: %s", err)
+	}
+	return svc, proj
+}
+
+func TestAuthorization(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2")
+
+	_, err := svc.GetProjectByID(contextutil.AddUserIDToCtx(context.Background(), "u2"), proj.ID)
+	if err != nil {
+		t.Errorf("expected member to access project got %s", err)
+	}
+
+	outsiderCtx := contextutil.AddUserIDToCtx(context.Background(), "outsider")
+	_, err = svc.GetProjectByID(outsiderCtx, proj.ID)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected forbidden for outsider got %v", err)
+	}
+	page, err := svc.GetProjects(outsiderCtx, service.ProjectQuery{})
+	if err != nil || len(page.Projects) != 0 {
+		t.Errorf("expected no projects for outsider got %v: %v", page.Projects, err)
+	}
+	_, err = svc.GetCostsByUser(outsiderCtx, "u1", money.EUR)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected forbidden for costs of other user got %v", err)
+	}
+	_, err = svc.GetProjectByID(context.Background(), proj.ID)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected forbidden without user got %v", err)
+	}
+}
+
+func TestCostsAndSettlements(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2", "u3")
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+
+	_, err := svc.AddTransaction(ctx, proj.ID, service.Transaction{
+		ID: uuid.New(), Name: "rent", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(9000, money.EUR), SourceID: "u1", TargetIDs: []string{"u2", "u3"},
+		SplitMode: service.SharesSplitMode, Weights: []int{2, 1},
+	})
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+
+	costs, err := svc.GetCostsByProject(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get costs: %s", err)
+	}
+	if costs.UserCosts["u2"].Balance.Amount() != -6000 || costs.UserCosts["u3"].Balance.Amount() != -3000 {
+		t.Errorf("unexpected balances %+v", costs.UserCosts)
+	}
+
+	transfers, err := svc.AddSettlements(ctx, proj.ID, nil)
+	if err != nil {
+		t.Fatalf("add settlements: %s", err)
+	}
+	if len(transfers) != 2 {
+		t.Errorf("expected 2 settlement transfers got %d", len(transfers))
+	}
+
+	settlements, err := svc.GetSettlements(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get settlements: %s", err)
+	}
+	if len(settlements) != 0 {
+		t.Errorf("expected everything to be settled got %+v", settlements)
+	}
+}
+
+func TestGetProjectsPagination(t *testing.T) {
+	store := memory.New()
+	svc := service.New(store, store, invite.NewSigner("test"))
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	for i := 0; i < 5; i++ {
+		_, err := svc.AddProject(ctx, service.Project{ID: uuid.New(), Name: "test"})
+		if err != nil {
+			t.Fatalf("add project: %s", err)
+		}
+	}
+
+	seen := map[uuid.UUID]bool{}
+	query := service.ProjectQuery{Limit: 2}
+	for pages := 1; ; pages++ {
+		page, err := svc.GetProjects(ctx, query)
+		if err != nil {
+			t.Fatalf("get projects: %s", err)
+		}
+		for _, p := range page.Projects {
+			seen[p.ID] = true
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Errorf("expected 3 pages got %d", pages)
+			}
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Errorf("expected to see all 5 projects got %d", len(seen))
+	}
+
+	_, err := svc.GetProjects(ctx, service.ProjectQuery{Cursor: "not a cursor"})
+	if !errors.Is(err, service.ErrInvalidCursor) {
+		t.Errorf("expected invalid cursor error got %v", err)
+	}
+}
+
+func TestGetTransactionsAmountFilter(t *testing.T) {
+	store := memory.New()
+	svc := service.New(store, store, invite.NewSigner("test"))
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	proj, err := svc.AddProject(ctx, service.Project{Name: "tokyo", Currency: "JPY", Members: []string{"u1", "u2"}})
+	if err != nil {
+		t.Fatalf("add project: %s", err)
+	}
+	_, err = svc.AddTransaction(ctx, proj.ID, service.Transaction{
+		Name: "sushi", TransactionType: service.ExpenseTransactionType, Amount: money.New(1000, "JPY"),
+		SourceID: "u1", TargetIDs: []string{"u2"}, SplitMode: service.EqualSplitMode,
+	})
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+
+	// about 30 EUR would match the minimum if the amount was compared in minor units of the project currency
+	_, err = svc.AddTransaction(ctx, proj.ID, service.Transaction{
+		Name: "dinner", TransactionType: service.ExpenseTransactionType, Amount: money.New(3000, money.EUR),
+		SourceID: "u1", TargetIDs: []string{"u2"}, SplitMode: service.EqualSplitMode,
+	})
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+
+	minAmount, maxAmount := 500.0, 999.0
+	page, err := svc.GetTransactions(ctx, proj.ID, service.TransactionQuery{MinAmount: &minAmount})
+	if err != nil || len(page.Transactions) != 1 || page.Transactions[0].Name != "sushi" {
+		t.Errorf("expected only the transaction in the project currency above the minimum got %v: %v", page.Transactions, err)
+	}
+	page, err = svc.GetTransactions(ctx, proj.ID, service.TransactionQuery{MaxAmount: &maxAmount})
+	if err != nil || len(page.Transactions) != 0 {
+		t.Errorf("expected no transaction below the maximum got %v: %v", page.Transactions, err)
+	}
+}
+
+func TestExportProject(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2", "u3")
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+
+	later := service.Transaction{
+		ID: uuid.New(), Name: "dinner", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(1000, money.EUR), SourceID: "u2", TargetIDs: []string{"u1", "u2", "u3"},
+		OccurredAt: time.Date(2024, time.May, 2, 0, 0, 0, 0, time.UTC),
+	}
+	earlier := service.Transaction{
+		ID: uuid.New(), Name: "rent", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(9000, money.EUR), SourceID: "u1", TargetIDs: []string{"u2", "u3"},
+		SplitMode: service.SharesSplitMode, Weights: []int{2, 1},
+		OccurredAt: time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, tx := range []service.Transaction{later, earlier} {
+		if _, err := svc.AddTransaction(ctx, proj.ID, tx); err != nil {
+			t.Fatalf("add transaction: %s", err)
+		}
+	}
+
+	var export ledgerRecorder
+	err := svc.ExportProject(ctx, proj.ID, &export)
+	if err != nil {
+		t.Fatalf("export project: %s", err)
+	}
+	if export.project.ID != proj.ID || len(export.project.Transactions) != 0 {
+		t.Errorf("expected the project without transactions got %+v", export.project)
+	}
+	if len(export.entries) != 2 || export.entries[0].Transaction.ID != earlier.ID {
+		t.Fatalf("expected transactions sorted by date got %+v", export.entries)
+	}
+	expectedShares := [][]int64{{6000, 3000}, {334, 333, 333}}
+	for i, entry := range export.entries {
+		for j, share := range entry.Shares {
+			if share.Amount() != expectedShares[i][j] {
+				t.Errorf("transaction %d: expected share %d of target %d got %d", i, expectedShares[i][j], j, share.Amount())
+			}
+		}
+	}
+	if export.balances.UserCosts["u2"].Balance.Amount() != -5333 {
+		t.Errorf("unexpected balance of u2 %d", export.balances.UserCosts["u2"].Balance.Amount())
+	}
+
+	err = svc.ExportProject(contextutil.AddUserIDToCtx(context.Background(), "outsider"), proj.ID, &ledgerRecorder{})
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected forbidden for outsider got %v", err)
+	}
+}
+
+func TestExportProjectPages(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2")
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+
+	count := 2*service.MaxPageSize + 1
+	for i := 0; i < count; i++ {
+		tx := service.Transaction{
+			ID: uuid.New(), Name: "coffee", TransactionType: service.ExpenseTransactionType,
+			Amount: money.New(100, money.EUR), SourceID: "u1", TargetIDs: []string{"u2"},
+			OccurredAt: time.Date(2024, time.May, 1+i%28, 0, 0, 0, 0, time.UTC),
+		}
+		if _, err := svc.AddTransaction(ctx, proj.ID, tx); err != nil {
+			t.Fatalf("add transaction: %s", err)
+		}
+	}
+
+	var export ledgerRecorder
+	if err := svc.ExportProject(ctx, proj.ID, &export); err != nil {
+		t.Fatalf("export project: %s", err)
+	}
+	if len(export.entries) != count {
+		t.Fatalf("expected %d transactions got %d", count, len(export.entries))
+	}
+	for i := 1; i < count; i++ {
+		if export.entries[i].Transaction.OccurredAt.Before(export.entries[i-1].Transaction.OccurredAt) {
+			t.Fatalf("expected transactions sorted by date, %d is before %d", i, i-1)
+		}
+	}
+	if export.balances.UserCosts["u2"].Balance.Amount() != int64(-100*count) {
+		t.Errorf("expected balance %d of u2 got %d", -100*count, export.balances.UserCosts["u2"].Balance.Amount())
+	}
+}
+
+type ledgerRecorder struct {
+	project  service.Project
+	entries  []service.LedgerEntry
+	balances service.ProjectCosts
+}
+
+func (r *ledgerRecorder) WriteProject(proj service.Project) error {
+	r.project = proj
+	return nil
+}
+
+func (r *ledgerRecorder) WriteEntry(entry service.LedgerEntry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *ledgerRecorder) WriteBalances(balances service.ProjectCosts) error {
+	r.balances = balances
+	return nil
+}
+
+func TestImportTransactions(t *testing.T) {
+	svc, proj := newTestService(t, "u1")
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	imported := []service.Transaction{{
+		ID: uuid.New(), Name: "hotel", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(3000, money.EUR), SourceID: "anna", TargetIDs: []string{"u1", "anna"},
+		SplitMode: service.ExactSplitMode, Weights: []int{1000, 2000},
+	}}
+
+	result, err := svc.ImportTransactions(ctx, proj.ID, imported, true)
+	if err != nil {
+		t.Fatalf("dry run: %s", err)
+	}
+	if len(result.NewMembers) != 1 || result.NewMembers[0] != "anna" {
+		t.Errorf("expected anna as new member got %v", result.NewMembers)
+	}
+	users, err := svc.GetProjectUsers(ctx, proj.ID)
+	if err != nil || len(users) != 1 {
+		t.Errorf("expected dry run to not add members got %v: %v", users, err)
+	}
+
+	_, err = svc.ImportTransactions(ctx, proj.ID, imported, false)
+	if err != nil {
+		t.Fatalf("import: %s", err)
+	}
+	costs, err := svc.GetCostsByProject(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get costs: %s", err)
+	}
+	if costs.UserCosts["u1"].Balance.Amount() != -1000 {
+		t.Errorf("expected u1 to owe 10 got %d", costs.UserCosts["u1"].Balance.Amount())
+	}
+
+	err = svc.AddProjectUser(ctx, proj.ID, "u2", service.EditorRole)
+	if err != nil {
+		t.Fatalf("add project user: %s", err)
+	}
+	imported[0].ID = uuid.New()
+	imported[0].SourceID = "bob"
+	_, err = svc.ImportTransactions(contextutil.AddUserIDToCtx(context.Background(), "u2"), proj.ID, imported, true)
+	if !errors.Is(err, apperror.Validation) {
+		t.Errorf("expected editor not to add members by importing got %v", err)
+	}
+}
+
+func TestAddTransactionsIsAtomic(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2")
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	newTx := func() service.Transaction {
+		return service.Transaction{
+			ID: uuid.New(), Name: "snacks", TransactionType: service.ExpenseTransactionType,
+			Amount: money.New(500, money.EUR), SourceID: "u1", TargetIDs: []string{"u2"},
+		}
+	}
+
+	existing := newTx()
+	if _, err := svc.AddTransaction(ctx, proj.ID, existing); err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+	_, err := svc.AddTransactions(ctx, proj.ID, []service.Transaction{newTx(), existing})
+	if err == nil {
+		t.Fatalf("expected error for batch with an existing transaction")
+	}
+
+	added, err := svc.AddTransactions(ctx, proj.ID, []service.Transaction{newTx(), newTx()})
+	if err != nil {
+		t.Fatalf("add transactions: %s", err)
+	}
+	if added[0].CreatedBy != "u1" {
+		t.Errorf("expected creator u1 got %q", added[0].CreatedBy)
+	}
+	page, err := svc.GetTransactions(ctx, proj.ID, service.TransactionQuery{})
+	if err != nil {
+		t.Fatalf("get transactions: %s", err)
+	}
+	if len(page.Transactions) != 3 {
+		t.Errorf("expected 3 transactions got %d", len(page.Transactions))
+	}
+}
+
+func TestIdempotentRequests(t *testing.T) {
+	store := memory.New()
+	svc := service.New(store, store, invite.NewSigner("test"))
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+
+	claim, response, err := svc.BeginIdempotentRequest(ctx, "key", "request")
+	if err != nil || response != nil {
+		t.Fatalf("expected first request to be executed got %v: %v", response, err)
+	}
+	_, _, err = svc.BeginIdempotentRequest(ctx, "key", "request")
+	if !errors.Is(err, service.ErrIdempotencyKeyInProgress) {
+		t.Errorf("expected in progress for concurrent retry got %v", err)
+	}
+
+	err = svc.CompleteIdempotentRequest(ctx, claim,
+		service.IdempotentResponse{StatusCode: 201, Location: "/transactions/1", Body: []byte("created")})
+	if err != nil {
+		t.Fatalf("complete request: %s", err)
+	}
+	_, response, err = svc.BeginIdempotentRequest(ctx, "key", "request")
+	if err != nil || response == nil || response.StatusCode != 201 || response.Location != "/transactions/1" ||
+		string(response.Body) != "created" {
+		t.Errorf("expected the stored response got %+v: %v", response, err)
+	}
+	_, _, err = svc.BeginIdempotentRequest(ctx, "key", "other request")
+	if !errors.Is(err, service.ErrIdempotencyKeyReused) {
+		t.Errorf("expected reused key error got %v", err)
+	}
+
+	// keys are scoped to the user
+	_, response, err = svc.BeginIdempotentRequest(contextutil.AddUserIDToCtx(context.Background(), "u2"), "key", "other request")
+	if err != nil || response != nil {
+		t.Errorf("expected request of other user to be executed got %v: %v", response, err)
+	}
+}
+
+func TestIdempotentRequestTakenOver(t *testing.T) {
+	store := memory.New()
+	svc := service.New(store, store, invite.NewSigner("test"))
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+
+	claim, _, err := svc.BeginIdempotentRequest(ctx, "key", "request")
+	if err != nil {
+		t.Fatalf("begin request: %s", err)
+	}
+	// another request takes over the key once the first one counts as abandoned
+	err = store.AddIdempotencyRecord(ctx, storage.IdempotencyRecord{
+		UserID: "u1", Key: "key", Fingerprint: "request", CreatedAt: claim.CreatedAt.Add(time.Hour),
+	}, claim.CreatedAt.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("take over key: %s", err)
+	}
+
+	err = svc.CompleteIdempotentRequest(ctx, claim, service.IdempotentResponse{StatusCode: 201})
+	if !errors.Is(err, service.ErrIdempotencyKeyTakenOver) {
+		t.Errorf("expected taken over key when completing got %v", err)
+	}
+	err = svc.AbortIdempotentRequest(ctx, claim)
+	if !errors.Is(err, service.ErrIdempotencyKeyTakenOver) {
+		t.Errorf("expected taken over key when aborting got %v", err)
+	}
+	if _, err := store.GetIdempotencyRecord(ctx, "u1", "key"); err != nil {
+		t.Errorf("expected the record of the new request to be kept got %v", err)
+	}
+}
+
+func TestGeneratedIDs(t *testing.T) {
+	store := memory.New()
+	svc := service.New(store, store, invite.NewSigner("test"))
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+
+	proj, err := svc.AddProject(ctx, service.Project{Name: "no id", Currency: money.EUR})
+	if err != nil {
+		t.Fatalf("add project: %s", err)
+	}
+	if proj.ID.Version() != 7 {
+		t.Errorf("expected generated uuid v7 got %s", proj.ID)
+	}
+
+	added, err := svc.AddTransaction(ctx, proj.ID, service.Transaction{
+		Name: "coffee", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(300, money.EUR), SourceID: "u1", TargetIDs: []string{"u1"},
+	})
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+	if added.ID.Version() != 7 {
+		t.Errorf("expected generated uuid v7 got %s", added.ID)
+	}
+	got, err := svc.GetTransaction(ctx, proj.ID, added.ID)
+	if err != nil || got.ID != added.ID {
+		t.Errorf("expected to get the added transaction got %+v: %v", got, err)
+	}
+	_, err = svc.GetTransaction(ctx, proj.ID, uuid.New())
+	if !errors.Is(err, service.ErrTransactionNotFound) {
+		t.Errorf("expected not found for unknown transaction got %v", err)
+	}
+}
+
+func TestAddTransactionOfNonMembers(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2")
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	tx := service.Transaction{
+		Name: "snacks", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(500, money.EUR), SourceID: "u3", TargetIDs: []string{"u1", "u4"},
+	}
+
+	_, err := svc.AddTransaction(ctx, proj.ID, tx)
+	if apperror.KindOf(err) != apperror.Validation {
+		t.Fatalf("expected validation error got %v", err)
+	}
+	fields := apperror.Fields(err)
+	if len(fields) != 2 || fields[0].Field != "sourceId" || fields[1].Field != "targetIds[1]" || fields[1].Code != apperror.CodeNotMember {
+		t.Errorf("expected source and second target to be reported got %+v", fields)
+	}
+
+	_, err = svc.AddTransactions(ctx, proj.ID, []service.Transaction{{
+		Name: "ok", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(500, money.EUR), SourceID: "u1", TargetIDs: []string{"u2"},
+	}, tx})
+	fields = apperror.Fields(err)
+	if len(fields) != 2 || fields[0].Field != "transactions[1].sourceId" {
+		t.Errorf("expected errors of the second transaction got %+v", fields)
+	}
+}
+
+func TestUpdateTransactionKeepsDate(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2")
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	occurredAt := time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC)
+
+	tx, err := svc.AddTransaction(ctx, proj.ID, service.Transaction{
+		Name: "dinner", TransactionType: service.ExpenseTransactionType, Amount: money.New(1000, money.EUR),
+		SourceID: "u1", TargetIDs: []string{"u2"}, SplitMode: service.EqualSplitMode, OccurredAt: occurredAt,
+	})
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+
+	tx.Name = "lunch"
+	tx.OccurredAt = time.Time{}
+	updated, err := svc.UpdateTransaction(ctx, proj.ID, tx)
+	if err != nil {
+		t.Fatalf("update transaction: %s", err)
+	}
+	got, err := svc.GetTransaction(ctx, proj.ID, tx.ID)
+	if err != nil {
+		t.Fatalf("get transaction: %s", err)
+	}
+	if got.Name != "lunch" || !got.OccurredAt.Equal(occurredAt) {
+		t.Errorf("expected renamed transaction on %s got %s on %s", occurredAt, got.Name, got.OccurredAt)
+	}
+	if !updated.OccurredAt.Equal(got.OccurredAt) || updated.CreatedBy != got.CreatedBy ||
+		!updated.CreatedAt.Equal(got.CreatedAt) || !updated.UpdatedAt.Equal(got.UpdatedAt) {
+		t.Errorf("expected the stored transaction %+v to be returned got %+v", got, updated)
+	}
+}
+
+func TestRemoveProjectUser(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2", "u3")
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+
+	_, err := svc.AddTransaction(ctx, proj.ID, service.Transaction{
+		Name: "dinner", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(3000, money.EUR), SourceID: "u1", TargetIDs: []string{"u1", "u2"},
+	})
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+
+	_, err = svc.RemoveProjectUser(ctx, proj.ID, "u2", false)
+	if !errors.Is(err, service.ErrMemberHasBalance) {
+		t.Fatalf("expected member has balance got %v", err)
+	}
+	transfers, err := svc.RemoveProjectUser(ctx, proj.ID, "u3", false)
+	if err != nil || len(transfers) != 0 {
+		t.Fatalf("expected member without balance to be removed without transfers got %v %v", transfers, err)
+	}
+
+	transfers, err = svc.RemoveProjectUser(ctx, proj.ID, "u2", true)
+	if err != nil {
+		t.Fatalf("remove project user: %s", err)
+	}
+	if len(transfers) != 1 || transfers[0].SourceID != "u2" || transfers[0].Amount.Amount() != 1500 {
+		t.Errorf("expected u2 to pay back 15 EUR got %+v", transfers)
+	}
+
+	costs, err := svc.GetCostsByProject(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get costs: %s", err)
+	}
+	if !costs.UserCosts["u2"].Balance.IsZero() {
+		t.Errorf("expected the balance of the removed member to be settled got %s", costs.UserCosts["u2"].Balance.Display())
+	}
+	_, err = svc.AddTransaction(ctx, proj.ID, service.Transaction{
+		Name: "taxi", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(1000, money.EUR), SourceID: "u1", TargetIDs: []string{"u2"},
+	})
+	if apperror.KindOf(err) != apperror.Validation {
+		t.Errorf("expected validation error for removed member got %v", err)
+	}
+}
+
+func TestArchivedProjectsAreReadOnly(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2")
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	archived := true
+
+	updated, err := svc.UpdateProject(ctx, proj.ID, service.ProjectUpdate{Archived: &archived})
+	if err != nil || !updated.Archived() {
+		t.Fatalf("expected project to be archived got %+v %v", updated, err)
+	}
+	_, err = svc.AddTransaction(ctx, proj.ID, service.Transaction{
+		Name: "snacks", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(500, money.EUR), SourceID: "u1", TargetIDs: []string{"u2"},
+	})
+	if !errors.Is(err, service.ErrProjectArchived) {
+		t.Errorf("expected project archived error got %v", err)
+	}
+	name := "renamed"
+	_, err = svc.UpdateProject(ctx, proj.ID, service.ProjectUpdate{Name: &name})
+	if !errors.Is(err, service.ErrProjectArchived) {
+		t.Errorf("expected archived project not to be renamed got %v", err)
+	}
+
+	page, err := svc.GetProjects(ctx, service.ProjectQuery{})
+	if err != nil || len(page.Projects) != 0 {
+		t.Errorf("expected archived project to be hidden got %+v %v", page.Projects, err)
+	}
+	page, err = svc.GetProjects(ctx, service.ProjectQuery{IncludeArchived: true})
+	if err != nil || len(page.Projects) != 1 {
+		t.Errorf("expected archived project to be listed got %+v %v", page.Projects, err)
+	}
+
+	archived = false
+	updated, err = svc.UpdateProject(ctx, proj.ID, service.ProjectUpdate{Name: &name, Archived: &archived})
+	if err != nil || updated.Archived() || updated.Name != name {
+		t.Fatalf("expected project to be unarchived and renamed got %+v %v", updated, err)
+	}
+
+	err = svc.DeleteProject(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("delete project: %s", err)
+	}
+	_, err = svc.GetProjectByID(ctx, proj.ID)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected deleted project to be inaccessible got %v", err)
+	}
+}
+
+func TestUserProfiles(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2")
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+
+	user, err := svc.GetCurrentUser(ctx)
+	if err != nil || user.ID != "u1" || user.Name() != "u1" {
+		t.Fatalf("expected empty profile of u1 got %+v %v", user, err)
+	}
+	_, err = svc.UpdateCurrentUser(ctx, service.User{ID: "u2", DisplayName: "Anna", PreferredCurrency: money.USD})
+	if err != nil {
+		t.Fatalf("update current user: %s", err)
+	}
+	user, err = svc.GetCurrentUser(ctx)
+	if err != nil || user.ID != "u1" || user.DisplayName != "Anna" {
+		t.Errorf("expected profile of u1 to be updated got %+v %v", user, err)
+	}
+
+	users, err := svc.GetProjectUsers(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get project users: %s", err)
+	}
+	if len(users) != 2 || users[0].Name() != "Anna" || users[1].Name() != "u2" {
+		t.Errorf("expected display names of members got %+v", users)
+	}
+
+	_, err = svc.AddTransaction(ctx, proj.ID, service.Transaction{
+		Name: "snacks", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(500, money.EUR), SourceID: "u1", TargetIDs: []string{"u2"},
+	})
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+	costs, err := svc.GetCostsByProject(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get costs: %s", err)
+	}
+	if costs.DisplayNames["u1"] != "Anna" || costs.DisplayNames["u2"] != "u2" {
+		t.Errorf("expected display names in costs got %v", costs.DisplayNames)
+	}
+
+	got, err := svc.GetProjectByID(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get project: %s", err)
+	}
+	if got.DisplayNames["u1"] != "Anna" || got.DisplayNames["u2"] != "u2" {
+		t.Errorf("expected display names in project got %v", got.DisplayNames)
+	}
+	page, err := svc.GetProjects(ctx, service.ProjectQuery{})
+	if err != nil {
+		t.Fatalf("get projects: %s", err)
+	}
+	if len(page.Projects) != 1 || page.Projects[0].DisplayNames["u1"] != "Anna" {
+		t.Errorf("expected display names in project page got %+v", page.Projects)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2")
+	ownerCtx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	editorCtx := contextutil.AddUserIDToCtx(context.Background(), "u2")
+	viewerCtx := contextutil.AddUserIDToCtx(context.Background(), "u3")
+
+	err := svc.AddProjectUser(editorCtx, proj.ID, "u3", service.ViewerRole)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected editor not to add members got %v", err)
+	}
+	err = svc.AddProjectUser(ownerCtx, proj.ID, "u3", service.ViewerRole)
+	if err != nil {
+		t.Fatalf("add project user: %s", err)
+	}
+	users, err := svc.GetProjectUsers(viewerCtx, proj.ID)
+	if err != nil {
+		t.Fatalf("get project users: %s", err)
+	}
+	roles := map[string]service.Role{}
+	for _, u := range users {
+		roles[u.ID] = u.Role
+	}
+	if roles["u1"] != service.OwnerRole || roles["u2"] != service.EditorRole || roles["u3"] != service.ViewerRole {
+		t.Errorf("expected owner, editor and viewer got %v", roles)
+	}
+
+	tx := service.Transaction{
+		Name: "snacks", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(500, money.EUR), SourceID: "u2", TargetIDs: []string{"u3"},
+	}
+	_, err = svc.AddTransaction(viewerCtx, proj.ID, tx)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected viewer not to add transactions got %v", err)
+	}
+	_, err = svc.AddTransaction(editorCtx, proj.ID, tx)
+	if err != nil {
+		t.Errorf("expected editor to add transactions got %v", err)
+	}
+	if _, err := svc.GetCostsByProject(viewerCtx, proj.ID); err != nil {
+		t.Errorf("expected viewer to read costs got %v", err)
+	}
+
+	name := "renamed"
+	_, err = svc.UpdateProject(editorCtx, proj.ID, service.ProjectUpdate{Name: &name})
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected editor not to rename the project got %v", err)
+	}
+	_, err = svc.RemoveProjectUser(editorCtx, proj.ID, "u3", true)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected editor not to remove members got %v", err)
+	}
+	err = svc.UpdateProjectUserRole(editorCtx, proj.ID, "u2", service.OwnerRole)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected editor not to change roles got %v", err)
+	}
+
+	err = svc.UpdateProjectUserRole(ownerCtx, proj.ID, "u1", service.EditorRole)
+	if !errors.Is(err, service.ErrLastOwner) {
+		t.Errorf("expected last owner not to be demoted got %v", err)
+	}
+	_, err = svc.RemoveProjectUser(ownerCtx, proj.ID, "u1", true)
+	if !errors.Is(err, service.ErrLastOwner) {
+		t.Errorf("expected last owner not to be removed got %v", err)
+	}
+	err = svc.UpdateProjectUserRole(ownerCtx, proj.ID, "u2", service.OwnerRole)
+	if err != nil {
+		t.Fatalf("update role: %s", err)
+	}
+	err = svc.UpdateProjectUserRole(ownerCtx, proj.ID, "u1", service.ViewerRole)
+	if err != nil {
+		t.Errorf("expected owner to step down once there is another owner got %v", err)
+	}
+	_, err = svc.UpdateProject(editorCtx, proj.ID, service.ProjectUpdate{Name: &name})
+	if err != nil {
+		t.Errorf("expected new owner to rename the project got %v", err)
+	}
+	err = svc.UpdateProjectUserRole(editorCtx, proj.ID, "outsider", service.EditorRole)
+	if !errors.Is(err, service.ErrMemberNotFound) {
+		t.Errorf("expected member not found got %v", err)
+	}
+}
+
+func TestInvites(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2")
+	ownerCtx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	editorCtx := contextutil.AddUserIDToCtx(context.Background(), "u2")
+	guestCtx := contextutil.AddUserIDToCtx(context.Background(), "u3")
+
+	_, err := svc.CreateInvite(editorCtx, proj.ID, service.EditorRole, 0)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected editor not to create invites got %v", err)
+	}
+	inv, err := svc.CreateInvite(ownerCtx, proj.ID, service.ViewerRole, 0)
+	if err != nil {
+		t.Fatalf("create invite: %s", err)
+	}
+	if inv.Token == "" || inv.ExpiresAt.Before(time.Now().Add(service.DefaultInviteValidity-time.Minute)) {
+		t.Errorf("expected token valid for the default duration got %+v", inv)
+	}
+
+	joined, err := svc.AcceptInvite(guestCtx, inv.Token)
+	if err != nil {
+		t.Fatalf("accept invite: %s", err)
+	}
+	if joined.ID != proj.ID || joined.Roles["u3"] != service.ViewerRole {
+		t.Errorf("expected u3 to join as viewer got %v", joined.Roles)
+	}
+	_, err = svc.AcceptInvite(editorCtx, inv.Token)
+	if err != nil {
+		t.Errorf("expected accepting as member to succeed got %v", err)
+	}
+	users, err := svc.GetProjectUsers(ownerCtx, proj.ID)
+	if err != nil {
+		t.Fatalf("get project users: %s", err)
+	}
+	for _, u := range users {
+		if u.ID == "u2" && u.Role != service.EditorRole {
+			t.Errorf("expected accepting an invite not to change the role of members got %s", u.Role)
+		}
+	}
+
+	invites, err := svc.GetInvites(ownerCtx, proj.ID)
+	if err != nil || len(invites) != 1 || invites[0].Token != inv.Token {
+		t.Errorf("expected the created invite got %v: %v", invites, err)
+	}
+	err = svc.RevokeInvite(ownerCtx, proj.ID, inv.ID)
+	if err != nil {
+		t.Fatalf("revoke invite: %s", err)
+	}
+	_, err = svc.AcceptInvite(contextutil.AddUserIDToCtx(context.Background(), "u4"), inv.Token)
+	if !errors.Is(err, service.ErrInviteNotFound) {
+		t.Errorf("expected revoked invite not to be found got %v", err)
+	}
+	_, err = svc.AcceptInvite(guestCtx, "not-a-token")
+	if !errors.Is(err, service.ErrInviteNotFound) {
+		t.Errorf("expected invalid token not to be found got %v", err)
+	}
+	err = svc.RevokeInvite(ownerCtx, proj.ID, inv.ID)
+	if !errors.Is(err, service.ErrInviteNotFound) {
+		t.Errorf("expected revoking twice not to find the invite got %v", err)
+	}
+}
+
+func TestInvitesDisabled(t *testing.T) {
+	store := memory.New()
+	svc := service.New(store, store, nil)
+	ctx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	proj, err := svc.AddProject(ctx, service.Project{ID: uuid.New(), Name: "test", Currency: money.EUR, Members: []string{"u1"}})
+	if err != nil {
+		t.Fatalf("add project: %s", err)
+	}
+
+	_, err = svc.CreateInvite(ctx, proj.ID, service.EditorRole, 0)
+	if !errors.Is(err, service.ErrInvitesDisabled) {
+		t.Errorf("expected invites to be disabled got %v", err)
+	}
+	_, err = svc.AcceptInvite(ctx, "token")
+	if !errors.Is(err, service.ErrInvitesDisabled) {
+		t.Errorf("expected invites to be disabled got %v", err)
+	}
+}
+
+func TestGuests(t *testing.T) {
+	svc, proj := newTestService(t, "u1", "u2")
+	ownerCtx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	editorCtx := contextutil.AddUserIDToCtx(context.Background(), "u2")
+
+	_, err := svc.AddGuest(editorCtx, proj.ID, "Bob")
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected editor not to add guests got %v", err)
+	}
+	guest, err := svc.AddGuest(ownerCtx, proj.ID, "Bob")
+	if err != nil {
+		t.Fatalf("add guest: %s", err)
+	}
+	if !guest.Guest || guest.Name() != "Bob" {
+		t.Errorf("expected guest named Bob got %+v", guest)
+	}
+
+	paidByGuest, err := svc.AddTransaction(editorCtx, proj.ID, service.Transaction{
+		Name: "dinner", TransactionType: service.ExpenseTransactionType,
+		Amount: money.New(900, money.EUR), SourceID: guest.ID, TargetIDs: []string{"u1", "u2", guest.ID}, SplitMode: service.EqualSplitMode,
+	})
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+
+	other, err := svc.AddProject(ownerCtx, service.Project{Name: "other", Currency: money.EUR})
+	if err != nil {
+		t.Fatalf("add project: %s", err)
+	}
+	err = svc.AddProjectUser(ownerCtx, other.ID, guest.ID, service.EditorRole)
+	if !errors.Is(err, service.ErrGuestOfOtherProject) {
+		t.Errorf("expected guest not to join another project got %v", err)
+	}
+	_, err = svc.ClaimGuest(contextutil.AddUserIDToCtx(context.Background(), "outsider"), proj.ID, guest.ID)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected outsider not to claim guest got %v", err)
+	}
+	err = svc.AddProjectUser(ownerCtx, proj.ID, "u3", service.ViewerRole)
+	if err != nil {
+		t.Fatalf("add project user: %s", err)
+	}
+	_, err = svc.ClaimGuest(contextutil.AddUserIDToCtx(context.Background(), "u3"), proj.ID, guest.ID)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected viewer not to claim guest got %v", err)
+	}
+	_, err = svc.ClaimGuest(editorCtx, proj.ID, "u1")
+	if !errors.Is(err, service.ErrGuestNotFound) {
+		t.Errorf("expected member that is no guest not to be claimed got %v", err)
+	}
+
+	claimed, err := svc.ClaimGuest(editorCtx, proj.ID, guest.ID)
+	if err != nil {
+		t.Fatalf("claim guest: %s", err)
+	}
+	if slices.Contains(claimed.Members, guest.ID) {
+		t.Errorf("expected claimed guest to be removed got %v", claimed.Members)
+	}
+	tx, err := svc.GetTransaction(editorCtx, proj.ID, paidByGuest.ID)
+	if err != nil {
+		t.Fatalf("get transaction: %s", err)
+	}
+	if tx.SourceID != "u2" || !slices.Equal(tx.TargetIDs, []string{"u1", "u2"}) {
+		t.Errorf("expected transaction to be moved to u2 got %s to %v", tx.SourceID, tx.TargetIDs)
+	}
+	costs, err := svc.GetCostsByProject(editorCtx, proj.ID)
+	if err != nil {
+		t.Fatalf("get costs: %s", err)
+	}
+	if balance := costs.UserCosts["u2"].Balance.Amount(); balance != 300 {
+		t.Errorf("expected u2 to keep the share of the guest with a balance of 300 got %d", balance)
+	}
+
+	_, err = svc.ClaimGuest(editorCtx, proj.ID, guest.ID)
+	if !errors.Is(err, service.ErrGuestNotFound) {
+		t.Errorf("expected claimed guest not to be found got %v", err)
+	}
+}
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestRecurringTransactions(t *testing.T) {
+	store := memory.New()
+	svc := service.New(store, store, invite.NewSigner("test"))
+	proj, err := svc.AddProject(contextutil.AddUserIDToCtx(context.Background(), "u1"),
+		service.Project{Name: "flat", Currency: money.EUR, Members: []string{"u1", "u2", "u3"}})
+	if err != nil {
+		t.Fatalf("add project: %s", err)
+	}
+	editorCtx := contextutil.AddUserIDToCtx(context.Background(), "u2")
+	start := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+	rent, err := svc.AddRecurringTransaction(editorCtx, proj.ID, service.RecurringTransaction{
+		Template: service.Transaction{
+			Name: "rent", TransactionType: service.ExpenseTransactionType, Amount: money.New(90000, money.EUR),
+			SourceID: "u1", TargetIDs: []string{"u1", "u2", "u3"}, SplitMode: service.EqualSplitMode,
+		},
+		Schedule: recurrence.Schedule{Frequency: recurrence.Monthly, Interval: 1, Start: start, End: time.Date(2024, time.May, 15, 0, 0, 0, 0, time.UTC)},
+	})
+	if err != nil {
+		t.Fatalf("add recurring transaction: %s", err)
+	}
+	if !rent.NextOccurrence.Equal(start) {
+		t.Errorf("expected first occurrence on %s got %s", start, rent.NextOccurrence)
+	}
+	_, err = svc.AddRecurringTransaction(editorCtx, proj.ID, service.RecurringTransaction{
+		Template: service.Transaction{
+			Name: "internet", TransactionType: service.ExpenseTransactionType, Amount: money.New(3000, money.EUR),
+			SourceID: "outsider", TargetIDs: []string{"u1"}, SplitMode: service.EqualSplitMode,
+		},
+		Schedule: recurrence.Schedule{Frequency: recurrence.Weekly, Interval: 1, Start: start},
+	})
+	if !errors.Is(err, apperror.Validation) {
+		t.Errorf("expected validation error for source that is no member got %v", err)
+	}
+
+	clock := &fixedClock{now: time.Date(2024, time.March, 1, 8, 0, 0, 0, time.UTC)}
+	scheduler := service.NewScheduler(svc, clock, time.Hour)
+	if added := scheduler.RunOnce(context.Background()); added != 2 {
+		t.Errorf("expected the occurrences of january and february got %d", added)
+	}
+	if added := scheduler.RunOnce(context.Background()); added != 0 {
+		t.Errorf("expected no new occurrences on the same day got %d", added)
+	}
+	// occurrences that were added before the progress was stored are not added again
+	err = store.SetNextOccurrence(context.Background(), rent.ID, sql.NullTime{Time: start, Valid: true})
+	if err != nil {
+		t.Fatalf("set next occurrence: %s", err)
+	}
+	if added := scheduler.RunOnce(context.Background()); added != 0 {
+		t.Errorf("expected occurrences not to be added twice got %d", added)
+	}
+	clock.now = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if added := scheduler.RunOnce(context.Background()); added != 2 {
+		t.Errorf("expected the occurrences of march and april until the end date got %d", added)
+	}
+
+	page, err := svc.GetTransactions(editorCtx, proj.ID, service.TransactionQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("get transactions: %s", err)
+	}
+	var days []string
+	for _, tx := range page.Transactions {
+		days = append(days, tx.OccurredAt.Format(time.DateOnly))
+		if tx.CreatedBy != "u2" || tx.Amount.Amount() != 90000 {
+			t.Errorf("expected copy of the template created by u2 got %+v", tx)
+		}
+	}
+	expected := []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"}
+	if !slices.Equal(days, expected) {
+		t.Errorf("expected transactions on %v got %v", expected, days)
+	}
+
+	recurring, err := svc.GetRecurringTransactions(editorCtx, proj.ID)
+	if err != nil || len(recurring) != 1 || !recurring[0].NextOccurrence.IsZero() {
+		t.Errorf("expected the ended recurring transaction got %+v: %v", recurring, err)
+	}
+	err = svc.DeleteRecurringTransaction(contextutil.AddUserIDToCtx(context.Background(), "outsider"), proj.ID, rent.ID)
+	if !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("expected outsider not to delete recurring transactions got %v", err)
+	}
+	err = svc.DeleteRecurringTransaction(editorCtx, proj.ID, rent.ID)
+	if err != nil {
+		t.Fatalf("delete recurring transaction: %s", err)
+	}
+	err = svc.DeleteRecurringTransaction(editorCtx, proj.ID, rent.ID)
+	if !errors.Is(err, service.ErrRecurringTransactionNotFound) {
+		t.Errorf("expected deleted recurring transaction not to be found got %v", err)
+	}
+}
+
+func TestRecurringTransactionsOfFormerMembers(t *testing.T) {
+	store := memory.New()
+	svc := service.New(store, store, invite.NewSigner("test"))
+	ownerCtx := contextutil.AddUserIDToCtx(context.Background(), "u1")
+	proj, err := svc.AddProject(ownerCtx, service.Project{Name: "flat", Currency: money.EUR, Members: []string{"u1", "u2", "u3"}})
+	if err != nil {
+		t.Fatalf("add project: %s", err)
+	}
+	_, err = svc.AddRecurringTransaction(ownerCtx, proj.ID, service.RecurringTransaction{
+		Template: service.Transaction{
+			Name: "rent", TransactionType: service.ExpenseTransactionType, Amount: money.New(90000, money.EUR),
+			SourceID: "u1", TargetIDs: []string{"u2", "u3"}, SplitMode: service.EqualSplitMode,
+		},
+		Schedule: recurrence.Schedule{Frequency: recurrence.Weekly, Interval: 1, Start: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	})
+	if err != nil {
+		t.Fatalf("add recurring transaction: %s", err)
+	}
+
+	_, err = svc.RemoveProjectUser(ownerCtx, proj.ID, "u3", true)
+	if !errors.Is(err, service.ErrMemberInRecurringTransaction) {
+		t.Errorf("expected member of a recurring transaction not to be removed got %v", err)
+	}
+
+	// a member that left anyway, e.g. concurrently to adding the recurring transaction, is not charged
+	err = store.RemoveProjectUser(context.Background(), proj.ID, "u3", nil)
+	if err != nil {
+		t.Fatalf("remove project user: %s", err)
+	}
+	scheduler := service.NewScheduler(svc, &fixedClock{now: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)}, time.Hour)
+	if added := scheduler.RunOnce(context.Background()); added != 0 {
+		t.Errorf("expected no occurrences for a former member got %d", added)
+	}
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/diezfx/split-app-backend/internal/storage"
 	"github.com/google/uuid"
@@ -9,14 +11,46 @@ import (
 
 type ProjectStorage interface {
 	GetProjectByID(ctx context.Context, id uuid.UUID) (storage.Project, error)
-	GetProjects(ctx context.Context) ([]storage.Project, error)
-	GetProjectUsers(ctx context.Context, projectID uuid.UUID) ([]storage.User, error)
+	GetProjectHeader(ctx context.Context, id uuid.UUID) (storage.Project, error)
+	GetProjects(ctx context.Context, filter storage.ProjectFilter) ([]storage.Project, error)
+	GetProjectRole(ctx context.Context, projectID uuid.UUID, userID string) (string, error)
+	GetProjectUsers(ctx context.Context, projectID uuid.UUID) ([]storage.ProjectUser, error)
 	AddProject(ctx context.Context, project storage.Project) (storage.Project, error)
+	UpdateProject(ctx context.Context, project storage.Project) error
+	DeleteProject(ctx context.Context, id uuid.UUID) error
 	AddTransaction(ctx context.Context, projectID uuid.UUID, transaction storage.Transaction) error
+	AddTransactions(ctx context.Context, projectID uuid.UUID, transactions []storage.Transaction) error
+	ImportTransactions(ctx context.Context, projectID uuid.UUID, newMembers []string, transactions []storage.Transaction) error
+	UpdateTransaction(ctx context.Context, projectID uuid.UUID, transaction storage.Transaction) error
+	DeleteTransaction(ctx context.Context, projectID, transactionID uuid.UUID) error
+	GetTransactions(ctx context.Context, filter storage.TransactionFilter) ([]storage.Transaction, error)
 	GetUsers(ctx context.Context) ([]storage.User, error)
 	GetUser(ctx context.Context, userID string) (storage.User, error)
+	GetUsersByIDs(ctx context.Context, userIDs []string) ([]storage.User, error)
 	AddUser(ctx context.Context, user storage.User) error
-	AddProjectUser(ctx context.Context, projectID uuid.UUID, userID string) error
+	UpdateUser(ctx context.Context, user storage.User) error
+	AddProjectUser(ctx context.Context, projectID uuid.UUID, userID, role string) error
+	UpdateProjectUserRole(ctx context.Context, projectID uuid.UUID, userID, role string) error
+	RemoveProjectUser(ctx context.Context, projectID uuid.UUID, userID string, transactions []storage.Transaction) error
+	AddGuest(ctx context.Context, guest storage.User, role string) error
+	ClaimGuest(ctx context.Context, projectID uuid.UUID, guestID, userID string) error
+
+	AddInvite(ctx context.Context, invite storage.Invite) error
+	GetInvite(ctx context.Context, id uuid.UUID) (storage.Invite, error)
+	GetInvites(ctx context.Context, projectID uuid.UUID) ([]storage.Invite, error)
+	DeleteInvite(ctx context.Context, projectID, id uuid.UUID) error
+
+	AddRecurringTransaction(ctx context.Context, rt storage.RecurringTransaction) error
+	GetRecurringTransactions(ctx context.Context, projectID uuid.UUID) ([]storage.RecurringTransaction, error)
+	GetDueRecurringTransactions(ctx context.Context, until time.Time) ([]storage.RecurringTransaction, error)
+	SetNextOccurrence(ctx context.Context, id uuid.UUID, next sql.NullTime) error
+	DeleteRecurringTransaction(ctx context.Context, projectID, id uuid.UUID) error
+
+	AddIdempotencyRecord(ctx context.Context, record storage.IdempotencyRecord, abandonedBefore time.Time) error
+	GetIdempotencyRecord(ctx context.Context, userID, key string) (storage.IdempotencyRecord, error)
+	CompleteIdempotencyRecord(ctx context.Context, record storage.IdempotencyRecord) error
+	DeleteIdempotencyRecord(ctx context.Context, record storage.IdempotencyRecord) error
+	DeleteIdempotencyRecords(ctx context.Context, createdBefore time.Time) (int, error)
 
 	GetAllOutgoingTransactionsByUserID(ctx context.Context, userID string) ([]storage.Transaction, error)
 	GetAllIncomingTransactionsByUserID(ctx context.Context, userID string) ([]storage.Transaction, error)
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/diezfx/split-app-backend/internal/apperror"
+	"golang.org/x/exp/slices"
+)
+
+// memberFieldErrors returns a field error for the source and every target of the transaction that is not a member.
+// The fields are named like in the request bodies and prefixed with prefix.
+func memberFieldErrors(prefix string, tx *Transaction, members []string) []apperror.FieldError {
+	var fields []apperror.FieldError
+	if !slices.Contains(members, tx.SourceID) {
+		fields = append(fields, notMemberError(prefix+"sourceId", tx.SourceID))
+	}
+	for i, target := range tx.TargetIDs {
+		if !slices.Contains(members, target) {
+			fields = append(fields, notMemberError(fmt.Sprintf("%stargetIds[%d]", prefix, i), target))
+		}
+	}
+	return fields
+}
+
+func notMemberError(field, userID string) apperror.FieldError {
+	return apperror.FieldError{Field: field, Code: apperror.CodeNotMember, Message: fmt.Sprintf("%s is not a member of the project", userID)}
+}
+
+// checkMembers returns a validation error if the source or a target of the transaction is not a member of the project.
+func checkMembers(members []string, tx *Transaction) error {
+	if fields := memberFieldErrors("", tx, members); len(fields) > 0 {
+		return apperror.NewValidationError(fields...)
+	}
+	return nil
+}
+
+// checkSettlementMembers returns a validation error for every party of a settlement that is not a member of the project.
+func checkSettlementMembers(members []string, settlements []Settlement) error {
+	var fields []apperror.FieldError
+	for i, settlement := range settlements {
+		if !slices.Contains(members, settlement.From) {
+			fields = append(fields, notMemberError(fmt.Sprintf("settlements[%d].from", i), settlement.From))
+		}
+		if !slices.Contains(members, settlement.To) {
+			fields = append(fields, notMemberError(fmt.Sprintf("settlements[%d].to", i), settlement.To))
+		}
+	}
+	if len(fields) > 0 {
+		return apperror.NewValidationError(fields...)
+	}
+	return nil
+}
@@ -9,39 +9,61 @@ import (
 
 	"github.com/diezfx/split-app-backend/internal/api"
 	"github.com/diezfx/split-app-backend/internal/config"
+	"github.com/diezfx/split-app-backend/internal/exchangerate"
+	"github.com/diezfx/split-app-backend/internal/invite"
 	"github.com/diezfx/split-app-backend/internal/service"
 	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/diezfx/split-app-backend/internal/storage/memory"
+	"github.com/diezfx/split-app-backend/pkg/auth"
 	"github.com/diezfx/split-app-backend/pkg/logger"
 	"github.com/diezfx/split-app-backend/pkg/postgres"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
-func SetupSplitService() (*http.Server, error) {
+// SetupSplitService creates the server, the background jobs are started once the setup succeeded
+// and run until ctx is done.
+func SetupSplitService(ctx context.Context) (*http.Server, error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return nil, fmt.Errorf("read config: %w", err)
 	}
-	ctx := context.Background()
 	if cfg.Environment == config.LocalEnv {
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	}
 
 	logger.Info(ctx).String("config", fmt.Sprint(cfg)).Msg("Loaded config")
 
-	psqlClient, err := postgres.New(cfg.DB)
+	storageClient, err := newStorage(ctx, &cfg)
 	if err != nil {
-		return nil, fmt.Errorf("create sqlite client: %w", err)
+		return nil, err
 	}
 
-	storageClient, err := storage.New(ctx, psqlClient)
-	if err != nil {
-		return nil, fmt.Errorf("create storage client: %w", err)
+	var rateProvider service.ExchangeRateProvider = storageClient
+	if cfg.ExchangeRatesFile != "" {
+		rateProvider, err = exchangerate.NewFileProvider(cfg.ExchangeRatesFile)
+		if err != nil {
+			return nil, fmt.Errorf("create exchange rate provider: %w", err)
+		}
 	}
 
-	projectService := service.New(storageClient)
+	var inviteSigner *invite.Signer
+	if cfg.InviteKey != "" {
+		inviteSigner = invite.NewSigner(cfg.InviteKey)
+	} else {
+		logger.Info(ctx).Msg("No invite signing key configured, invites are disabled")
+	}
+	projectService := service.New(storageClient, rateProvider, inviteSigner)
 
-	router := api.InitAPI(&cfg, projectService)
+	var authClient *auth.Client
+	if !cfg.IsLocal() {
+		authClient, err = auth.New(ctx, cfg.Auth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	router := api.InitAPI(&cfg, authClient, projectService, projectService, projectService)
 
 	srv := &http.Server{
 		Handler: router.Handler,
@@ -51,5 +73,32 @@ func SetupSplitService() (*http.Server, error) {
 		ReadTimeout:  15 * time.Second,
 	}
 
+	// occurrences are only added once even with several instances running the scheduler
+	go service.NewScheduler(projectService, service.SystemClock{}, service.DefaultSchedulerInterval).Run(ctx)
+
 	return srv, nil
 }
+
+// storageBackend is implemented by all storages, they also serve as fallback for exchange rates.
+type storageBackend interface {
+	service.ProjectStorage
+	service.ExchangeRateProvider
+}
+
+func newStorage(ctx context.Context, cfg *config.Config) (storageBackend, error) {
+	if cfg.Storage == config.MemoryStorage {
+		logger.Info(ctx).Msg("using in-memory storage, all data is lost on restart")
+		return memory.New(), nil
+	}
+
+	psqlClient, err := postgres.New(cfg.DB)
+	if err != nil {
+		return nil, fmt.Errorf("create postgres client: %w", err)
+	}
+
+	storageClient, err := storage.New(ctx, psqlClient)
+	if err != nil {
+		return nil, fmt.Errorf("create storage client: %w", err)
+	}
+	return storageClient, nil
+}
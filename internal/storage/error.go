@@ -1,8 +1,35 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/diezfx/split-app-backend/internal/apperror"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
 
 var (
-	ErrNotFound      = errors.New("element not found")
-	ErrAlreadyExists = errors.New("element already exists")
+	ErrNotFound      = fmt.Errorf("element %w", apperror.NotFound)
+	ErrAlreadyExists = fmt.Errorf("element already exists: %w", apperror.Conflict)
+	// ErrInvalidReference is returned if a referenced element doesn't exist or an element that is still referenced is removed
+	ErrInvalidReference = fmt.Errorf("invalid reference: %w", apperror.Conflict)
+	// ErrLastOwner is returned if the only owner of a project would be removed or lose the role
+	ErrLastOwner = fmt.Errorf("project needs an owner: %w", apperror.Conflict)
 )
+
+// mapError translates postgres errors into the errors of this package, other errors are returned unchanged.
+func mapError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case pgerrcode.UniqueViolation:
+		return fmt.Errorf("%w: %w", ErrAlreadyExists, err)
+	case pgerrcode.ForeignKeyViolation:
+		return fmt.Errorf("%w: %w", ErrInvalidReference, err)
+	default:
+		return err
+	}
+}
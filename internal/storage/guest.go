@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// AddGuest creates the guest and adds it with the role to its project in one database transaction.
+func (c *Client) AddGuest(ctx context.Context, guest User, role string) error {
+	addGuestFunc := func(ctx context.Context, tx *sql.Tx) error {
+		const sqlQuery = `INSERT INTO members (id,display_name,guest_project_id) VALUES ($1,$2,$3)`
+		_, err := tx.ExecContext(ctx, sqlQuery, guest.ID, guest.DisplayName, guest.GuestProjectID)
+		if err != nil {
+			return fmt.Errorf("insert guest: %w", err)
+		}
+		return addUsers(ctx, tx, guest.GuestProjectID.UUID, []string{guest.ID}, map[string]string{guest.ID: role})
+	}
+
+	err := withTransaction(ctx, c.conn.DB, addGuestFunc)
+	if err != nil {
+		return fmt.Errorf("add guest: %w", mapError(err))
+	}
+	return nil
+}
+
+// ClaimGuest replaces the guest with the user in all transactions and recurring transactions of the project
+// and deletes the guest in one database transaction. If both are targets of a transaction their weights are added up,
+// equal splits become shares, so the split stays the same.
+// Returns ErrNotFound if the guest is no guest of the project and ErrInvalidReference if the user is no member.
+func (c *Client) ClaimGuest(ctx context.Context, projectID uuid.UUID, guestID, userID string) error {
+	claimFunc := func(ctx context.Context, tx *sql.Tx) error {
+		const lockGuestQuery = `SELECT id FROM members WHERE id=$1 AND guest_project_id=$2 FOR UPDATE`
+		var id string
+		err := tx.QueryRowContext(ctx, lockGuestQuery, guestID, projectID).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("select guest: %w", err)
+		}
+
+		const membershipQuery = `SELECT user_id FROM project_memberships WHERE project_id=$1 AND user_id=$2 AND left_at IS NULL`
+		err = tx.QueryRowContext(ctx, membershipQuery, projectID, userID).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("member %s: %w", userID, ErrInvalidReference)
+		}
+		if err != nil {
+			return fmt.Errorf("select membership: %w", err)
+		}
+
+		// $1 is the project, $2 the guest and $3 the user in all statements
+		statements := []struct {
+			name  string
+			query string
+		}{
+			{"update split modes", `
+			UPDATE transactions SET split_mode='Shares'
+			WHERE project_id=$1 AND split_mode='Equal'
+			AND id IN (SELECT transaction_id FROM transaction_targets WHERE project_id=$1 AND user_id=$2)
+			AND id IN (SELECT transaction_id FROM transaction_targets WHERE project_id=$1 AND user_id=$3)`},
+			{"merge shared targets", `
+			UPDATE transaction_targets as ut SET weight=ut.weight+gt.weight
+			FROM transaction_targets as gt
+			WHERE ut.transaction_id=gt.transaction_id AND ut.project_id=$1 AND ut.user_id=$3 AND gt.user_id=$2`},
+			{"delete shared targets", `
+			DELETE FROM transaction_targets as gt
+			USING transaction_targets as ut
+			WHERE gt.transaction_id=ut.transaction_id AND gt.project_id=$1 AND gt.user_id=$2 AND ut.user_id=$3`},
+			{"update targets", `UPDATE transaction_targets SET user_id=$3 WHERE project_id=$1 AND user_id=$2`},
+			{"update sources", `UPDATE transactions SET source_id=$3 WHERE project_id=$1 AND source_id=$2`},
+			{"update recurring split modes", `
+			UPDATE recurring_transactions SET split_mode='Shares'
+			WHERE project_id=$1 AND split_mode='Equal'
+			AND id IN (SELECT recurring_transaction_id FROM recurring_transaction_targets WHERE project_id=$1 AND user_id=$2)
+			AND id IN (SELECT recurring_transaction_id FROM recurring_transaction_targets WHERE project_id=$1 AND user_id=$3)`},
+			{"merge shared recurring targets", `
+			UPDATE recurring_transaction_targets as ut SET weight=ut.weight+gt.weight
+			FROM recurring_transaction_targets as gt
+			WHERE ut.recurring_transaction_id=gt.recurring_transaction_id AND ut.project_id=$1 AND ut.user_id=$3 AND gt.user_id=$2`},
+			{"delete shared recurring targets", `
+			DELETE FROM recurring_transaction_targets as gt
+			USING recurring_transaction_targets as ut
+			WHERE gt.recurring_transaction_id=ut.recurring_transaction_id AND gt.project_id=$1 AND gt.user_id=$2 AND ut.user_id=$3`},
+			{"update recurring targets", `UPDATE recurring_transaction_targets SET user_id=$3 WHERE project_id=$1 AND user_id=$2`},
+			{"update recurring sources", `UPDATE recurring_transactions SET source_id=$3 WHERE project_id=$1 AND source_id=$2`},
+			{"delete guest membership", `DELETE FROM project_memberships WHERE project_id=$1 AND user_id=$2`},
+			{"delete guest", `DELETE FROM members WHERE id=$2 AND guest_project_id=$1`},
+		}
+		for _, stmt := range statements {
+			if _, err := tx.ExecContext(ctx, stmt.query, projectID, guestID, userID); err != nil {
+				return fmt.Errorf("%s: %w", stmt.name, err)
+			}
+		}
+		return nil
+	}
+
+	return mapError(withTransaction(ctx, c.conn.DB, claimFunc))
+}
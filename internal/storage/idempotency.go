@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/georgysavva/scany/v2/sqlscan"
+)
+
+// AddIdempotencyRecord reserves the key for the user, ErrAlreadyExists is returned if the key was used before.
+// A record that is still in progress but was created before abandonedBefore belongs to a request that crashed,
+// it is replaced so the key can be used again.
+func (c *Client) AddIdempotencyRecord(ctx context.Context, record IdempotencyRecord, abandonedBefore time.Time) error {
+	sqlQuery := `
+	INSERT INTO idempotency_keys (user_id,key,fingerprint,created_at)
+	VALUES ($1,$2,$3,$4)
+	ON CONFLICT (user_id,key) DO UPDATE SET fingerprint=excluded.fingerprint, created_at=excluded.created_at
+	WHERE idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < $5
+	`
+	res, err := c.conn.DB.ExecContext(ctx, sqlQuery, record.UserID, record.Key, record.Fingerprint, record.CreatedAt, abandonedBefore)
+	if err != nil {
+		return fmt.Errorf("insert idempotency key: %w", mapError(err))
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return fmt.Errorf("idempotency key %s: %w", record.Key, ErrAlreadyExists)
+	}
+	return nil
+}
+
+func (c *Client) GetIdempotencyRecord(ctx context.Context, userID, key string) (IdempotencyRecord, error) {
+	sqlQuery := `
+	SELECT user_id,key,fingerprint,coalesce(status_code,0) as status_code,
+		coalesce(content_type,'') as content_type,coalesce(location,'') as location,response_body,created_at
+	FROM idempotency_keys
+	WHERE user_id=$1 AND key=$2
+	`
+	var record IdempotencyRecord
+	err := sqlscan.Get(ctx, c.conn.DB, &record, sqlQuery, userID, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return IdempotencyRecord{}, ErrNotFound
+	}
+	if err != nil {
+		return IdempotencyRecord{}, fmt.Errorf("select idempotency key: %w", err)
+	}
+	return record, nil
+}
+
+// CompleteIdempotencyRecord stores the response of the request. The record is matched by its fingerprint and
+// creation time as well, ErrNotFound is returned if the key was taken over by another request in the meantime.
+func (c *Client) CompleteIdempotencyRecord(ctx context.Context, record IdempotencyRecord) error {
+	sqlQuery := `
+	UPDATE idempotency_keys
+	SET status_code=$5, content_type=$6, location=$7, response_body=$8
+	WHERE user_id=$1 AND key=$2 AND fingerprint=$3 AND created_at=$4 AND status_code IS NULL
+	`
+	res, err := c.conn.DB.ExecContext(ctx, sqlQuery, record.UserID, record.Key, record.Fingerprint, record.CreatedAt,
+		record.StatusCode, record.ContentType, record.Location, record.ResponseBody)
+	if err != nil {
+		return fmt.Errorf("update idempotency key: %w", err)
+	}
+	return expectAffectedRows(res)
+}
+
+// DeleteIdempotencyRecord releases the key, it is matched like in CompleteIdempotencyRecord.
+func (c *Client) DeleteIdempotencyRecord(ctx context.Context, record IdempotencyRecord) error {
+	sqlQuery := `DELETE FROM idempotency_keys WHERE user_id=$1 AND key=$2 AND fingerprint=$3 AND created_at=$4`
+	res, err := c.conn.DB.ExecContext(ctx, sqlQuery, record.UserID, record.Key, record.Fingerprint, record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("delete idempotency key: %w", err)
+	}
+	return expectAffectedRows(res)
+}
+
+// DeleteIdempotencyRecords deletes all records created before the given time and returns how many were deleted.
+func (c *Client) DeleteIdempotencyRecords(ctx context.Context, createdBefore time.Time) (int, error) {
+	sqlQuery := `DELETE FROM idempotency_keys WHERE created_at < $1`
+	res, err := c.conn.DB.ExecContext(ctx, sqlQuery, createdBefore)
+	if err != nil {
+		return 0, fmt.Errorf("delete idempotency keys: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get deleted idempotency keys: %w", err)
+	}
+	return int(deleted), nil
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/sqlscan"
+	"github.com/google/uuid"
+)
+
+// AddInvite stores the invite, the created at time is set by the database.
+func (c *Client) AddInvite(ctx context.Context, invite Invite) error {
+	sqlQuery := `
+	INSERT INTO project_invites (id,project_id,role,created_by,expires_at)
+	VALUES ($1,$2,$3,$4,$5)
+	`
+	_, err := c.conn.DB.ExecContext(ctx, sqlQuery, invite.ID, invite.ProjectID, invite.Role, invite.CreatedBy, invite.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("insert invite: %w", mapError(err))
+	}
+	return nil
+}
+
+// GetInvite returns ErrNotFound if there is no invite with the id.
+func (c *Client) GetInvite(ctx context.Context, id uuid.UUID) (Invite, error) {
+	sqlQuery := `
+	SELECT id,project_id,role,created_by,created_at,expires_at
+	FROM project_invites
+	WHERE id=$1
+	`
+	var invite Invite
+	err := sqlscan.Get(ctx, c.conn.DB, &invite, sqlQuery, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Invite{}, ErrNotFound
+	}
+	if err != nil {
+		return Invite{}, fmt.Errorf("select invite: %w", err)
+	}
+	return invite, nil
+}
+
+// GetInvites returns all invites of the project ordered by creation, including expired ones.
+func (c *Client) GetInvites(ctx context.Context, projectID uuid.UUID) ([]Invite, error) {
+	sqlQuery := `
+	SELECT id,project_id,role,created_by,created_at,expires_at
+	FROM project_invites
+	WHERE project_id=$1
+	ORDER BY created_at, id
+	`
+	invites := []Invite{}
+	err := sqlscan.Select(ctx, c.conn.DB, &invites, sqlQuery, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("select invites: %w", err)
+	}
+	return invites, nil
+}
+
+// DeleteInvite returns ErrNotFound if the project has no invite with the id.
+func (c *Client) DeleteInvite(ctx context.Context, projectID, id uuid.UUID) error {
+	sqlQuery := `DELETE FROM project_invites WHERE id=$1 AND project_id=$2`
+	res, err := c.conn.DB.ExecContext(ctx, sqlQuery, id, projectID)
+	if err != nil {
+		return fmt.Errorf("delete invite: %w", err)
+	}
+	return expectAffectedRows(res)
+}
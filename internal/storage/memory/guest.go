@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+// AddGuest creates the guest and adds it with the role to its project.
+func (c *Client) AddGuest(_ context.Context, guest storage.User, role string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[guest.GuestProjectID.UUID]
+	if !guest.GuestProjectID.Valid || !ok {
+		return fmt.Errorf("project %s: %w", guest.GuestProjectID.UUID, storage.ErrInvalidReference)
+	}
+	if _, ok := c.users[guest.ID]; ok {
+		return fmt.Errorf("member %s: %w", guest.ID, storage.ErrAlreadyExists)
+	}
+	c.users[guest.ID] = guest
+	p.members = append(p.members, guest.ID)
+	slices.Sort(p.members)
+	p.roles[guest.ID] = storage.RoleOrDefault(role)
+	return nil
+}
+
+// ClaimGuest replaces the guest with the user in all transactions and recurring transactions of the project
+// and deletes the guest, like the postgres storage.Client.
+func (c *Client) ClaimGuest(_ context.Context, projectID uuid.UUID, guestID, userID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	guest, ok := c.users[guestID]
+	p, projectOK := c.projects[projectID]
+	if !ok || !projectOK || guest.GuestProjectID != (uuid.NullUUID{UUID: projectID, Valid: true}) {
+		return storage.ErrNotFound
+	}
+	if !slices.Contains(p.members, userID) {
+		return fmt.Errorf("member %s: %w", userID, storage.ErrInvalidReference)
+	}
+
+	for i := range p.transactions {
+		claimTransaction(&p.transactions[i], guestID, userID)
+	}
+	for i := range p.recurring {
+		rt := &p.recurring[i]
+		tx := storage.Transaction{SourceID: rt.SourceID, TargetIDs: rt.TargetIDs, SplitMode: rt.SplitMode, Weights: rt.Weights}
+		claimTransaction(&tx, guestID, userID)
+		rt.SourceID, rt.TargetIDs, rt.SplitMode, rt.Weights = tx.SourceID, tx.TargetIDs, tx.SplitMode, tx.Weights
+	}
+	isGuest := func(m string) bool { return m == guestID }
+	p.members = slices.DeleteFunc(p.members, isGuest)
+	p.formerMembers = slices.DeleteFunc(p.formerMembers, isGuest)
+	delete(p.roles, guestID)
+	delete(c.users, guestID)
+	return nil
+}
+
+// claimTransaction replaces the guest with the user, a user that is a target already gets the weight of the guest added.
+func claimTransaction(tx *storage.Transaction, guestID, userID string) {
+	if tx.SourceID == guestID {
+		tx.SourceID = userID
+	}
+	guestIndex := slices.Index(tx.TargetIDs, guestID)
+	if guestIndex == -1 {
+		return
+	}
+	userIndex := slices.Index(tx.TargetIDs, userID)
+	if userIndex == -1 {
+		tx.TargetIDs[guestIndex] = userID
+		return
+	}
+
+	weights := make([]int, 0, len(tx.TargetIDs))
+	for i := range tx.TargetIDs {
+		weights = append(weights, tx.Weight(i))
+	}
+	weights[userIndex] += weights[guestIndex]
+	tx.TargetIDs = slices.Delete(tx.TargetIDs, guestIndex, guestIndex+1)
+	tx.Weights = slices.Delete(weights, guestIndex, guestIndex+1)
+	if tx.SplitMode == "Equal" {
+		tx.SplitMode = "Shares"
+	}
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"golang.org/x/exp/slices"
+)
+
+func (c *Client) AddIdempotencyRecord(_ context.Context, record storage.IdempotencyRecord, abandonedBefore time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := [2]string{record.UserID, record.Key}
+	if stored, ok := c.idempotency[key]; ok && (stored.StatusCode != 0 || !stored.CreatedAt.Before(abandonedBefore)) {
+		return fmt.Errorf("idempotency key %s: %w", record.Key, storage.ErrAlreadyExists)
+	}
+	c.idempotency[key] = storage.IdempotencyRecord{
+		UserID: record.UserID, Key: record.Key, Fingerprint: record.Fingerprint, CreatedAt: record.CreatedAt,
+	}
+	return nil
+}
+
+func (c *Client) GetIdempotencyRecord(_ context.Context, userID, key string) (storage.IdempotencyRecord, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	record, ok := c.idempotency[[2]string{userID, key}]
+	if !ok {
+		return storage.IdempotencyRecord{}, storage.ErrNotFound
+	}
+	record.ResponseBody = slices.Clone(record.ResponseBody)
+	return record, nil
+}
+
+func (c *Client) CompleteIdempotencyRecord(_ context.Context, record storage.IdempotencyRecord) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := [2]string{record.UserID, record.Key}
+	stored, ok := c.idempotency[key]
+	if !ok || !sameClaim(&stored, &record) || stored.StatusCode != 0 {
+		return storage.ErrNotFound
+	}
+	stored.StatusCode = record.StatusCode
+	stored.ContentType = record.ContentType
+	stored.Location = record.Location
+	stored.ResponseBody = slices.Clone(record.ResponseBody)
+	c.idempotency[key] = stored
+	return nil
+}
+
+func (c *Client) DeleteIdempotencyRecord(_ context.Context, record storage.IdempotencyRecord) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := [2]string{record.UserID, record.Key}
+	if stored, ok := c.idempotency[key]; !ok || !sameClaim(&stored, &record) {
+		return storage.ErrNotFound
+	}
+	delete(c.idempotency, key)
+	return nil
+}
+
+// sameClaim reports whether both records belong to the same request, a key that was taken over has a new claim.
+func sameClaim(stored, record *storage.IdempotencyRecord) bool {
+	return stored.Fingerprint == record.Fingerprint && stored.CreatedAt.Equal(record.CreatedAt)
+}
+
+func (c *Client) DeleteIdempotencyRecords(_ context.Context, createdBefore time.Time) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deleted := 0
+	for key, record := range c.idempotency {
+		if record.CreatedAt.Before(createdBefore) {
+			delete(c.idempotency, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+// AddInvite stores the invite with its project, so it is deleted together with the project.
+func (c *Client) AddInvite(_ context.Context, invite storage.Invite) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[invite.ProjectID]
+	if !ok {
+		return fmt.Errorf("project %s: %w", invite.ProjectID, storage.ErrInvalidReference)
+	}
+	if _, err := c.findInvite(invite.ID); err == nil {
+		return fmt.Errorf("invite %s: %w", invite.ID, storage.ErrAlreadyExists)
+	}
+	invite.CreatedAt = time.Now()
+	p.invites = append(p.invites, invite)
+	return nil
+}
+
+func (c *Client) GetInvite(_ context.Context, id uuid.UUID) (storage.Invite, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.findInvite(id)
+}
+
+// GetInvites returns all invites of the project ordered by creation, including expired ones.
+func (c *Client) GetInvites(_ context.Context, projectID uuid.UUID) ([]storage.Invite, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	p, ok := c.projects[projectID]
+	if !ok {
+		return []storage.Invite{}, nil
+	}
+	return append([]storage.Invite{}, p.invites...), nil
+}
+
+func (c *Client) DeleteInvite(_ context.Context, projectID, id uuid.UUID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[projectID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	index := slices.IndexFunc(p.invites, func(i storage.Invite) bool { return i.ID == id })
+	if index == -1 {
+		return storage.ErrNotFound
+	}
+	p.invites = slices.Delete(p.invites, index, index+1)
+	return nil
+}
+
+func (c *Client) findInvite(id uuid.UUID) (storage.Invite, error) {
+	for _, p := range c.projects {
+		for _, invite := range p.invites {
+			if invite.ID == id {
+				return invite, nil
+			}
+		}
+	}
+	return storage.Invite{}, storage.ErrNotFound
+}
@@ -0,0 +1,642 @@
+// Package memory implements the project storage in memory, it is meant for tests and local development.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/google/uuid"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+type project struct {
+	storage.Project
+	members []string
+	// roles of the current members
+	roles map[string]string
+	// formerMembers left the project, they are still referenced by its transactions
+	formerMembers []string
+	transactions  []storage.Transaction
+	invites       []storage.Invite
+	recurring     []storage.RecurringTransaction
+}
+
+type rateKey struct {
+	from string
+	to   string
+}
+
+// Client stores everything in maps guarded by a single mutex.
+// It returns the same errors as the postgres storage.Client.
+type Client struct {
+	mu       sync.RWMutex
+	projects map[uuid.UUID]*project
+	users    map[string]storage.User
+	rates    map[rateKey]float64
+	// idempotency records are keyed by user and key
+	idempotency map[[2]string]storage.IdempotencyRecord
+}
+
+func New() *Client {
+	return &Client{
+		projects: map[uuid.UUID]*project{},
+		users:    map[string]storage.User{},
+		rates:    map[rateKey]float64{},
+
+		idempotency: map[[2]string]storage.IdempotencyRecord{},
+	}
+}
+
+func (c *Client) GetProjectByID(_ context.Context, id uuid.UUID) (storage.Project, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	p, ok := c.projects[id]
+	if !ok {
+		return storage.Project{}, storage.ErrNotFound
+	}
+	return p.toStorage(), nil
+}
+
+// GetProjectHeader returns the project with its members but without its transactions.
+func (c *Client) GetProjectHeader(ctx context.Context, id uuid.UUID) (storage.Project, error) {
+	p, err := c.GetProjectByID(ctx, id)
+	if err != nil {
+		return storage.Project{}, err
+	}
+	p.Transactions = []storage.Transaction{}
+	return p, nil
+}
+
+// GetProjects returns one page of the projects matching the filter ordered by id.
+func (c *Client) GetProjects(_ context.Context, filter storage.ProjectFilter) ([]storage.Project, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	projects := []storage.Project{}
+	for _, p := range c.projects {
+		if !slices.Contains(p.members, filter.UserID) {
+			continue
+		}
+		if filter.Member != "" && !slices.Contains(p.members, filter.Member) {
+			continue
+		}
+		if filter.Name != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(filter.Name)) {
+			continue
+		}
+		if p.ArchivedAt.Valid && !filter.IncludeArchived {
+			continue
+		}
+		if filter.After.Valid && p.ID.String() <= filter.After.UUID.String() {
+			continue
+		}
+		projects = append(projects, p.toStorage())
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].ID.String() < projects[j].ID.String() })
+	if len(projects) > filter.Limit {
+		projects = projects[:filter.Limit]
+	}
+	return projects, nil
+}
+
+// GetProjectRole returns the role of the user in the project, ErrNotFound if the user is no member.
+func (c *Client) GetProjectRole(_ context.Context, projectID uuid.UUID, userID string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	p, ok := c.projects[projectID]
+	if !ok || !slices.Contains(p.members, userID) {
+		return "", storage.ErrNotFound
+	}
+	return p.roles[userID], nil
+}
+
+func (c *Client) GetProjectUsers(_ context.Context, projectID uuid.UUID) ([]storage.ProjectUser, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	users := []storage.ProjectUser{}
+	p, ok := c.projects[projectID]
+	if !ok {
+		return users, nil
+	}
+	for _, m := range p.members {
+		users = append(users, storage.ProjectUser{User: c.users[m], Role: p.roles[m]})
+	}
+	return users, nil
+}
+
+func (c *Client) AddProject(_ context.Context, proj storage.Project) (storage.Project, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.projects[proj.ID]; ok {
+		return proj, fmt.Errorf("project %s: %w", proj.ID, storage.ErrAlreadyExists)
+	}
+	if err := c.checkMembers(proj.Members); err != nil {
+		return proj, err
+	}
+
+	roles := make(map[string]string, len(proj.Members))
+	for _, m := range proj.Members {
+		roles[m] = storage.RoleOrDefault(proj.Roles[m])
+	}
+	c.projects[proj.ID] = &project{
+		Project:      storage.Project{ID: proj.ID, Name: proj.Name, Currency: proj.Currency},
+		members:      sortedMembers(proj.Members),
+		roles:        roles,
+		transactions: []storage.Transaction{},
+	}
+	return proj, nil
+}
+
+func (c *Client) UpdateProject(_ context.Context, proj storage.Project) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[proj.ID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	p.Name = proj.Name
+	p.ArchivedAt = proj.ArchivedAt
+	return nil
+}
+
+// DeleteProject removes the project with its memberships, transactions and guests.
+func (c *Client) DeleteProject(_ context.Context, id uuid.UUID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.projects[id]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(c.projects, id)
+	maps.DeleteFunc(c.users, func(_ string, u storage.User) bool {
+		return u.GuestProjectID.Valid && u.GuestProjectID.UUID == id
+	})
+	return nil
+}
+
+// AddProjectUser adds the user with the role to the project, an empty role is the storage.DefaultRole.
+func (c *Client) AddProjectUser(_ context.Context, projectID uuid.UUID, userID, role string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[projectID]
+	if !ok {
+		return fmt.Errorf("project %s: %w", projectID, storage.ErrNotFound)
+	}
+	if err := c.checkMembers([]string{userID}); err != nil {
+		return err
+	}
+	if slices.Contains(p.members, userID) {
+		return fmt.Errorf("member %s: %w", userID, storage.ErrAlreadyExists)
+	}
+	p.members = append(p.members, userID)
+	slices.Sort(p.members)
+	p.roles[userID] = storage.RoleOrDefault(role)
+	p.formerMembers = slices.DeleteFunc(p.formerMembers, func(m string) bool { return m == userID })
+	return nil
+}
+
+// UpdateProjectUserRole changes the role of the member, returns storage.ErrNotFound if the user is no member.
+func (c *Client) UpdateProjectUserRole(_ context.Context, projectID uuid.UUID, userID, role string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[projectID]
+	if !ok || !slices.Contains(p.members, userID) {
+		return storage.ErrNotFound
+	}
+	if role != storage.OwnerRole && p.isLastOwner(userID) {
+		return storage.ErrLastOwner
+	}
+	p.roles[userID] = role
+	return nil
+}
+
+// RemoveProjectUser adds the transactions and moves the user to the former members, nothing is changed on an error.
+func (c *Client) RemoveProjectUser(_ context.Context, projectID uuid.UUID, userID string, transactions []storage.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[projectID]
+	if !ok {
+		return fmt.Errorf("project %s: %w", projectID, storage.ErrNotFound)
+	}
+	if !slices.Contains(p.members, userID) {
+		return fmt.Errorf("member %s: %w", userID, storage.ErrNotFound)
+	}
+	if p.isLastOwner(userID) {
+		return storage.ErrLastOwner
+	}
+	if err := c.checkNewTransactions(p, transactions); err != nil {
+		return err
+	}
+	c.appendTransactions(p, projectID, transactions)
+	p.members = slices.DeleteFunc(p.members, func(m string) bool { return m == userID })
+	delete(p.roles, userID)
+	p.formerMembers = append(p.formerMembers, userID)
+	return nil
+}
+
+func (c *Client) AddTransaction(ctx context.Context, projectID uuid.UUID, transaction storage.Transaction) error {
+	return c.AddTransactions(ctx, projectID, []storage.Transaction{transaction})
+}
+
+// AddTransactions adds either all transactions or none of them.
+func (c *Client) AddTransactions(_ context.Context, projectID uuid.UUID, transactions []storage.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[projectID]
+	if !ok {
+		return fmt.Errorf("project %s: %w", projectID, storage.ErrNotFound)
+	}
+
+	if err := c.checkNewTransactions(p, transactions); err != nil {
+		return err
+	}
+	c.appendTransactions(p, projectID, transactions)
+	return nil
+}
+
+// checkNewTransactions mirrors the constraints of the database for transactions that are added to the project.
+func (c *Client) checkNewTransactions(p *project, transactions []storage.Transaction) error {
+	newIDs := map[uuid.UUID]bool{}
+	for i := range transactions {
+		tx := &transactions[i]
+		if newIDs[tx.ID] || c.findTransaction(tx.ID) != nil {
+			return fmt.Errorf("transaction %s: %w", tx.ID, storage.ErrAlreadyExists)
+		}
+		if err := checkTransactionMembers(p, tx); err != nil {
+			return err
+		}
+		newIDs[tx.ID] = true
+	}
+	return nil
+}
+
+func (c *Client) appendTransactions(p *project, projectID uuid.UUID, transactions []storage.Transaction) {
+	now := time.Now()
+	for i := range transactions {
+		tx := copyTransaction(&transactions[i], projectID)
+		tx.CreatedAt = now
+		tx.UpdatedAt = now
+		p.transactions = append(p.transactions, tx)
+	}
+}
+
+// ImportTransactions adds the new members to the project and then the transactions, nothing is changed on an error.
+func (c *Client) ImportTransactions(_ context.Context, projectID uuid.UUID, newMembers []string, transactions []storage.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[projectID]
+	if !ok {
+		return fmt.Errorf("project %s: %w", projectID, storage.ErrNotFound)
+	}
+	for _, member := range newMembers {
+		if slices.Contains(p.members, member) {
+			return fmt.Errorf("member %s: %w", member, storage.ErrAlreadyExists)
+		}
+	}
+
+	// new members only exist after the import, so they are checked against both
+	known := func(userID string) bool {
+		return slices.Contains(p.members, userID) || slices.Contains(p.formerMembers, userID) || slices.Contains(newMembers, userID)
+	}
+	newIDs := map[uuid.UUID]bool{}
+	for i := range transactions {
+		tx := &transactions[i]
+		if newIDs[tx.ID] || c.findTransaction(tx.ID) != nil {
+			return fmt.Errorf("transaction %s: %w", tx.ID, storage.ErrAlreadyExists)
+		}
+		for _, userID := range append([]string{tx.SourceID}, tx.TargetIDs...) {
+			if !known(userID) {
+				return fmt.Errorf("member %s: %w", userID, storage.ErrInvalidReference)
+			}
+		}
+		newIDs[tx.ID] = true
+	}
+
+	for _, member := range newMembers {
+		if _, ok := c.users[member]; !ok {
+			c.users[member] = storage.User{ID: member}
+		}
+		p.members = append(p.members, member)
+		p.roles[member] = storage.DefaultRole
+		p.formerMembers = slices.DeleteFunc(p.formerMembers, func(m string) bool { return m == member })
+	}
+	slices.Sort(p.members)
+
+	now := time.Now()
+	for i := range transactions {
+		tx := copyTransaction(&transactions[i], projectID)
+		tx.CreatedAt = now
+		tx.UpdatedAt = now
+		p.transactions = append(p.transactions, tx)
+	}
+	return nil
+}
+
+func (c *Client) UpdateTransaction(_ context.Context, projectID uuid.UUID, transaction storage.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[projectID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	index := slices.IndexFunc(p.transactions, func(t storage.Transaction) bool { return t.ID == transaction.ID })
+	if index == -1 {
+		return storage.ErrNotFound
+	}
+	if err := checkTransactionMembers(p, &transaction); err != nil {
+		return err
+	}
+	updated := copyTransaction(&transaction, projectID)
+	updated.CreatedAt = p.transactions[index].CreatedAt
+	updated.CreatedBy = p.transactions[index].CreatedBy
+	updated.UpdatedAt = time.Now()
+	p.transactions[index] = updated
+	return nil
+}
+
+func (c *Client) DeleteTransaction(_ context.Context, projectID, transactionID uuid.UUID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[projectID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	index := slices.IndexFunc(p.transactions, func(t storage.Transaction) bool { return t.ID == transactionID })
+	if index == -1 {
+		return storage.ErrNotFound
+	}
+	p.transactions = slices.Delete(p.transactions, index, index+1)
+	return nil
+}
+
+// GetTransactions returns one page of the transactions matching the filter.
+func (c *Client) GetTransactions(_ context.Context, filter storage.TransactionFilter) ([]storage.Transaction, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	transactions := []storage.Transaction{}
+	p, ok := c.projects[filter.ProjectID]
+	if !ok {
+		return transactions, nil
+	}
+
+	for i := range p.transactions {
+		if matchesFilter(&p.transactions[i], &filter) {
+			transactions = append(transactions, copyTransaction(&p.transactions[i], p.ID))
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return compareTransactions(&transactions[i], &transactions[j], &filter) < 0
+	})
+	if len(transactions) > filter.Limit {
+		transactions = transactions[:filter.Limit]
+	}
+	return transactions, nil
+}
+
+func matchesFilter(t *storage.Transaction, filter *storage.TransactionFilter) bool {
+	switch {
+	case filter.ID.Valid && t.ID != filter.ID.UUID,
+		filter.TransactionType != "" && t.TransactionType != filter.TransactionType,
+		filter.SourceID != "" && t.SourceID != filter.SourceID,
+		filter.TargetID != "" && !slices.Contains(t.TargetIDs, filter.TargetID),
+		filter.Currency != "" && t.Currency != filter.Currency,
+		filter.MinAmount.Valid && int64(t.Amount) < filter.MinAmount.Int64,
+		filter.MaxAmount.Valid && int64(t.Amount) > filter.MaxAmount.Int64,
+		filter.From.Valid && t.OccurredAt.Before(filter.From.Time),
+		filter.To.Valid && !t.OccurredAt.Before(filter.To.Time):
+		return false
+	}
+	if filter.After == nil {
+		return true
+	}
+	after := storage.Transaction{ID: filter.After.ID, Amount: filter.After.Amount, OccurredAt: filter.After.OccurredAt}
+	return compareTransactions(t, &after, filter) > 0
+}
+
+// compareTransactions orders by the sort column of the filter and then by id, like the postgres storage.
+func compareTransactions(a, b *storage.Transaction, filter *storage.TransactionFilter) int {
+	result := a.OccurredAt.Compare(b.OccurredAt)
+	if filter.SortBy == storage.SortByAmount {
+		result = cmp.Compare(a.Amount, b.Amount)
+	}
+	if result == 0 {
+		result = strings.Compare(a.ID.String(), b.ID.String())
+	}
+	if filter.Descending {
+		return -result
+	}
+	return result
+}
+
+func (c *Client) GetAllOutgoingTransactionsByUserID(_ context.Context, userID string) ([]storage.Transaction, error) {
+	return c.filterTransactions(func(t *storage.Transaction) bool {
+		return t.SourceID == userID
+	}), nil
+}
+
+func (c *Client) GetAllIncomingTransactionsByUserID(_ context.Context, userID string) ([]storage.Transaction, error) {
+	return c.filterTransactions(func(t *storage.Transaction) bool {
+		return slices.Contains(t.TargetIDs, userID)
+	}), nil
+}
+
+func (c *Client) GetUser(_ context.Context, userID string) (storage.User, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	user, ok := c.users[userID]
+	if !ok {
+		return storage.User{}, storage.ErrNotFound
+	}
+	return user, nil
+}
+
+func (c *Client) GetUsers(_ context.Context) ([]storage.User, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	users := make([]storage.User, 0, len(c.users))
+	for _, u := range c.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// GetUsersByIDs returns the users with the given ids ordered by id, unknown ids are skipped.
+func (c *Client) GetUsersByIDs(_ context.Context, userIDs []string) ([]storage.User, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	users := []storage.User{}
+	for _, id := range userIDs {
+		if u, ok := c.users[id]; ok && !slices.Contains(users, u) {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// UpdateUser replaces the profile of the user, the user is created if it doesn't exist yet.
+func (c *Client) UpdateUser(_ context.Context, user storage.User) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.users[user.ID] = user
+	return nil
+}
+
+func (c *Client) AddUser(_ context.Context, user storage.User) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.users[user.ID]; ok {
+		return fmt.Errorf("member %s: %w", user.ID, storage.ErrAlreadyExists)
+	}
+	c.users[user.ID] = user
+	return nil
+}
+
+// SetExchangeRate stores the rate for converting from into to.
+func (c *Client) SetExchangeRate(from, to string, rate float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rates[rateKey{from: from, to: to}] = rate
+}
+
+// GetExchangeRate returns how many units of to you get for one unit of from.
+// If only the inverse rate is stored it is used instead.
+func (c *Client) GetExchangeRate(_ context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if rate, ok := c.rates[rateKey{from: from, to: to}]; ok {
+		return rate, nil
+	}
+	if rate, ok := c.rates[rateKey{from: to, to: from}]; ok {
+		return 1 / rate, nil
+	}
+	return 0, storage.ErrNotFound
+}
+
+func (c *Client) filterTransactions(filter func(t *storage.Transaction) bool) []storage.Transaction {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	transactions := []storage.Transaction{}
+	for _, p := range c.projects {
+		for i := range p.transactions {
+			if filter(&p.transactions[i]) {
+				transactions = append(transactions, copyTransaction(&p.transactions[i], p.ID))
+			}
+		}
+	}
+	return transactions
+}
+
+func (c *Client) findTransaction(id uuid.UUID) *storage.Transaction {
+	for _, p := range c.projects {
+		for i := range p.transactions {
+			if p.transactions[i].ID == id {
+				return &p.transactions[i]
+			}
+		}
+	}
+	return nil
+}
+
+// checkMembers mirrors the foreign keys on the members table.
+func (c *Client) checkMembers(userIDs []string) error {
+	for _, id := range userIDs {
+		if _, ok := c.users[id]; !ok {
+			return fmt.Errorf("member %s: %w", id, storage.ErrInvalidReference)
+		}
+	}
+	return nil
+}
+
+// checkTransactionMembers mirrors the foreign keys from transactions and their targets on the project memberships,
+// members that left the project keep their membership.
+func checkTransactionMembers(p *project, tx *storage.Transaction) error {
+	for _, id := range append([]string{tx.SourceID}, tx.TargetIDs...) {
+		if !slices.Contains(p.members, id) && !slices.Contains(p.formerMembers, id) {
+			return fmt.Errorf("member %s: %w", id, storage.ErrInvalidReference)
+		}
+	}
+	return nil
+}
+
+// isLastOwner reports whether the user is the only owner of the project.
+func (p *project) isLastOwner(userID string) bool {
+	if p.roles[userID] != storage.OwnerRole {
+		return false
+	}
+	for member, role := range p.roles {
+		if member != userID && role == storage.OwnerRole {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *project) toStorage() storage.Project {
+	transactions := make([]storage.Transaction, 0, len(p.transactions))
+	for i := range p.transactions {
+		transactions = append(transactions, copyTransaction(&p.transactions[i], p.ID))
+	}
+	proj := p.Project
+	proj.Members = slices.Clone(p.members)
+	proj.Roles = maps.Clone(p.roles)
+	proj.Transactions = transactions
+	return proj
+}
+
+func sortedMembers(members []string) []string {
+	sorted := slices.Clone(members)
+	if sorted == nil {
+		sorted = []string{}
+	}
+	slices.Sort(sorted)
+	return sorted
+}
+
+// copyTransaction makes sure no slices are shared between the store and its callers.
+func copyTransaction(t *storage.Transaction, projectID uuid.UUID) storage.Transaction {
+	tx := *t
+	tx.ProjectID = projectID
+	tx.TargetIDs = slices.Clone(t.TargetIDs)
+	if tx.TargetIDs == nil {
+		tx.TargetIDs = []string{}
+	}
+	weights := make([]int, 0, len(t.TargetIDs))
+	for i := range t.TargetIDs {
+		weights = append(weights, t.Weight(i))
+	}
+	tx.Weights = weights
+	return tx
+}
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/diezfx/split-app-backend/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) service.ProjectStorage {
+		return New()
+	})
+}
@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+// AddRecurringTransaction stores the recurring transaction with its project, so it is deleted together with the project.
+func (c *Client) AddRecurringTransaction(_ context.Context, rt storage.RecurringTransaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[rt.ProjectID]
+	if !ok {
+		return fmt.Errorf("project %s: %w", rt.ProjectID, storage.ErrInvalidReference)
+	}
+	if _, ok := c.findRecurringTransaction(rt.ID); ok {
+		return fmt.Errorf("recurring transaction %s: %w", rt.ID, storage.ErrAlreadyExists)
+	}
+	for _, id := range append([]string{rt.SourceID}, rt.TargetIDs...) {
+		if !slices.Contains(p.members, id) && !slices.Contains(p.formerMembers, id) {
+			return fmt.Errorf("member %s: %w", id, storage.ErrInvalidReference)
+		}
+	}
+	rt.CreatedAt = time.Now()
+	p.recurring = append(p.recurring, copyRecurringTransaction(&rt))
+	return nil
+}
+
+// GetRecurringTransactions returns the recurring transactions of the project ordered by creation.
+func (c *Client) GetRecurringTransactions(_ context.Context, projectID uuid.UUID) ([]storage.RecurringTransaction, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	recurring := []storage.RecurringTransaction{}
+	if p, ok := c.projects[projectID]; ok {
+		for i := range p.recurring {
+			recurring = append(recurring, copyRecurringTransaction(&p.recurring[i]))
+		}
+	}
+	return recurring, nil
+}
+
+// GetDueRecurringTransactions returns the recurring transactions with an occurrence on or before until
+// that wasn't added yet, archived projects are skipped.
+func (c *Client) GetDueRecurringTransactions(_ context.Context, until time.Time) ([]storage.RecurringTransaction, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	due := []storage.RecurringTransaction{}
+	for _, p := range c.projects {
+		if p.ArchivedAt.Valid {
+			continue
+		}
+		for i := range p.recurring {
+			rt := &p.recurring[i]
+			if rt.NextOccurrence.Valid && !rt.NextOccurrence.Time.After(until) {
+				due = append(due, copyRecurringTransaction(rt))
+			}
+		}
+	}
+	slices.SortFunc(due, func(a, b storage.RecurringTransaction) int {
+		if byDate := a.NextOccurrence.Time.Compare(b.NextOccurrence.Time); byDate != 0 {
+			return byDate
+		}
+		return cmp.Compare(a.ID.String(), b.ID.String())
+	})
+	return due, nil
+}
+
+// SetNextOccurrence stores up to which day the recurring transaction was added.
+func (c *Client) SetNextOccurrence(_ context.Context, id uuid.UUID, next sql.NullTime) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rt, ok := c.findRecurringTransaction(id)
+	if !ok {
+		return storage.ErrNotFound
+	}
+	rt.NextOccurrence = next
+	return nil
+}
+
+// DeleteRecurringTransaction keeps the transactions that were already added.
+func (c *Client) DeleteRecurringTransaction(_ context.Context, projectID, id uuid.UUID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[projectID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	index := slices.IndexFunc(p.recurring, func(rt storage.RecurringTransaction) bool { return rt.ID == id })
+	if index == -1 {
+		return storage.ErrNotFound
+	}
+	p.recurring = slices.Delete(p.recurring, index, index+1)
+	return nil
+}
+
+func (c *Client) findRecurringTransaction(id uuid.UUID) (*storage.RecurringTransaction, bool) {
+	for _, p := range c.projects {
+		for i := range p.recurring {
+			if p.recurring[i].ID == id {
+				return &p.recurring[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// copyRecurringTransaction makes sure no slices are shared between the store and its callers.
+func copyRecurringTransaction(rt *storage.RecurringTransaction) storage.RecurringTransaction {
+	copied := *rt
+	copied.TargetIDs = slices.Clone(rt.TargetIDs)
+	if copied.TargetIDs == nil {
+		copied.TargetIDs = []string{}
+	}
+	weights := make([]int, 0, len(rt.TargetIDs))
+	for i := range rt.TargetIDs {
+		weights = append(weights, rt.Weight(i))
+	}
+	copied.Weights = weights
+	return copied
+}
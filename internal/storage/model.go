@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -12,19 +13,37 @@ type Transaction struct {
 	Name            string
 	TransactionType string
 	Amount          int
+	Currency        string
 	SourceID        string
 	TargetIDs       []string
+	SplitMode       string
+	// Weights are aligned with TargetIDs
+	Weights []int
+	// OccurredAt is the day the transaction happened, the time is always midnight UTC
+	OccurredAt time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	CreatedBy  string
 }
 
 type projectQueryElement struct {
-	ProjectID       sql.NullString
-	ProjectName     sql.NullString
-	TransactionID   sql.NullString
-	TransactionName sql.NullString
-	TransactionType sql.NullString
-	Amount          sql.NullInt64
-	SourceID        sql.NullString
-	TargetID        sql.NullString
+	ProjectID         sql.NullString
+	ProjectName       sql.NullString
+	ProjectCurrency   sql.NullString
+	ProjectArchivedAt sql.NullTime
+	TransactionID     sql.NullString
+	TransactionName   sql.NullString
+	TransactionType   sql.NullString
+	Amount            sql.NullInt64
+	Currency          sql.NullString
+	SourceID          sql.NullString
+	SplitMode         sql.NullString
+	OccurredAt        sql.NullTime
+	CreatedAt         sql.NullTime
+	UpdatedAt         sql.NullTime
+	CreatedBy         sql.NullString
+	TargetID          sql.NullString
+	Weight            sql.NullInt64
 }
 
 type transactionQueryElement struct {
@@ -33,17 +52,172 @@ type transactionQueryElement struct {
 	Name            string
 	TransactionType string
 	Amount          int
+	Currency        string
 	SourceID        string
+	SplitMode       string
+	OccurredAt      time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	CreatedBy       string
 	TargetID        string
+	Weight          int
 }
 
+// ProjectFilter selects a page of the projects the user is a member of.
+type ProjectFilter struct {
+	UserID string
+	// Member only returns projects this user is a member of as well, ignored if empty
+	Member string
+	// Name only returns projects containing this string case-insensitively, ignored if empty
+	Name string
+	// After is the id of the last project of the previous page
+	After uuid.NullUUID
+	Limit int
+	// IncludeArchived also returns archived projects
+	IncludeArchived bool
+}
+
+type TransactionSort string
+
+const (
+	SortByDate   TransactionSort = "date"
+	SortByAmount TransactionSort = "amount"
+)
+
+// TransactionFilter selects a page of the transactions of a project, empty fields are ignored.
+type TransactionFilter struct {
+	ProjectID uuid.UUID
+	// ID only matches the transaction with the id
+	ID              uuid.NullUUID
+	TransactionType string
+	SourceID        string
+	TargetID        string
+	// Currency only matches transactions in this currency
+	Currency string
+	// MinAmount and MaxAmount are inclusive and compared to the amount in its original currency
+	MinAmount sql.NullInt64
+	MaxAmount sql.NullInt64
+	// From is inclusive, To is exclusive, both are compared to OccurredAt
+	From sql.NullTime
+	To   sql.NullTime
+
+	SortBy     TransactionSort
+	Descending bool
+	// After is the position of the last transaction of the previous page
+	After *TransactionCursor
+	Limit int
+}
+
+// TransactionCursor is the position of a transaction in the sort order of a TransactionFilter.
+type TransactionCursor struct {
+	ID         uuid.UUID
+	Amount     int
+	OccurredAt time.Time
+}
+
+// User is a member with their profile, empty profile fields were not set by the user.
+// Guests are placeholders for people without an account, they only have a display name.
 type User struct {
-	ID string
+	ID                string `db:"id"`
+	DisplayName       string `db:"display_name"`
+	Email             string `db:"email"`
+	AvatarURL         string `db:"avatar_url"`
+	PreferredCurrency string `db:"preferred_currency"`
+	// GuestProjectID is the only project of a guest, users with an account have none
+	GuestProjectID uuid.NullUUID `db:"guest_project_id"`
+}
+
+// ProjectUser is a member of a project with their role in it.
+type ProjectUser struct {
+	User
+	Role string `db:"role"`
 }
 
+// DefaultRole is given to members that are added without a role, e.g. by an import.
+const DefaultRole = "editor"
+
+// OwnerRole is the role that manages the project, the last owner can't be removed or lose the role.
+const OwnerRole = "owner"
+
 type Project struct {
 	ID           uuid.UUID
 	Name         string
+	Currency     string
 	Transactions []Transaction
 	Members      []string
+	// Roles maps members to their role, members without one get the DefaultRole
+	Roles map[string]string
+	// ArchivedAt is only valid for archived projects
+	ArchivedAt sql.NullTime
+}
+
+// RoleOrDefault returns the DefaultRole for an empty role.
+func RoleOrDefault(role string) string {
+	if role == "" {
+		return DefaultRole
+	}
+	return role
+}
+
+// Invite lets everyone with its token join the project with the role until it expires.
+type Invite struct {
+	ID        uuid.UUID `db:"id"`
+	ProjectID uuid.UUID `db:"project_id"`
+	Role      string    `db:"role"`
+	CreatedBy string    `db:"created_by"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// RecurringTransaction is the template of a transaction that is added on every occurrence of its schedule.
+type RecurringTransaction struct {
+	ID              uuid.UUID `db:"id"`
+	ProjectID       uuid.UUID `db:"project_id"`
+	Name            string    `db:"name"`
+	TransactionType string    `db:"transaction_type"`
+	Amount          int       `db:"amount"`
+	Currency        string    `db:"currency"`
+	SourceID        string    `db:"source_id"`
+	TargetIDs       []string  `db:"-"`
+	SplitMode       string    `db:"split_mode"`
+	// Weights are aligned with TargetIDs
+	Weights   []int     `db:"-"`
+	Frequency string    `db:"frequency"`
+	Interval  int       `db:"interval_count"`
+	StartDate time.Time `db:"start_date"`
+	// EndDate is the last day an occurrence may fall on, it is only valid for schedules that end
+	EndDate sql.NullTime `db:"end_date"`
+	// NextOccurrence is the first occurrence that wasn't added as a transaction yet, it is invalid once the schedule ended
+	NextOccurrence sql.NullTime `db:"next_occurrence"`
+	CreatedBy      string       `db:"created_by"`
+	CreatedAt      time.Time    `db:"created_at"`
+}
+
+// IdempotencyRecord is a request made with an idempotency key and its response.
+// StatusCode is 0 as long as the request is in progress.
+type IdempotencyRecord struct {
+	UserID       string
+	Key          string
+	Fingerprint  string
+	StatusCode   int
+	ContentType  string
+	Location     string
+	ResponseBody []byte
+	CreatedAt    time.Time
+}
+
+// Weight returns the weight of the i-th target, equal splits don't carry weights and default to 1.
+func (t *Transaction) Weight(i int) int {
+	if i >= len(t.Weights) {
+		return 1
+	}
+	return t.Weights[i]
+}
+
+// Weight returns the weight of the i-th target like Transaction.Weight.
+func (t *RecurringTransaction) Weight(i int) int {
+	if i >= len(t.Weights) {
+		return 1
+	}
+	return t.Weights[i]
 }
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/georgysavva/scany/v2/sqlscan"
+	"github.com/google/uuid"
+)
+
+const recurringTransactionColumns = `
+	rt.id, rt.project_id, rt.name, rt.transaction_type, rt.amount, rt.currency, rt.source_id, rt.split_mode,
+	rt.frequency, rt.interval_count, rt.start_date, rt.end_date, rt.next_occurrence, rt.created_by, rt.created_at`
+
+// AddRecurringTransaction stores the recurring transaction with its targets in one database transaction.
+func (c *Client) AddRecurringTransaction(ctx context.Context, rt RecurringTransaction) error {
+	addFunc := func(ctx context.Context, tx *sql.Tx) error {
+		const sqlQuery = `
+		INSERT INTO recurring_transactions (id,project_id,name,transaction_type,amount,currency,source_id,split_mode,
+			frequency,interval_count,start_date,end_date,next_occurrence,created_by)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
+		`
+		_, err := tx.ExecContext(ctx, sqlQuery, rt.ID, rt.ProjectID, rt.Name, rt.TransactionType, rt.Amount, rt.Currency,
+			rt.SourceID, rt.SplitMode, rt.Frequency, rt.Interval, rt.StartDate, rt.EndDate, rt.NextOccurrence, rt.CreatedBy)
+		if err != nil {
+			return fmt.Errorf("insert recurring transaction: %w", err)
+		}
+
+		const targetsQuery = `
+		INSERT INTO recurring_transaction_targets (recurring_transaction_id,project_id,user_id,weight)
+		VALUES ($1,$2,$3,$4)
+		`
+		stmt, err := tx.PrepareContext(ctx, targetsQuery)
+		if err != nil {
+			return fmt.Errorf("prepare add recurring transaction targets: %w", err)
+		}
+		defer stmt.Close()
+		for i, target := range rt.TargetIDs {
+			if _, err := stmt.ExecContext(ctx, rt.ID, rt.ProjectID, target, rt.Weight(i)); err != nil {
+				return fmt.Errorf("insert target: %w", err)
+			}
+		}
+		return nil
+	}
+
+	return mapError(withTransaction(ctx, c.conn.DB, addFunc))
+}
+
+// GetRecurringTransactions returns the recurring transactions of the project ordered by creation.
+func (c *Client) GetRecurringTransactions(ctx context.Context, projectID uuid.UUID) ([]RecurringTransaction, error) {
+	sqlQuery := `SELECT ` + recurringTransactionColumns + `
+	FROM recurring_transactions as rt
+	WHERE rt.project_id=$1
+	ORDER BY rt.created_at, rt.id
+	`
+	return c.selectRecurringTransactions(ctx, sqlQuery, projectID)
+}
+
+// GetDueRecurringTransactions returns the recurring transactions with an occurrence on or before until
+// that wasn't added yet. Archived projects are skipped, they can't get new transactions.
+func (c *Client) GetDueRecurringTransactions(ctx context.Context, until time.Time) ([]RecurringTransaction, error) {
+	sqlQuery := `SELECT ` + recurringTransactionColumns + `
+	FROM recurring_transactions as rt
+	JOIN projects as p
+	ON p.id=rt.project_id
+	WHERE rt.next_occurrence <= $1 AND p.archived_at IS NULL
+	ORDER BY rt.next_occurrence, rt.id
+	`
+	return c.selectRecurringTransactions(ctx, sqlQuery, until)
+}
+
+func (c *Client) selectRecurringTransactions(ctx context.Context, sqlQuery string, args ...any) ([]RecurringTransaction, error) {
+	recurring := []RecurringTransaction{}
+	err := sqlscan.Select(ctx, c.conn.DB, &recurring, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select recurring transactions: %w", err)
+	}
+	if len(recurring) == 0 {
+		return recurring, nil
+	}
+
+	ids := make([]string, 0, len(recurring))
+	for _, rt := range recurring {
+		ids = append(ids, rt.ID.String())
+	}
+	const targetsQuery = `
+	SELECT recurring_transaction_id, user_id, weight
+	FROM recurring_transaction_targets
+	WHERE recurring_transaction_id = ANY($1::uuid[])
+	ORDER BY user_id
+	`
+	var targets []struct {
+		RecurringTransactionID uuid.UUID
+		UserID                 string
+		Weight                 int
+	}
+	err = sqlscan.Select(ctx, c.conn.DB, &targets, targetsQuery, ids)
+	if err != nil {
+		return nil, fmt.Errorf("select recurring transaction targets: %w", err)
+	}
+	for i := range recurring {
+		recurring[i].TargetIDs = []string{}
+		recurring[i].Weights = []int{}
+		for _, target := range targets {
+			if target.RecurringTransactionID == recurring[i].ID {
+				recurring[i].TargetIDs = append(recurring[i].TargetIDs, target.UserID)
+				recurring[i].Weights = append(recurring[i].Weights, target.Weight)
+			}
+		}
+	}
+	return recurring, nil
+}
+
+// SetNextOccurrence stores up to which day the recurring transaction was added, returns ErrNotFound if it doesn't exist.
+func (c *Client) SetNextOccurrence(ctx context.Context, id uuid.UUID, next sql.NullTime) error {
+	const sqlQuery = `UPDATE recurring_transactions SET next_occurrence=$2 WHERE id=$1`
+	res, err := c.conn.DB.ExecContext(ctx, sqlQuery, id, next)
+	if err != nil {
+		return fmt.Errorf("update next occurrence: %w", err)
+	}
+	return expectAffectedRows(res)
+}
+
+// DeleteRecurringTransaction returns ErrNotFound if the project has no recurring transaction with the id.
+// Transactions that were already added are kept.
+func (c *Client) DeleteRecurringTransaction(ctx context.Context, projectID, id uuid.UUID) error {
+	const sqlQuery = `DELETE FROM recurring_transactions WHERE id=$1 AND project_id=$2`
+	res, err := c.conn.DB.ExecContext(ctx, sqlQuery, id, projectID)
+	if err != nil {
+		return fmt.Errorf("delete recurring transaction: %w", err)
+	}
+	return expectAffectedRows(res)
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/georgysavva/scany/v2/sqlscan"
+	"github.com/google/uuid"
+)
+
+// MembershipViolation is a source or target of a transaction that is not a member of the transaction's project.
+// They can exist from before the membership foreign keys were added.
+type MembershipViolation struct {
+	ProjectID     uuid.UUID `db:"project_id"`
+	TransactionID uuid.UUID `db:"transaction_id"`
+	UserID        string    `db:"user_id"`
+	// Role is either source or target
+	Role string `db:"role"`
+}
+
+const membershipViolationsQuery = `
+	SELECT t.project_id, t.id as transaction_id, t.source_id as user_id, 'source' as role
+	FROM transactions as t
+	LEFT JOIN project_memberships as pm
+	ON pm.project_id=t.project_id AND pm.user_id=t.source_id
+	WHERE pm.user_id IS NULL
+	UNION ALL
+	SELECT tt.project_id, tt.transaction_id, tt.user_id, 'target' as role
+	FROM transaction_targets as tt
+	LEFT JOIN project_memberships as pm
+	ON pm.project_id=tt.project_id AND pm.user_id=tt.user_id
+	WHERE pm.user_id IS NULL
+	ORDER BY project_id, transaction_id, role, user_id
+	`
+
+// GetMembershipViolations returns all sources and targets of transactions that are not members of the project.
+func (c *Client) GetMembershipViolations(ctx context.Context) ([]MembershipViolation, error) {
+	var violations []MembershipViolation
+	err := sqlscan.Select(ctx, c.conn.DB, &violations, membershipViolationsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("select membership violations: %w", err)
+	}
+	return violations, nil
+}
+
+// RepairMembershipViolations adds the users involved in transactions of the given projects as former members,
+// so the transactions are valid again without giving the users access to the projects.
+// The membership foreign keys are validated once no violations are left in any project.
+// It returns the number of added memberships and whether the foreign keys were validated.
+func (c *Client) RepairMembershipViolations(ctx context.Context, projectIDs []uuid.UUID) (int, bool, error) {
+	var added int64
+	var validated bool
+	repairFunc := func(ctx context.Context, tx *sql.Tx) error {
+		sqlQuery := `
+		INSERT INTO project_memberships (project_id,user_id,left_at)
+		SELECT DISTINCT project_id, user_id, now() FROM (` + membershipViolationsQuery + `) as violations
+		WHERE project_id = ANY($1::uuid[])
+		ON CONFLICT DO NOTHING
+		`
+		ids := make([]string, 0, len(projectIDs))
+		for _, id := range projectIDs {
+			ids = append(ids, id.String())
+		}
+		res, err := tx.ExecContext(ctx, sqlQuery, ids)
+		if err != nil {
+			return fmt.Errorf("insert missing memberships: %w", err)
+		}
+		added, err = res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get affected rows: %w", err)
+		}
+
+		var remaining int
+		err = tx.QueryRowContext(ctx, `SELECT count(*) FROM (`+membershipViolationsQuery+`) as violations`).Scan(&remaining)
+		if err != nil {
+			return fmt.Errorf("count remaining violations: %w", err)
+		}
+		if remaining > 0 {
+			return nil
+		}
+		for _, validateQuery := range []string{
+			`ALTER TABLE transactions VALIDATE CONSTRAINT fk_source_membership`,
+			`ALTER TABLE transaction_targets VALIDATE CONSTRAINT fk_target_membership`,
+		} {
+			if _, err := tx.ExecContext(ctx, validateQuery); err != nil {
+				return fmt.Errorf("validate constraint: %w", err)
+			}
+		}
+		validated = true
+		return nil
+	}
+
+	err := withTransaction(ctx, c.conn.DB, repairFunc)
+	if err != nil {
+		return 0, false, fmt.Errorf("execute repair transaction: %w", err)
+	}
+	return int(added), validated, nil
+}
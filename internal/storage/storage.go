@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/diezfx/split-app-backend/pkg/logger"
 	"github.com/diezfx/split-app-backend/pkg/postgres"
@@ -32,14 +33,16 @@ func New(ctx context.Context, sqlConn *postgres.DB) (*Client, error) {
 
 func (c *Client) GetProjectByID(ctx context.Context, id uuid.UUID) (Project, error) {
 	sqlQuery := `
-	SELECT p.id as project_id, p.name as project_name,
-		t.id as transaction_id, t.name as transaction_name,t.amount,t.source_id,t.transaction_type,tt.user_id as target_id
+	SELECT p.id as project_id, p.name as project_name, p.currency as project_currency, p.archived_at as project_archived_at,
+		t.id as transaction_id, t.name as transaction_name,t.amount,t.currency,t.source_id,t.transaction_type,t.split_mode,
+		t.occurred_at, t.created_at, t.updated_at, t.created_by, tt.user_id as target_id, tt.weight
 	FROM projects as p
 	LEFT JOIN transactions as t
 	ON p.id=t.project_id 
 	LEFT JOIN transaction_targets as tt
 	ON t.id=tt.transaction_id
 	where p.id=$1
+	ORDER BY t.id, tt.position
 	`
 	var projectQueryElements []projectQueryElement
 
@@ -51,116 +54,501 @@ func (c *Client) GetProjectByID(ctx context.Context, id uuid.UUID) (Project, err
 	if len(projects) == 0 {
 		return Project{}, ErrNotFound
 	}
+	err = c.addMembers(ctx, projects)
+	if err != nil {
+		return Project{}, err
+	}
 	return projects[0], nil
 }
 
-func (c *Client) GetProjects(ctx context.Context) ([]Project, error) {
+// GetProjectHeader returns the project with its members but without loading its transactions.
+func (c *Client) GetProjectHeader(ctx context.Context, id uuid.UUID) (Project, error) {
 	sqlQuery := `
-	SELECT p.id as project_id, p.name as project_name,
-		t.id as transaction_id, t.name as transaction_name,t.amount,t.source_id,t.transaction_type,tt.user_id as target_id
+	SELECT p.id as project_id, p.name as project_name, p.currency as project_currency, p.archived_at as project_archived_at
 	FROM projects as p
+	where p.id=$1
+	`
+	var projectQueryElements []projectQueryElement
+
+	err := sqlscan.Select(ctx, c.conn.DB, &projectQueryElements, sqlQuery, id)
+	if err != nil {
+		return Project{}, fmt.Errorf("select project: %w", err)
+	}
+	projects := mergeProject(projectQueryElements)
+	if len(projects) == 0 {
+		return Project{}, ErrNotFound
+	}
+	err = c.addMembers(ctx, projects)
+	if err != nil {
+		return Project{}, err
+	}
+	return projects[0], nil
+}
+
+// GetProjects returns one page of the projects matching the filter ordered by id.
+// The page is selected first, so only the transactions of these projects are joined.
+func (c *Client) GetProjects(ctx context.Context, filter ProjectFilter) ([]Project, error) {
+	sqlQuery := `
+	WITH page AS (
+		SELECT p.id
+		FROM projects as p
+		WHERE p.id IN (SELECT project_id FROM project_memberships WHERE user_id=$1 AND left_at IS NULL)
+		AND ($2='' OR p.id IN (SELECT project_id FROM project_memberships WHERE user_id=$2 AND left_at IS NULL))
+		AND ($3='' OR strpos(lower(p.name), lower($3)) > 0)
+		AND ($4::uuid IS NULL OR p.id > $4)
+		AND ($6 OR p.archived_at IS NULL)
+		ORDER BY p.id
+		LIMIT $5
+	)
+	SELECT p.id as project_id, p.name as project_name, p.currency as project_currency, p.archived_at as project_archived_at,
+		t.id as transaction_id, t.name as transaction_name,t.amount,t.currency,t.source_id,t.transaction_type,t.split_mode,
+		t.occurred_at, t.created_at, t.updated_at, t.created_by, tt.user_id as target_id, tt.weight
+	FROM page
+	JOIN projects as p
+	ON p.id=page.id
 	LEFT JOIN transactions as t
 	ON p.id=t.project_id
 	LEFT JOIN transaction_targets as tt
 	ON t.id=tt.transaction_id
-	ORDER BY p.id
+	ORDER BY p.id, t.id, tt.position
 	`
 	var projectQueryElements []projectQueryElement
 
-	err := sqlscan.Select(ctx, c.conn.DB, &projectQueryElements, sqlQuery)
+	err := sqlscan.Select(ctx, c.conn.DB, &projectQueryElements, sqlQuery,
+		filter.UserID, filter.Member, filter.Name, filter.After, filter.Limit, filter.IncludeArchived)
 	if err != nil {
 		return nil, fmt.Errorf("select queryElements: %w", err)
 	}
 	projects := mergeProject(projectQueryElements)
+	err = c.addMembers(ctx, projects)
+	if err != nil {
+		return nil, err
+	}
 	return projects, nil
 }
 
-func (c *Client) AddProjectUser(ctx context.Context, projectID uuid.UUID, userID string) error {
+// addMembers loads the members of all given projects.
+func (c *Client) addMembers(ctx context.Context, projects []Project) error {
+	if len(projects) == 0 {
+		return nil
+	}
+	projectIDs := make([]string, 0, len(projects))
+	for _, p := range projects {
+		projectIDs = append(projectIDs, p.ID.String())
+	}
+
+	sqlQuery := `
+	SELECT project_id, user_id, role
+	FROM project_memberships
+	WHERE project_id = ANY($1::uuid[]) AND left_at IS NULL
+	ORDER BY user_id
+	`
+	var memberships []struct {
+		ProjectID uuid.UUID
+		UserID    string
+		Role      string
+	}
+	err := sqlscan.Select(ctx, c.conn.DB, &memberships, sqlQuery, projectIDs)
+	if err != nil {
+		return fmt.Errorf("select members: %w", err)
+	}
+
+	for i := range projects {
+		projects[i].Members = []string{}
+		projects[i].Roles = map[string]string{}
+		for _, m := range memberships {
+			if m.ProjectID == projects[i].ID {
+				projects[i].Members = append(projects[i].Members, m.UserID)
+				projects[i].Roles[m.UserID] = m.Role
+			}
+		}
+	}
+	return nil
+}
+
+// AddProjectUser adds the user with the role to the project, an empty role is the DefaultRole.
+func (c *Client) AddProjectUser(ctx context.Context, projectID uuid.UUID, userID, role string) error {
 	err := withTransaction(ctx, c.conn.DB, func(ctx context.Context, tx *sql.Tx) error {
-		return addUsers(ctx, tx, projectID, []string{userID})
+		return addUsers(ctx, tx, projectID, []string{userID}, map[string]string{userID: role})
 	})
 	if err != nil {
-		return fmt.Errorf("addUser: %w", err)
+		return fmt.Errorf("addUser: %w", mapError(err))
 	}
 	return nil
 }
 
-func addUsers(ctx context.Context, tx *sql.Tx, projectID uuid.UUID, userIDs []string) error {
-	sqlUserInsert := `insert into project_memberships (project_id,user_id)
-	values($1,$2)
+// addUsers adds the users as members of the project with their role from roles,
+// members that left the project before rejoin it with the new role.
+// ErrAlreadyExists is returned if a user is already a member.
+func addUsers(ctx context.Context, tx *sql.Tx, projectID uuid.UUID, userIDs []string, roles map[string]string) error {
+	sqlUserInsert := `insert into project_memberships (project_id,user_id,role)
+	values($1,$2,$3)
+	on conflict (project_id,user_id) do update set left_at=null, role=excluded.role
+	where project_memberships.left_at is not null
 	`
 	stmt, err := tx.PrepareContext(ctx, sqlUserInsert)
 	if err != nil {
 		return fmt.Errorf("prepare add project users: %w", err)
 	}
 	for _, user := range userIDs {
-		_, err := stmt.ExecContext(ctx, projectID, user)
+		res, err := stmt.ExecContext(ctx, projectID, user, RoleOrDefault(roles[user]))
 		if err != nil {
 			return fmt.Errorf("insert user: %w", err)
 		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("get affected rows: %w", err)
+		}
+		if affected == 0 {
+			return fmt.Errorf("member %s: %w", user, ErrAlreadyExists)
+		}
+	}
+	return nil
+}
+
+// UpdateProjectUserRole changes the role of the member, returns ErrNotFound if the user is no member.
+func (c *Client) UpdateProjectUserRole(ctx context.Context, projectID uuid.UUID, userID, role string) error {
+	updateFunc := func(ctx context.Context, tx *sql.Tx) error {
+		if role != OwnerRole {
+			if err := checkOtherOwner(ctx, tx, projectID, userID); err != nil {
+				return err
+			}
+		}
+		const sqlQuery = `
+		UPDATE project_memberships SET role=$3
+		WHERE project_id=$1 AND user_id=$2 AND left_at IS NULL
+		`
+		res, err := tx.ExecContext(ctx, sqlQuery, projectID, userID, role)
+		if err != nil {
+			return fmt.Errorf("update role: %w", err)
+		}
+		return expectAffectedRows(res)
+	}
+
+	return mapError(withTransaction(ctx, c.conn.DB, updateFunc))
+}
+
+// RemoveProjectUser adds the transactions and marks the user as having left the project in one database transaction.
+// The membership is kept, so the transactions of the user still reference it. Returns ErrNotFound if the user is no member.
+func (c *Client) RemoveProjectUser(ctx context.Context, projectID uuid.UUID, userID string, transactions []Transaction) error {
+	removeFunc := func(ctx context.Context, tx *sql.Tx) error {
+		if err := checkOtherOwner(ctx, tx, projectID, userID); err != nil {
+			return err
+		}
+		for i := range transactions {
+			if err := addTransaction(ctx, tx, projectID, &transactions[i]); err != nil {
+				return err
+			}
+		}
+		const sqlQuery = `
+		UPDATE project_memberships SET left_at=now()
+		WHERE project_id=$1 AND user_id=$2 AND left_at IS NULL
+		`
+		res, err := tx.ExecContext(ctx, sqlQuery, projectID, userID)
+		if err != nil {
+			return fmt.Errorf("update membership: %w", err)
+		}
+		return expectAffectedRows(res)
+	}
+
+	return mapError(withTransaction(ctx, c.conn.DB, removeFunc))
+}
+
+// checkOtherOwner returns ErrLastOwner if the user is the only owner of the project.
+// The project is locked until the end of the transaction, so concurrent changes can't remove the other owners in between.
+func checkOtherOwner(ctx context.Context, tx *sql.Tx, projectID uuid.UUID, userID string) error {
+	const lockQuery = `SELECT id FROM projects WHERE id=$1 FOR UPDATE`
+	var id uuid.UUID
+	err := tx.QueryRowContext(ctx, lockQuery, projectID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("project %s: %w", projectID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("lock project: %w", err)
+	}
+
+	const ownersQuery = `SELECT user_id FROM project_memberships WHERE project_id=$1 AND role=$2 AND left_at IS NULL`
+	var owners []string
+	err = sqlscan.Select(ctx, tx, &owners, ownersQuery, projectID, OwnerRole)
+	if err != nil {
+		return fmt.Errorf("select owners: %w", err)
+	}
+	if len(owners) == 1 && owners[0] == userID {
+		return ErrLastOwner
 	}
 	return nil
 }
 
 func (c *Client) AddProject(ctx context.Context, proj Project) (Project, error) {
 	addProjectFunc := func(ctx context.Context, tx *sql.Tx) error {
-		sqlQuery := `insert into projects (id,name)
-		values($1,$2)
+		sqlQuery := `insert into projects (id,name,currency)
+		values($1,$2,$3)
 		`
-		_, err := tx.ExecContext(ctx, sqlQuery, proj.ID, proj.Name)
+		_, err := tx.ExecContext(ctx, sqlQuery, proj.ID, proj.Name, proj.Currency)
 		if err != nil {
 			return fmt.Errorf("insert project: %w", err)
 		}
-		return addUsers(ctx, tx, proj.ID, proj.Members)
+		return addUsers(ctx, tx, proj.ID, proj.Members, proj.Roles)
 	}
 
 	err := withTransaction(ctx, c.conn.DB, addProjectFunc)
 	if err != nil {
-		return proj, fmt.Errorf("execute add project transaction: %w", err)
+		return proj, fmt.Errorf("execute add project transaction: %w", mapError(err))
 	}
 
 	return proj, nil
 }
 
+// UpdateProject changes the name and the archive state of the project, returns ErrNotFound if it doesn't exist.
+func (c *Client) UpdateProject(ctx context.Context, proj Project) error {
+	const sqlQuery = `UPDATE projects SET name=$2, archived_at=$3 WHERE id=$1`
+	res, err := c.conn.DB.ExecContext(ctx, sqlQuery, proj.ID, proj.Name, proj.ArchivedAt)
+	if err != nil {
+		return fmt.Errorf("update project: %w", err)
+	}
+	return expectAffectedRows(res)
+}
+
+// DeleteProject removes the project with its memberships and transactions, returns ErrNotFound if it doesn't exist.
+func (c *Client) DeleteProject(ctx context.Context, id uuid.UUID) error {
+	const sqlQuery = `DELETE FROM projects WHERE id=$1`
+	res, err := c.conn.DB.ExecContext(ctx, sqlQuery, id)
+	if err != nil {
+		return fmt.Errorf("delete project: %w", err)
+	}
+	return expectAffectedRows(res)
+}
+
 func (c *Client) AddTransaction(ctx context.Context, projectID uuid.UUID, transaction Transaction) error {
-	addTransactionFunc := func(ctx context.Context, tx *sql.Tx) error {
+	return c.AddTransactions(ctx, projectID, []Transaction{transaction})
+}
+
+// AddTransactions adds all transactions in one database transaction, either all of them are stored or none.
+func (c *Client) AddTransactions(ctx context.Context, projectID uuid.UUID, transactions []Transaction) error {
+	addTransactionsFunc := func(ctx context.Context, tx *sql.Tx) error {
+		for i := range transactions {
+			if err := addTransaction(ctx, tx, projectID, &transactions[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return mapError(withTransaction(ctx, c.conn.DB, addTransactionsFunc))
+}
+
+// ImportTransactions adds the new members to the project and then the transactions in one database transaction.
+// Members that don't exist yet are created.
+func (c *Client) ImportTransactions(ctx context.Context, projectID uuid.UUID, newMembers []string, transactions []Transaction) error {
+	importFunc := func(ctx context.Context, tx *sql.Tx) error {
+		sqlQuery := `INSERT INTO members (id) VALUES ($1) ON CONFLICT DO NOTHING`
+		for _, member := range newMembers {
+			if _, err := tx.ExecContext(ctx, sqlQuery, member); err != nil {
+				return fmt.Errorf("insert member: %w", err)
+			}
+		}
+		if err := addUsers(ctx, tx, projectID, newMembers, nil); err != nil {
+			return err
+		}
+		for i := range transactions {
+			if err := addTransaction(ctx, tx, projectID, &transactions[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := withTransaction(ctx, c.conn.DB, importFunc)
+	if err != nil {
+		return fmt.Errorf("execute import transaction: %w", mapError(err))
+	}
+	return nil
+}
+
+func addTransaction(ctx context.Context, tx *sql.Tx, projectID uuid.UUID, transaction *Transaction) error {
+	const sqlQuery = `
+	INSERT INTO transactions (id,name,amount,currency,source_id,transaction_type,split_mode,project_id,occurred_at,created_by)
+	VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+	`
+	_, err := tx.ExecContext(ctx, sqlQuery,
+		transaction.ID, transaction.Name, transaction.Amount, transaction.Currency, transaction.SourceID,
+		transaction.TransactionType, transaction.SplitMode, projectID, transaction.OccurredAt, transaction.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("insert transaction: %w", err)
+	}
+
+	return addTransactionTargets(ctx, tx, projectID, transaction)
+}
+
+func addTransactionTargets(ctx context.Context, tx *sql.Tx, projectID uuid.UUID, transaction *Transaction) error {
+	const insertTransactionTargetsQuery = `
+	INSERT INTO transaction_targets (transaction_id,project_id,user_id,weight,position)
+	VALUES($1,$2,$3,$4,$5)`
+
+	stmt, err := tx.PrepareContext(ctx, insertTransactionTargetsQuery)
+	if err != nil {
+		return fmt.Errorf("prepare add transaction targets: %w", err)
+	}
+	defer stmt.Close()
+	for i, target := range transaction.TargetIDs {
+		_, err := stmt.ExecContext(ctx, transaction.ID, projectID, target, transaction.Weight(i), i)
+		if err != nil {
+			return fmt.Errorf("insert target: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpdateTransaction replaces the transaction and all of its targets, returns ErrNotFound
+// if there is no transaction with this id in the project.
+func (c *Client) UpdateTransaction(ctx context.Context, projectID uuid.UUID, transaction Transaction) error {
+	updateTransactionFunc := func(ctx context.Context, tx *sql.Tx) error {
 		const sqlQuery = `
-		INSERT INTO transactions (id,name,amount,source_id,transaction_type,project_id)
-		VALUES($1,$2,$3,$4,$5,$6)
+		UPDATE transactions
+		SET name=$3, amount=$4, currency=$5, source_id=$6, transaction_type=$7, split_mode=$8, occurred_at=$9,
+			updated_at=now()
+		WHERE id=$1 AND project_id=$2
 		`
-		_, err := tx.ExecContext(ctx, sqlQuery,
-			transaction.ID, transaction.Name, transaction.Amount, transaction.SourceID, transaction.TransactionType, projectID)
+		res, err := tx.ExecContext(ctx, sqlQuery, transaction.ID, projectID,
+			transaction.Name, transaction.Amount, transaction.Currency, transaction.SourceID,
+			transaction.TransactionType, transaction.SplitMode, transaction.OccurredAt)
 		if err != nil {
-			return fmt.Errorf("insert project: %w", err)
+			return fmt.Errorf("update transaction: %w", err)
+		}
+		if err := expectAffectedRows(res); err != nil {
+			return err
 		}
 
-		const insertTransactionTargetsQuery = `
-		INSERT INTO transaction_targets (transaction_id,user_id)
-		VALUES($1,$2)`
+		const deleteTargetsQuery = `DELETE FROM transaction_targets WHERE transaction_id=$1`
+		_, err = tx.ExecContext(ctx, deleteTargetsQuery, transaction.ID)
+		if err != nil {
+			return fmt.Errorf("delete transaction targets: %w", err)
+		}
+		return addTransactionTargets(ctx, tx, projectID, &transaction)
+	}
+
+	return mapError(withTransaction(ctx, c.conn.DB, updateTransactionFunc))
+}
 
-		stmt, err := tx.Prepare(insertTransactionTargetsQuery)
+// DeleteTransaction removes the transaction and all of its targets, returns ErrNotFound
+// if there is no transaction with this id in the project.
+func (c *Client) DeleteTransaction(ctx context.Context, projectID, transactionID uuid.UUID) error {
+	deleteTransactionFunc := func(ctx context.Context, tx *sql.Tx) error {
+		const deleteTargetsQuery = `
+		DELETE FROM transaction_targets as tt
+		USING transactions as t
+		WHERE tt.transaction_id=t.id AND t.id=$1 AND t.project_id=$2
+		`
+		_, err := tx.ExecContext(ctx, deleteTargetsQuery, transactionID, projectID)
 		if err != nil {
-			return fmt.Errorf("prepare add project users: %w", err)
+			return fmt.Errorf("delete transaction targets: %w", err)
 		}
-		for _, target := range transaction.TargetIDs {
-			_, err := stmt.ExecContext(ctx, transaction.ID, target)
-			if err != nil {
-				return fmt.Errorf("insert target: %w", err)
-			}
+
+		const deleteTransactionQuery = `DELETE FROM transactions WHERE id=$1 AND project_id=$2`
+		res, err := tx.ExecContext(ctx, deleteTransactionQuery, transactionID, projectID)
+		if err != nil {
+			return fmt.Errorf("delete transaction: %w", err)
 		}
-		return nil
+		return expectAffectedRows(res)
 	}
 
-	return withTransaction(ctx, c.conn.DB, addTransactionFunc)
+	return withTransaction(ctx, c.conn.DB, deleteTransactionFunc)
+}
+
+// GetTransactions returns one page of the transactions matching the filter.
+// Transactions with the same sort value are ordered by id, so the cursor is unambiguous.
+func (c *Client) GetTransactions(ctx context.Context, filter TransactionFilter) ([]Transaction, error) {
+	sortColumn := "t.occurred_at"
+	if filter.SortBy == SortByAmount {
+		sortColumn = "t.amount"
+	}
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	args := []any{filter.ProjectID}
+	conditions := []string{"t.project_id=$1"}
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.ID.Valid {
+		addCondition("t.id=$%d", filter.ID.UUID)
+	}
+	if filter.TransactionType != "" {
+		addCondition("t.transaction_type=$%d", filter.TransactionType)
+	}
+	if filter.SourceID != "" {
+		addCondition("t.source_id=$%d", filter.SourceID)
+	}
+	if filter.TargetID != "" {
+		addCondition("t.id IN (SELECT transaction_id FROM transaction_targets WHERE user_id=$%d)", filter.TargetID)
+	}
+	if filter.Currency != "" {
+		addCondition("t.currency=$%d", filter.Currency)
+	}
+	if filter.MinAmount.Valid {
+		addCondition("t.amount>=$%d", filter.MinAmount.Int64)
+	}
+	if filter.MaxAmount.Valid {
+		addCondition("t.amount<=$%d", filter.MaxAmount.Int64)
+	}
+	if filter.From.Valid {
+		addCondition("t.occurred_at>=$%d", filter.From.Time)
+	}
+	if filter.To.Valid {
+		addCondition("t.occurred_at<$%d", filter.To.Time)
+	}
+	if filter.After != nil {
+		var afterValue any = filter.After.OccurredAt
+		if filter.SortBy == SortByAmount {
+			afterValue = filter.After.Amount
+		}
+		args = append(args, afterValue, filter.After.ID)
+		conditions = append(conditions,
+			fmt.Sprintf("(%s, t.id) %s ($%d, $%d)", sortColumn, comparison, len(args)-1, len(args)))
+	}
+	args = append(args, filter.Limit)
+
+	// only whitelisted columns and directions are formatted into the query
+	//nolint:gosec
+	sqlQuery := fmt.Sprintf(`
+	WITH page AS (
+		SELECT t.id
+		FROM transactions as t
+		WHERE %[1]s
+		ORDER BY %[2]s %[3]s, t.id %[3]s
+		LIMIT $%[4]d
+	)
+	SELECT t.id, t.name, t.source_id,tt.user_id as target_id,tt.weight, t.transaction_type,t.split_mode,t.project_id,
+		t.amount,t.currency,t.occurred_at,t.created_at,t.updated_at,t.created_by
+	FROM page
+	JOIN transactions as t
+	ON t.id=page.id
+	LEFT JOIN transaction_targets as tt
+	ON t.id=tt.transaction_id
+	ORDER BY %[2]s %[3]s, t.id %[3]s, tt.position
+	`, strings.Join(conditions, " AND "), sortColumn, direction, len(args))
+
+	var transactionElements []transactionQueryElement
+	err := sqlscan.Select(ctx, c.conn.DB, &transactionElements, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select transactions: %w", err)
+	}
+
+	return mergeTransactionElements(transactionElements), nil
 }
 
 func (c *Client) GetAllOutgoingTransactionsByUserID(ctx context.Context, userID string) ([]Transaction, error) {
 	sqlQuery := `
-	SELECT t.id, t.name, t.source_id,tt.user_id as target_id, t.transaction_type,t.project_id,t.amount
+	SELECT t.id, t.name, t.source_id,tt.user_id as target_id,tt.weight, t.transaction_type,t.split_mode,t.project_id,t.amount,t.currency,
+		t.occurred_at,t.created_at,t.updated_at,t.created_by
 	FROM transactions as t
 	LEFT JOIN transaction_targets as tt
 	ON t.id=tt.transaction_id 
 	WHERE source_id=$1
+	ORDER BY t.id, tt.position
 	`
 	var transactionElements []transactionQueryElement
 	err := sqlscan.Select(ctx, c.conn.DB, &transactionElements, sqlQuery, userID)
@@ -176,11 +564,13 @@ func (c *Client) GetAllOutgoingTransactionsByUserID(ctx context.Context, userID
 
 func (c *Client) GetAllIncomingTransactionsByUserID(ctx context.Context, userID string) ([]Transaction, error) {
 	sqlQuery := `
-	SELECT t.id, t.name, t.source_id,tt.user_id as target_id, t.transaction_type,t.project_id,t.amount
+	SELECT t.id, t.name, t.source_id,tt.user_id as target_id,tt.weight, t.transaction_type,t.split_mode,t.project_id,t.amount,t.currency,
+		t.occurred_at,t.created_at,t.updated_at,t.created_by
 	FROM transactions as t
 	JOIN transaction_targets as tt
-	ON t.id=tt.transaction_id 
-	WHERE tt.user_id=$1
+	ON t.id=tt.transaction_id
+	WHERE t.id IN (SELECT transaction_id FROM transaction_targets WHERE user_id=$1)
+	ORDER BY t.id, tt.position
 	`
 	var transactionElements []transactionQueryElement
 	err := sqlscan.Select(ctx, c.conn.DB, &transactionElements, sqlQuery, userID)
@@ -193,13 +583,16 @@ func (c *Client) GetAllIncomingTransactionsByUserID(ctx context.Context, userID
 	return transactions, nil
 }
 
-func (c *Client) GetProjectUsers(ctx context.Context, projectID uuid.UUID) ([]User, error) {
+func (c *Client) GetProjectUsers(ctx context.Context, projectID uuid.UUID) ([]ProjectUser, error) {
 	sqlQuery := `
-	SELECT user_id as id
-	FROM project_memberships
-	WHERE project_id=$1
+	SELECT m.id, m.display_name, m.email, m.avatar_url, m.preferred_currency, m.guest_project_id, pm.role
+	FROM project_memberships as pm
+	JOIN members as m
+	ON m.id=pm.user_id
+	WHERE pm.project_id=$1 AND pm.left_at IS NULL
+	ORDER BY m.id
 	`
-	var users []User
+	var users []ProjectUser
 	err := sqlscan.Select(ctx, c.conn.DB, &users, sqlQuery, projectID)
 	if err != nil {
 		return nil, fmt.Errorf("select users: %w", err)
@@ -208,9 +601,25 @@ func (c *Client) GetProjectUsers(ctx context.Context, projectID uuid.UUID) ([]Us
 	return users, nil
 }
 
+// GetProjectRole returns the role of the user in the project, ErrNotFound if the user is no member.
+func (c *Client) GetProjectRole(ctx context.Context, projectID uuid.UUID, userID string) (string, error) {
+	sqlQuery := `
+	SELECT role FROM project_memberships WHERE project_id=$1 AND user_id=$2 AND left_at IS NULL
+	`
+	var role string
+	err := c.conn.DB.QueryRowContext(ctx, sqlQuery, projectID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("select membership: %w", err)
+	}
+	return role, nil
+}
+
 func (c *Client) GetUser(ctx context.Context, userID string) (User, error) {
 	sqlQuery := `
-	SELECT id
+	SELECT id, display_name, email, avatar_url, preferred_currency, guest_project_id
 	FROM members
 	WHERE id=$1
 	`
@@ -228,7 +637,7 @@ func (c *Client) GetUser(ctx context.Context, userID string) (User, error) {
 
 func (c *Client) GetUsers(ctx context.Context) ([]User, error) {
 	sqlQuery := `
-	SELECT id
+	SELECT id, display_name, email, avatar_url, preferred_currency, guest_project_id
 	FROM members
 	`
 	var users []User
@@ -240,19 +649,90 @@ func (c *Client) GetUsers(ctx context.Context) ([]User, error) {
 	return users, nil
 }
 
+// GetUsersByIDs returns the users with the given ids ordered by id, unknown ids are skipped.
+func (c *Client) GetUsersByIDs(ctx context.Context, userIDs []string) ([]User, error) {
+	sqlQuery := `
+	SELECT id, display_name, email, avatar_url, preferred_currency, guest_project_id
+	FROM members
+	WHERE id = ANY($1::text[])
+	ORDER BY id
+	`
+	var users []User
+	err := sqlscan.Select(ctx, c.conn.DB, &users, sqlQuery, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("select users: %w", err)
+	}
+
+	return users, nil
+}
+
 func (c *Client) AddUser(ctx context.Context, user User) error {
 	sqlQuery := `
-	INSERT INTO members (id)
-	VALUES ($1)
+	INSERT INTO members (id,display_name,email,avatar_url,preferred_currency)
+	VALUES ($1,$2,$3,$4,$5)
 	`
-	_, err := c.conn.DB.ExecContext(ctx, sqlQuery, user.ID)
+	_, err := c.conn.DB.ExecContext(ctx, sqlQuery, user.ID, user.DisplayName, user.Email, user.AvatarURL, user.PreferredCurrency)
 	if err != nil {
-		return fmt.Errorf("insert member: %w", err)
+		return fmt.Errorf("insert member: %w", mapError(err))
 	}
 
 	return nil
 }
 
+// UpdateUser replaces the profile of the user, the user is created if it doesn't exist yet.
+func (c *Client) UpdateUser(ctx context.Context, user User) error {
+	sqlQuery := `
+	INSERT INTO members (id,display_name,email,avatar_url,preferred_currency)
+	VALUES ($1,$2,$3,$4,$5)
+	ON CONFLICT (id) DO UPDATE
+	SET display_name=excluded.display_name, email=excluded.email, avatar_url=excluded.avatar_url,
+		preferred_currency=excluded.preferred_currency
+	`
+	_, err := c.conn.DB.ExecContext(ctx, sqlQuery, user.ID, user.DisplayName, user.Email, user.AvatarURL, user.PreferredCurrency)
+	if err != nil {
+		return fmt.Errorf("upsert member: %w", mapError(err))
+	}
+
+	return nil
+}
+
+// GetExchangeRate returns how many units of to you get for one unit of from.
+// If only the inverse rate is stored it is used instead.
+func (c *Client) GetExchangeRate(ctx context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	sqlQuery := `
+	SELECT CASE WHEN from_currency=$1 THEN rate ELSE 1/rate END
+	FROM exchange_rates
+	WHERE (from_currency=$1 AND to_currency=$2) OR (from_currency=$2 AND to_currency=$1)
+	ORDER BY from_currency=$1 DESC
+	LIMIT 1
+	`
+	var rate float64
+	err := c.conn.DB.QueryRowContext(ctx, sqlQuery, from, to).Scan(&rate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("select exchange rate: %w", err)
+	}
+	return rate, nil
+}
+
+// expectAffectedRows returns ErrNotFound if the statement did not change any row.
+func expectAffectedRows(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func withTransaction(ctx context.Context, db *sql.DB, fn func(ctx context.Context, tx *sql.Tx) error) error {
 	// Begin a transaction
 	tx, err := db.BeginTx(ctx, &sql.TxOptions{})
@@ -286,10 +766,17 @@ func mergeTransactionElements(transactionElements []transactionQueryElement) []T
 				ID:              te.ID,
 				Name:            te.Name,
 				Amount:          te.Amount,
+				Currency:        te.Currency,
 				TransactionType: te.TransactionType,
 				SourceID:        te.SourceID,
 				ProjectID:       te.ProjectID,
+				SplitMode:       te.SplitMode,
+				OccurredAt:      te.OccurredAt,
+				CreatedAt:       te.CreatedAt,
+				UpdatedAt:       te.UpdatedAt,
+				CreatedBy:       te.CreatedBy,
 				TargetIDs:       []string{},
+				Weights:         []int{},
 			}
 			transactions = append(transactions, transaction)
 			index = len(transactions) - 1
@@ -299,6 +786,7 @@ func mergeTransactionElements(transactionElements []transactionQueryElement) []T
 
 		if te.TargetID != "" {
 			transaction.TargetIDs = append(transaction.TargetIDs, te.TargetID)
+			transaction.Weights = append(transaction.Weights, te.Weight)
 		}
 		transactions[index] = transaction
 	}
@@ -317,7 +805,8 @@ func mergeProject(projectElements []projectQueryElement) []Project {
 		if index == -1 {
 			project = Project{
 				ID:   uuid.MustParse(pe.ProjectID.String),
-				Name: pe.ProjectName.String, Transactions: []Transaction{},
+				Name: pe.ProjectName.String, Currency: pe.ProjectCurrency.String, ArchivedAt: pe.ProjectArchivedAt,
+				Transactions: []Transaction{},
 			}
 			projects = append(projects, project)
 			index = len(projects) - 1
@@ -337,9 +826,17 @@ func mergeProject(projectElements []projectQueryElement) []Project {
 				ID:              uuid.MustParse(pe.TransactionID.String),
 				Name:            pe.TransactionName.String,
 				Amount:          int(pe.Amount.Int64),
+				Currency:        pe.Currency.String,
 				SourceID:        pe.SourceID.String,
 				TargetIDs:       []string{},
 				TransactionType: pe.TransactionType.String,
+				ProjectID:       project.ID,
+				SplitMode:       pe.SplitMode.String,
+				OccurredAt:      pe.OccurredAt.Time,
+				CreatedAt:       pe.CreatedAt.Time,
+				UpdatedAt:       pe.UpdatedAt.Time,
+				CreatedBy:       pe.CreatedBy.String,
+				Weights:         []int{},
 			}
 			project.Transactions = append(project.Transactions, transaction)
 			transIndex = len(project.Transactions) - 1
@@ -349,6 +846,7 @@ func mergeProject(projectElements []projectQueryElement) []Project {
 
 		if pe.TargetID.Valid {
 			transaction.TargetIDs = append(transaction.TargetIDs, pe.TargetID.String)
+			transaction.Weights = append(transaction.Weights, int(pe.Weight.Int64))
 		}
 
 		project.Transactions[transIndex] = transaction
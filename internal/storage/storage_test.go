@@ -0,0 +1,37 @@
+package storage_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/diezfx/split-app-backend/internal/storage/storagetest"
+	"github.com/diezfx/split-app-backend/pkg/postgres"
+)
+
+// TestConformance runs against the postgres given by POSTGRES_TEST_HOST, e.g. the one from docker-compose.yml.
+func TestConformance(t *testing.T) {
+	host := os.Getenv("POSTGRES_TEST_HOST")
+	if host == "" {
+		t.Skip("POSTGRES_TEST_HOST not set")
+	}
+
+	db, err := postgres.New(postgres.Config{
+		Port: 5432, Host: host, Database: "postgres",
+		Username: "postgres", Password: "postgres",
+		MigrationsDir: "../../db/migrations",
+	})
+	if err != nil {
+		t.Fatalf("connect to postgres: %s", err)
+	}
+	client, err := storage.New(context.Background(), db)
+	if err != nil {
+		t.Fatalf("create storage client: %s", err)
+	}
+
+	storagetest.Run(t, func(t *testing.T) service.ProjectStorage {
+		return client
+	})
+}
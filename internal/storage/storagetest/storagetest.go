@@ -0,0 +1,1211 @@
+// Package storagetest contains a conformance test suite every implementation of service.ProjectStorage has to pass.
+package storagetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diezfx/split-app-backend/internal/service"
+	"github.com/diezfx/split-app-backend/internal/storage"
+	"github.com/google/uuid"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// Run executes all conformance tests against the storage returned by newStorage.
+// The tests only use random ids, so the storage may be shared between tests.
+func Run(t *testing.T, newStorage func(t *testing.T) service.ProjectStorage) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, s service.ProjectStorage)
+	}{
+		{"users", testUsers},
+		{"projects", testProjects},
+		{"project members", testProjectMembers},
+		{"remove project member", testRemoveProjectUser},
+		{"update and delete project", testUpdateAndDeleteProject},
+		{"project filter", testProjectFilter},
+		{"add transactions", testAddTransactions},
+		{"add transactions is atomic", testAddTransactionsAtomic},
+		{"import transactions", testImportTransactions},
+		{"update transaction", testUpdateTransaction},
+		{"delete transaction", testDeleteTransaction},
+		{"transactions by user", testTransactionsByUser},
+		{"transaction target order", testTransactionTargetOrder},
+		{"transaction filter", testTransactionFilter},
+		{"idempotency records", testIdempotencyRecords},
+		{"invites", testInvites},
+		{"guests", testGuests},
+		{"recurring transactions", testRecurringTransactions},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, newStorage(t))
+		})
+	}
+}
+
+func testUsers(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	user := storage.User{ID: randomUserID()}
+
+	_, err := s.GetUser(ctx, user.ID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected not found for unknown user got %v", err)
+	}
+
+	mustAddUsers(t, s, user.ID)
+
+	got, err := s.GetUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("get user: %s", err)
+	}
+	if got != user {
+		t.Errorf("expected user %+v got %+v", user, got)
+	}
+
+	err = s.AddUser(ctx, user)
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected already exists for duplicate user got %v", err)
+	}
+
+	users, err := s.GetUsers(ctx)
+	if err != nil {
+		t.Fatalf("get users: %s", err)
+	}
+	if !slices.Contains(users, user) {
+		t.Errorf("expected user %s in %v", user.ID, users)
+	}
+
+	user.DisplayName = "Anna"
+	user.Email = "anna@example.com"
+	user.AvatarURL = "https://example.com/anna.png"
+	user.PreferredCurrency = "USD"
+	err = s.UpdateUser(ctx, user)
+	if err != nil {
+		t.Fatalf("update user: %s", err)
+	}
+	newUser := storage.User{ID: randomUserID(), DisplayName: "Ben"}
+	err = s.UpdateUser(ctx, newUser)
+	if err != nil {
+		t.Fatalf("update unknown user: %s", err)
+	}
+
+	users, err = s.GetUsersByIDs(ctx, []string{newUser.ID, user.ID, randomUserID()})
+	if err != nil {
+		t.Fatalf("get users by ids: %s", err)
+	}
+	expected := []storage.User{user, newUser}
+	slices.SortFunc(expected, func(a, b storage.User) int { return strings.Compare(a.ID, b.ID) })
+	if !slices.Equal(users, expected) {
+		t.Errorf("expected users %+v got %+v", expected, users)
+	}
+}
+
+func testProjects(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+
+	_, err := s.GetProjectByID(ctx, uuid.New())
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected not found for unknown project got %v", err)
+	}
+
+	proj := mustAddProject(t, s, 2)
+
+	got, err := s.GetProjectByID(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get project: %s", err)
+	}
+	if got.ID != proj.ID || got.Name != proj.Name || got.Currency != proj.Currency {
+		t.Errorf("expected project %+v got %+v", proj, got)
+	}
+	if !slices.Equal(got.Members, proj.Members) {
+		t.Errorf("expected members %v got %v", proj.Members, got.Members)
+	}
+	if len(got.Transactions) != 0 {
+		t.Errorf("expected no transactions got %v", got.Transactions)
+	}
+
+	_, err = s.GetProjectHeader(ctx, uuid.New())
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("expected not found for unknown project header got %v", err)
+	}
+	err = s.AddTransaction(ctx, proj.ID, newTransaction(proj.Members[0], proj.Members[1]))
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+	header, err := s.GetProjectHeader(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get project header: %s", err)
+	}
+	if header.ID != proj.ID || header.Name != proj.Name || !slices.Equal(header.Members, proj.Members) {
+		t.Errorf("expected project header %+v got %+v", proj, header)
+	}
+	if len(header.Transactions) != 0 {
+		t.Errorf("expected no transactions in the header got %v", header.Transactions)
+	}
+
+	_, err = s.AddProject(ctx, proj)
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected already exists for duplicate project got %v", err)
+	}
+
+	owner := randomUserID()
+	mustAddUsers(t, s, owner)
+	withRoles := storage.Project{
+		ID: uuid.New(), Name: "project", Currency: "EUR",
+		Members: []string{owner, proj.Members[0]}, Roles: map[string]string{owner: "owner"},
+	}
+	_, err = s.AddProject(ctx, withRoles)
+	if err != nil {
+		t.Fatalf("add project with roles: %s", err)
+	}
+	got, err = s.GetProjectByID(ctx, withRoles.ID)
+	if err != nil {
+		t.Fatalf("get project: %s", err)
+	}
+	expectedRoles := map[string]string{owner: "owner", proj.Members[0]: storage.DefaultRole}
+	if !maps.Equal(got.Roles, expectedRoles) {
+		t.Errorf("expected roles %v got %v", expectedRoles, got.Roles)
+	}
+}
+
+func testProjectMembers(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 1)
+	otherProj := mustAddProject(t, s, 1)
+	newMember := randomUserID()
+	mustAddUsers(t, s, newMember)
+
+	err := s.AddProjectUser(ctx, proj.ID, newMember, "viewer")
+	if err != nil {
+		t.Fatalf("add project user: %s", err)
+	}
+	err = s.AddProjectUser(ctx, proj.ID, newMember, "editor")
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected already exists for duplicate membership got %v", err)
+	}
+
+	isMember, err := isProjectMember(ctx, s, proj.ID, newMember)
+	if err != nil || !isMember {
+		t.Errorf("expected %s to be a member: %v", newMember, err)
+	}
+	isMember, err = isProjectMember(ctx, s, otherProj.ID, newMember)
+	if err != nil || isMember {
+		t.Errorf("expected %s not to be a member of the other project: %v", newMember, err)
+	}
+
+	users, err := s.GetProjectUsers(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get project users: %s", err)
+	}
+	expectedMembers := append(slices.Clone(proj.Members), newMember)
+	slices.Sort(expectedMembers)
+	userIDs := make([]string, 0, len(users))
+	for _, u := range users {
+		userIDs = append(userIDs, u.ID)
+	}
+	if !slices.Equal(userIDs, expectedMembers) {
+		t.Errorf("expected members %v got %v", expectedMembers, userIDs)
+	}
+	for _, u := range users {
+		expectedRole := storage.DefaultRole
+		if u.ID == newMember {
+			expectedRole = "viewer"
+		}
+		if u.Role != expectedRole {
+			t.Errorf("expected %s to be %s got %s", u.ID, expectedRole, u.Role)
+		}
+	}
+
+	err = s.UpdateProjectUserRole(ctx, proj.ID, newMember, "owner")
+	if err != nil {
+		t.Fatalf("update role: %s", err)
+	}
+	role, err := s.GetProjectRole(ctx, proj.ID, newMember)
+	if err != nil || role != "owner" {
+		t.Errorf("expected %s to be owner got %q: %v", newMember, role, err)
+	}
+	got, err := s.GetProjectByID(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get project: %s", err)
+	}
+	if got.Roles[newMember] != "owner" || got.Roles[proj.Members[0]] != storage.DefaultRole {
+		t.Errorf("expected roles of the project got %v", got.Roles)
+	}
+	err = s.UpdateProjectUserRole(ctx, proj.ID, newMember, "editor")
+	if !errors.Is(err, storage.ErrLastOwner) {
+		t.Errorf("expected the only owner to keep the role got %v", err)
+	}
+	err = s.RemoveProjectUser(ctx, proj.ID, newMember, nil)
+	if !errors.Is(err, storage.ErrLastOwner) {
+		t.Errorf("expected the only owner not to be removed got %v", err)
+	}
+	err = s.UpdateProjectUserRole(ctx, otherProj.ID, newMember, "owner")
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found for role of non member got %v", err)
+	}
+
+	projects, err := s.GetProjects(ctx, storage.ProjectFilter{UserID: newMember, Limit: 10})
+	if err != nil {
+		t.Fatalf("get projects: %s", err)
+	}
+	if len(projects) != 1 || projects[0].ID != proj.ID {
+		t.Errorf("expected only project %s for the new member got %v", proj.ID, projects)
+	}
+}
+
+func testRemoveProjectUser(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 3)
+	leaving := proj.Members[2]
+	err := s.AddTransaction(ctx, proj.ID, newTransaction(leaving, proj.Members[0]))
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+
+	settlement := newTransaction(proj.Members[0], leaving)
+	err = s.RemoveProjectUser(ctx, proj.ID, leaving, []storage.Transaction{settlement})
+	if err != nil {
+		t.Fatalf("remove project user: %s", err)
+	}
+	err = s.RemoveProjectUser(ctx, proj.ID, leaving, nil)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found for removed member got %v", err)
+	}
+
+	isMember, err := isProjectMember(ctx, s, proj.ID, leaving)
+	if err != nil || isMember {
+		t.Errorf("expected %s not to be a member anymore: %v", leaving, err)
+	}
+	got, err := s.GetProjectByID(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get project: %s", err)
+	}
+	if slices.Contains(got.Members, leaving) {
+		t.Errorf("expected %s not to be listed as member got %v", leaving, got.Members)
+	}
+	// the transactions of the former member are kept
+	if transactions := mustGetTransactions(t, s, proj.ID); len(transactions) != 2 {
+		t.Errorf("expected the transaction and the settlement got %d transactions", len(transactions))
+	}
+
+	err = s.AddProjectUser(ctx, proj.ID, leaving, "viewer")
+	if err != nil {
+		t.Fatalf("rejoin project: %s", err)
+	}
+	role, err := s.GetProjectRole(ctx, proj.ID, leaving)
+	if err != nil || role != "viewer" {
+		t.Errorf("expected %s to be a member again with the new role got %q: %v", leaving, role, err)
+	}
+}
+
+func testUpdateAndDeleteProject(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 2)
+	err := s.AddTransaction(ctx, proj.ID, newTransaction(proj.Members[0], proj.Members[1]))
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+
+	proj.Name = "renamed"
+	proj.ArchivedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	err = s.UpdateProject(ctx, proj)
+	if err != nil {
+		t.Fatalf("update project: %s", err)
+	}
+	got, err := s.GetProjectByID(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get project: %s", err)
+	}
+	if got.Name != "renamed" || !got.ArchivedAt.Valid {
+		t.Errorf("expected renamed archived project got %+v", got)
+	}
+
+	filter := storage.ProjectFilter{UserID: proj.Members[0], Limit: 10}
+	if projects, err := s.GetProjects(ctx, filter); err != nil || len(projects) != 0 {
+		t.Errorf("expected archived project to be hidden got %v %v", projects, err)
+	}
+	filter.IncludeArchived = true
+	if projects, err := s.GetProjects(ctx, filter); err != nil || len(projects) != 1 {
+		t.Errorf("expected archived project to be included got %v %v", projects, err)
+	}
+
+	err = s.DeleteProject(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("delete project: %s", err)
+	}
+	_, err = s.GetProjectByID(ctx, proj.ID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found for deleted project got %v", err)
+	}
+	if isMember, err := isProjectMember(ctx, s, proj.ID, proj.Members[0]); err != nil || isMember {
+		t.Errorf("expected memberships to be deleted: %v", err)
+	}
+	err = s.DeleteProject(ctx, proj.ID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found for deleting twice got %v", err)
+	}
+}
+
+func testProjectFilter(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	user := randomUserID()
+	member := randomUserID()
+	mustAddUsers(t, s, user, member)
+
+	projects := []storage.Project{}
+	for _, name := range []string{"Trip to Rome", "Flat", "rome again"} {
+		proj := storage.Project{ID: uuid.New(), Name: name, Currency: "EUR", Members: []string{user}}
+		if name == "Flat" {
+			proj.Members = append(proj.Members, member)
+		}
+		if _, err := s.AddProject(ctx, proj); err != nil {
+			t.Fatalf("add project: %s", err)
+		}
+		projects = append(projects, proj)
+	}
+	slices.SortFunc(projects, func(a, b storage.Project) int { return strings.Compare(a.ID.String(), b.ID.String()) })
+
+	tests := []struct {
+		name     string
+		filter   storage.ProjectFilter
+		expected []storage.Project
+	}{
+		{"all", storage.ProjectFilter{UserID: user, Limit: 10}, projects},
+		{"first page", storage.ProjectFilter{UserID: user, Limit: 2}, projects[:2]},
+		{
+			"second page",
+			storage.ProjectFilter{UserID: user, Limit: 2, After: uuid.NullUUID{UUID: projects[1].ID, Valid: true}},
+			projects[2:],
+		},
+		{"name", storage.ProjectFilter{UserID: user, Name: "ROME", Limit: 10}, filterProjects(projects, func(p storage.Project) bool {
+			return p.Name != "Flat"
+		})},
+		{"member", storage.ProjectFilter{UserID: user, Member: member, Limit: 10}, filterProjects(projects, func(p storage.Project) bool {
+			return p.Name == "Flat"
+		})},
+		{"other user", storage.ProjectFilter{UserID: randomUserID(), Limit: 10}, []storage.Project{}},
+	}
+
+	for _, test := range tests {
+		got, err := s.GetProjects(ctx, test.filter)
+		if err != nil {
+			t.Fatalf("%s: get projects: %s", test.name, err)
+		}
+		if len(got) != len(test.expected) {
+			t.Errorf("%s: expected %d projects got %d", test.name, len(test.expected), len(got))
+			continue
+		}
+		for i := range got {
+			if got[i].ID != test.expected[i].ID {
+				t.Errorf("%s: expected project %s at %d got %s", test.name, test.expected[i].ID, i, got[i].ID)
+			}
+		}
+	}
+}
+
+func filterProjects(projects []storage.Project, keep func(p storage.Project) bool) []storage.Project {
+	filtered := []storage.Project{}
+	for _, p := range projects {
+		if keep(p) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+func testAddTransactions(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 3)
+	tx := newTransaction(proj.Members[0], proj.Members[1:]...)
+	tx.SplitMode = "Shares"
+	tx.Weights = []int{2, 1}
+
+	err := s.AddTransaction(ctx, proj.ID, tx)
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+	err = s.AddTransaction(ctx, proj.ID, tx)
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected already exists for duplicate transaction got %v", err)
+	}
+
+	err = s.AddTransaction(ctx, proj.ID, newTransaction(proj.Members[0], randomUserID()))
+	if !errors.Is(err, storage.ErrInvalidReference) {
+		t.Errorf("expected invalid reference for unknown target got %v", err)
+	}
+	outsider := randomUserID()
+	mustAddUsers(t, s, outsider)
+	err = s.AddTransaction(ctx, proj.ID, newTransaction(outsider, proj.Members[0]))
+	if !errors.Is(err, storage.ErrInvalidReference) {
+		t.Errorf("expected invalid reference for source that is not a member got %v", err)
+	}
+
+	got := mustGetTransactions(t, s, proj.ID)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 transaction got %d", len(got))
+	}
+	compareTransaction(t, got[0], tx, proj.ID)
+}
+
+func testAddTransactionsAtomic(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 2)
+	existing := newTransaction(proj.Members[0], proj.Members[1])
+	err := s.AddTransaction(ctx, proj.ID, existing)
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+
+	err = s.AddTransactions(ctx, proj.ID, []storage.Transaction{
+		newTransaction(proj.Members[1], proj.Members[0]),
+		existing,
+	})
+	if err == nil {
+		t.Fatalf("expected error when adding a duplicate transaction")
+	}
+
+	got := mustGetTransactions(t, s, proj.ID)
+	if len(got) != 1 {
+		t.Errorf("expected only the existing transaction got %d", len(got))
+	}
+}
+
+func testImportTransactions(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 1)
+	existingUser := randomUserID()
+	mustAddUsers(t, s, existingUser)
+	newUser := randomUserID()
+
+	tx := newTransaction(newUser, proj.Members[0], existingUser)
+	err := s.ImportTransactions(ctx, proj.ID, []string{existingUser, newUser}, []storage.Transaction{tx})
+	if err != nil {
+		t.Fatalf("import transactions: %s", err)
+	}
+	users, err := s.GetProjectUsers(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get project users: %s", err)
+	}
+	if len(users) != 3 {
+		t.Errorf("expected the imported members to be added got %v", users)
+	}
+	got := mustGetTransactions(t, s, proj.ID)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 transaction got %d", len(got))
+	}
+	compareTransaction(t, got[0], tx, proj.ID)
+
+	// nothing is written if one transaction fails
+	failedUser := randomUserID()
+	err = s.ImportTransactions(ctx, proj.ID, []string{failedUser},
+		[]storage.Transaction{newTransaction(failedUser, proj.Members[0]), tx})
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected already exists for duplicate transaction got %v", err)
+	}
+	isMember, err := isProjectMember(ctx, s, proj.ID, failedUser)
+	if err != nil || isMember {
+		t.Errorf("expected member of failed import to not be added got %v: %v", isMember, err)
+	}
+}
+
+func testUpdateTransaction(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 3)
+	tx := newTransaction(proj.Members[0], proj.Members[1])
+	err := s.AddTransaction(ctx, proj.ID, tx)
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+
+	tx.Name = "updated"
+	tx.Amount = 4200
+	tx.TargetIDs = []string{proj.Members[1], proj.Members[2]}
+	tx.Weights = []int{1, 1}
+	tx.OccurredAt = tx.OccurredAt.AddDate(0, 1, 0)
+	// the creator can't be changed by an update
+	updated := tx
+	updated.CreatedBy = proj.Members[2]
+	err = s.UpdateTransaction(ctx, proj.ID, updated)
+	if err != nil {
+		t.Fatalf("update transaction: %s", err)
+	}
+
+	got := mustGetTransactions(t, s, proj.ID)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 transaction got %d", len(got))
+	}
+	compareTransaction(t, got[0], tx, proj.ID)
+
+	err = s.UpdateTransaction(ctx, proj.ID, newTransaction(proj.Members[0], proj.Members[1]))
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found for unknown transaction got %v", err)
+	}
+}
+
+func testDeleteTransaction(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 2)
+	otherProj := mustAddProject(t, s, 2)
+	tx := newTransaction(proj.Members[0], proj.Members[1])
+	err := s.AddTransaction(ctx, proj.ID, tx)
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+
+	err = s.DeleteTransaction(ctx, otherProj.ID, tx.ID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found when deleting from another project got %v", err)
+	}
+
+	err = s.DeleteTransaction(ctx, proj.ID, tx.ID)
+	if err != nil {
+		t.Fatalf("delete transaction: %s", err)
+	}
+	if got := mustGetTransactions(t, s, proj.ID); len(got) != 0 {
+		t.Errorf("expected no transactions got %v", got)
+	}
+
+	err = s.DeleteTransaction(ctx, proj.ID, tx.ID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found for deleted transaction got %v", err)
+	}
+}
+
+func testTransactionsByUser(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 3)
+	user := proj.Members[0]
+	outgoing := newTransaction(user, proj.Members[1], proj.Members[2])
+	incoming := newTransaction(proj.Members[1], user, proj.Members[2])
+	unrelated := newTransaction(proj.Members[1], proj.Members[2])
+	err := s.AddTransactions(ctx, proj.ID, []storage.Transaction{outgoing, incoming, unrelated})
+	if err != nil {
+		t.Fatalf("add transactions: %s", err)
+	}
+
+	got, err := s.GetAllOutgoingTransactionsByUserID(ctx, user)
+	if err != nil {
+		t.Fatalf("get outgoing transactions: %s", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 outgoing transaction got %d", len(got))
+	}
+	compareTransaction(t, got[0], outgoing, proj.ID)
+
+	got, err = s.GetAllIncomingTransactionsByUserID(ctx, user)
+	if err != nil {
+		t.Fatalf("get incoming transactions: %s", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 incoming transaction got %d", len(got))
+	}
+	compareTransaction(t, got[0], incoming, proj.ID)
+}
+
+func testTransactionTargetOrder(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 4)
+	// the members are sorted, the targets are not, so neither order can come from the ids
+	tx := newTransaction(proj.Members[0], proj.Members[2], proj.Members[3], proj.Members[1])
+	tx.Amount = 100
+	err := s.AddTransactions(ctx, proj.ID, []storage.Transaction{tx})
+	if err != nil {
+		t.Fatalf("add transactions: %s", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		got := mustGetTransactions(t, s, proj.ID)
+		if len(got) != 1 {
+			t.Fatalf("expected 1 transaction got %d", len(got))
+		}
+		compareTransaction(t, got[0], tx, proj.ID)
+
+		// the remaining cent of 100 split three ways has to go to the same target every time
+		converted := service.FromStorageTransaction(got[0])
+		split := converted.ToCostCalc()
+		shares, err := split.Split()
+		if err != nil {
+			t.Fatalf("split transaction: %s", err)
+		}
+		if shares[0].Amount() != 34 || shares[1].Amount() != 33 || shares[2].Amount() != 33 {
+			t.Errorf("expected shares 34, 33, 33 for %v got %v", got[0].TargetIDs, shares)
+		}
+
+		got, err = s.GetTransactions(ctx, storage.TransactionFilter{ProjectID: proj.ID, Limit: 10})
+		if err != nil {
+			t.Fatalf("get transactions: %s", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("expected 1 transaction got %d", len(got))
+		}
+		compareTransaction(t, got[0], tx, proj.ID)
+
+		got, err = s.GetAllIncomingTransactionsByUserID(ctx, proj.Members[1])
+		if err != nil {
+			t.Fatalf("get incoming transactions: %s", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("expected 1 incoming transaction got %d", len(got))
+		}
+		compareTransaction(t, got[0], tx, proj.ID)
+	}
+}
+
+func testTransactionFilter(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 3)
+	small := newTransaction(proj.Members[0], proj.Members[1])
+	small.Amount = 100
+	medium := newTransaction(proj.Members[1], proj.Members[2])
+	medium.Amount = 200
+	medium.TransactionType = "Transfer"
+	large := newTransaction(proj.Members[0], proj.Members[2])
+	large.Amount = 300
+	large.OccurredAt = small.OccurredAt.AddDate(0, 0, -1)
+	err := s.AddTransactions(ctx, proj.ID, []storage.Transaction{small, medium, large})
+	if err != nil {
+		t.Fatalf("add transactions: %s", err)
+	}
+
+	byAmount := storage.TransactionFilter{ProjectID: proj.ID, SortBy: storage.SortByAmount, Limit: 10}
+	tests := []struct {
+		name     string
+		filter   func(f storage.TransactionFilter) storage.TransactionFilter
+		expected []storage.Transaction
+	}{
+		{"all", func(f storage.TransactionFilter) storage.TransactionFilter { return f }, []storage.Transaction{small, medium, large}},
+		{"descending", func(f storage.TransactionFilter) storage.TransactionFilter {
+			f.Descending = true
+			return f
+		}, []storage.Transaction{large, medium, small}},
+		{"id", func(f storage.TransactionFilter) storage.TransactionFilter {
+			f.ID = uuid.NullUUID{UUID: medium.ID, Valid: true}
+			return f
+		}, []storage.Transaction{medium}},
+		{"type", func(f storage.TransactionFilter) storage.TransactionFilter {
+			f.TransactionType = "Transfer"
+			return f
+		}, []storage.Transaction{medium}},
+		{"source", func(f storage.TransactionFilter) storage.TransactionFilter {
+			f.SourceID = proj.Members[0]
+			return f
+		}, []storage.Transaction{small, large}},
+		{"target", func(f storage.TransactionFilter) storage.TransactionFilter {
+			f.TargetID = proj.Members[2]
+			return f
+		}, []storage.Transaction{medium, large}},
+		{"amount range", func(f storage.TransactionFilter) storage.TransactionFilter {
+			f.MinAmount = sql.NullInt64{Int64: 150, Valid: true}
+			f.MaxAmount = sql.NullInt64{Int64: 300, Valid: true}
+			return f
+		}, []storage.Transaction{medium, large}},
+		{"date range", func(f storage.TransactionFilter) storage.TransactionFilter {
+			f.From = sql.NullTime{Time: large.OccurredAt, Valid: true}
+			f.To = sql.NullTime{Time: small.OccurredAt, Valid: true}
+			return f
+		}, []storage.Transaction{large}},
+		{"by date", func(f storage.TransactionFilter) storage.TransactionFilter {
+			f.SortBy = storage.SortByDate
+			f.SourceID = proj.Members[0]
+			return f
+		}, []storage.Transaction{large, small}},
+		{"page", func(f storage.TransactionFilter) storage.TransactionFilter {
+			f.Limit = 1
+			f.After = &storage.TransactionCursor{ID: small.ID, Amount: small.Amount}
+			return f
+		}, []storage.Transaction{medium}},
+	}
+
+	for _, test := range tests {
+		got, err := s.GetTransactions(ctx, test.filter(byAmount))
+		if err != nil {
+			t.Fatalf("%s: get transactions: %s", test.name, err)
+		}
+		if len(got) != len(test.expected) {
+			t.Errorf("%s: expected %d transactions got %d", test.name, len(test.expected), len(got))
+			continue
+		}
+		for i := range got {
+			compareTransaction(t, got[i], test.expected[i], proj.ID)
+		}
+	}
+
+	foreign := newTransaction(proj.Members[0], proj.Members[1])
+	foreign.Amount = 250
+	foreign.Currency = "JPY"
+	err = s.AddTransaction(ctx, proj.ID, foreign)
+	if err != nil {
+		t.Fatalf("add transaction: %s", err)
+	}
+	amountRange := byAmount
+	amountRange.MinAmount = sql.NullInt64{Int64: 150, Valid: true}
+	amountRange.MaxAmount = sql.NullInt64{Int64: 300, Valid: true}
+	got, err := s.GetTransactions(ctx, amountRange)
+	if err != nil || len(got) != 3 {
+		t.Errorf("expected the amount range to match all currencies got %v: %v", got, err)
+	}
+	amountRange.Currency = "EUR"
+	got, err = s.GetTransactions(ctx, amountRange)
+	if err != nil || len(got) != 2 || got[0].ID != medium.ID || got[1].ID != large.ID {
+		t.Errorf("expected the amount range to match only transactions in EUR got %v: %v", got, err)
+	}
+}
+
+func testIdempotencyRecords(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	abandonedBefore := now.Add(-time.Minute)
+	record := storage.IdempotencyRecord{UserID: randomUserID(), Key: uuid.NewString(), Fingerprint: "fingerprint", CreatedAt: now}
+	err := s.AddIdempotencyRecord(ctx, record, abandonedBefore)
+	if err != nil {
+		t.Fatalf("add idempotency record: %s", err)
+	}
+	err = s.AddIdempotencyRecord(ctx, record, abandonedBefore)
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected already exists for reused key got %v", err)
+	}
+	// the first request is abandoned once it is in progress for too long
+	retry := record
+	retry.Fingerprint = "retry"
+	retry.CreatedAt = now.Add(time.Second)
+	err = s.AddIdempotencyRecord(ctx, retry, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("expected abandoned key to be replaced got %v", err)
+	}
+	err = s.AddIdempotencyRecord(ctx, record, abandonedBefore)
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected already exists for replaced key got %v", err)
+	}
+	// the abandoned request must not touch the record of the request that took over its key
+	abandoned := record
+	abandoned.StatusCode = 500
+	err = s.CompleteIdempotencyRecord(ctx, abandoned)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found when completing a taken over key got %v", err)
+	}
+	err = s.DeleteIdempotencyRecord(ctx, abandoned)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found when deleting a taken over key got %v", err)
+	}
+	record = retry
+
+	got, err := s.GetIdempotencyRecord(ctx, record.UserID, record.Key)
+	if err != nil || got.Fingerprint != record.Fingerprint || got.StatusCode != 0 {
+		t.Errorf("expected record in progress got %+v: %v", got, err)
+	}
+
+	record.StatusCode = 201
+	record.ContentType = "application/json"
+	record.Location = "/transactions/1"
+	record.ResponseBody = []byte(`{"id":1}`)
+	err = s.CompleteIdempotencyRecord(ctx, record)
+	if err != nil {
+		t.Fatalf("complete idempotency record: %s", err)
+	}
+	got, err = s.GetIdempotencyRecord(ctx, record.UserID, record.Key)
+	if err != nil || got.StatusCode != 201 || got.ContentType != record.ContentType || got.Location != record.Location ||
+		string(got.ResponseBody) != `{"id":1}` {
+		t.Errorf("expected completed record got %+v: %v", got, err)
+	}
+	err = s.CompleteIdempotencyRecord(ctx, record)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found when completing a record twice got %v", err)
+	}
+
+	err = s.AddIdempotencyRecord(ctx, record, now.Add(time.Minute))
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected completed key not to be replaced got %v", err)
+	}
+
+	err = s.DeleteIdempotencyRecord(ctx, record)
+	if err != nil {
+		t.Fatalf("delete idempotency record: %s", err)
+	}
+	_, err = s.GetIdempotencyRecord(ctx, record.UserID, record.Key)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found after delete got %v", err)
+	}
+
+	expired := storage.IdempotencyRecord{UserID: record.UserID, Key: uuid.NewString(), Fingerprint: "expired", CreatedAt: now.AddDate(-10, 0, 0)}
+	kept := storage.IdempotencyRecord{UserID: record.UserID, Key: uuid.NewString(), Fingerprint: "kept", CreatedAt: now}
+	for _, r := range []storage.IdempotencyRecord{expired, kept} {
+		if err := s.AddIdempotencyRecord(ctx, r, abandonedBefore); err != nil {
+			t.Fatalf("add idempotency record: %s", err)
+		}
+	}
+	deleted, err := s.DeleteIdempotencyRecords(ctx, now.AddDate(-9, 0, 0))
+	if err != nil || deleted < 1 {
+		t.Fatalf("expected expired record to be deleted got %d: %v", deleted, err)
+	}
+	_, err = s.GetIdempotencyRecord(ctx, expired.UserID, expired.Key)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected expired record to be deleted got %v", err)
+	}
+	_, err = s.GetIdempotencyRecord(ctx, kept.UserID, kept.Key)
+	if err != nil {
+		t.Errorf("expected newer record to be kept got %v", err)
+	}
+}
+
+func testInvites(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 1)
+	invite := storage.Invite{
+		ID: uuid.New(), ProjectID: proj.ID, Role: "viewer", CreatedBy: proj.Members[0],
+		ExpiresAt: time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	err := s.AddInvite(ctx, invite)
+	if err != nil {
+		t.Fatalf("add invite: %s", err)
+	}
+	err = s.AddInvite(ctx, invite)
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected already exists for duplicate invite got %v", err)
+	}
+	other := invite
+	other.ID = uuid.New()
+	other.ProjectID = uuid.New()
+	err = s.AddInvite(ctx, other)
+	if !errors.Is(err, storage.ErrInvalidReference) {
+		t.Errorf("expected invalid reference for unknown project got %v", err)
+	}
+
+	got, err := s.GetInvite(ctx, invite.ID)
+	if err != nil {
+		t.Fatalf("get invite: %s", err)
+	}
+	if got.ProjectID != invite.ProjectID || got.Role != invite.Role || got.CreatedBy != invite.CreatedBy ||
+		!got.ExpiresAt.Equal(invite.ExpiresAt) || got.CreatedAt.IsZero() {
+		t.Errorf("expected invite %+v got %+v", invite, got)
+	}
+	invites, err := s.GetInvites(ctx, proj.ID)
+	if err != nil || len(invites) != 1 || invites[0].ID != invite.ID {
+		t.Errorf("expected the invite of the project got %+v: %v", invites, err)
+	}
+
+	err = s.DeleteInvite(ctx, uuid.New(), invite.ID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found when deleting from another project got %v", err)
+	}
+	err = s.DeleteInvite(ctx, proj.ID, invite.ID)
+	if err != nil {
+		t.Fatalf("delete invite: %s", err)
+	}
+	_, err = s.GetInvite(ctx, invite.ID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found for deleted invite got %v", err)
+	}
+}
+
+func testGuests(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 2)
+	member, claimer := proj.Members[0], proj.Members[1]
+	guest := storage.User{ID: randomUserID(), DisplayName: "Guest", GuestProjectID: uuid.NullUUID{UUID: proj.ID, Valid: true}}
+
+	err := s.AddGuest(ctx, guest, "")
+	if err != nil {
+		t.Fatalf("add guest: %s", err)
+	}
+	err = s.AddGuest(ctx, guest, "")
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected already exists for duplicate guest got %v", err)
+	}
+	err = s.AddGuest(ctx, storage.User{ID: randomUserID(), GuestProjectID: uuid.NullUUID{UUID: uuid.New(), Valid: true}}, "")
+	if !errors.Is(err, storage.ErrInvalidReference) {
+		t.Errorf("expected invalid reference for unknown project got %v", err)
+	}
+	got, err := s.GetUser(ctx, guest.ID)
+	if err != nil || got != guest {
+		t.Errorf("expected guest %+v got %+v: %v", guest, got, err)
+	}
+	if ok, err := isProjectMember(ctx, s, proj.ID, guest.ID); err != nil || !ok {
+		t.Errorf("expected guest to be a member: %v", err)
+	}
+
+	paidByGuest := newTransaction(guest.ID, member)
+	sharedEqual := newTransaction(member, guest.ID, claimer)
+	sharedShares := newTransaction(member, guest.ID, member, claimer)
+	sharedShares.SplitMode = "Shares"
+	sharedShares.Weights = []int{2, 1, 3}
+	err = s.AddTransactions(ctx, proj.ID, []storage.Transaction{paidByGuest, sharedEqual, sharedShares})
+	if err != nil {
+		t.Fatalf("add transactions: %s", err)
+	}
+
+	rent := storage.RecurringTransaction{
+		ID: uuid.New(), ProjectID: proj.ID, Name: "rent", TransactionType: "Expense", Amount: 900, Currency: "EUR",
+		SourceID: guest.ID, TargetIDs: []string{guest.ID, claimer}, SplitMode: "Equal", Weights: []int{1, 1},
+		Frequency: "monthly", Interval: 1, StartDate: paidByGuest.OccurredAt, CreatedBy: member,
+	}
+	err = s.AddRecurringTransaction(ctx, rent)
+	if err != nil {
+		t.Fatalf("add recurring transaction: %s", err)
+	}
+
+	other := mustAddProject(t, s, 1)
+	err = s.ClaimGuest(ctx, other.ID, guest.ID, other.Members[0])
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found for guest of another project got %v", err)
+	}
+	err = s.ClaimGuest(ctx, proj.ID, member, claimer)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found for member that is no guest got %v", err)
+	}
+	err = s.ClaimGuest(ctx, proj.ID, guest.ID, other.Members[0])
+	if !errors.Is(err, storage.ErrInvalidReference) {
+		t.Errorf("expected invalid reference for user that is no member got %v", err)
+	}
+
+	err = s.ClaimGuest(ctx, proj.ID, guest.ID, claimer)
+	if err != nil {
+		t.Fatalf("claim guest: %s", err)
+	}
+	paidByGuest.SourceID = claimer
+	sharedEqual.SplitMode = "Shares"
+	sharedEqual.TargetIDs = []string{claimer}
+	sharedEqual.Weights = []int{2}
+	sharedShares.TargetIDs = []string{member, claimer}
+	sharedShares.Weights = []int{1, 5}
+	expected := map[uuid.UUID]storage.Transaction{paidByGuest.ID: paidByGuest, sharedEqual.ID: sharedEqual, sharedShares.ID: sharedShares}
+	for _, tx := range mustGetTransactions(t, s, proj.ID) {
+		compareTransaction(t, tx, expected[tx.ID], proj.ID)
+	}
+	recurring, err := s.GetRecurringTransactions(ctx, proj.ID)
+	if err != nil || len(recurring) != 1 {
+		t.Fatalf("expected the recurring transaction got %+v: %v", recurring, err)
+	}
+	if recurring[0].SourceID != claimer || recurring[0].SplitMode != "Shares" ||
+		!maps.Equal(recurringTargetWeights(&recurring[0]), map[string]int{claimer: 2}) {
+		t.Errorf("expected recurring transaction to be moved to %s got %+v", claimer, recurring[0])
+	}
+	_, err = s.GetUser(ctx, guest.ID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected claimed guest to be deleted got %v", err)
+	}
+	if ok, err := isProjectMember(ctx, s, proj.ID, guest.ID); err != nil || ok {
+		t.Errorf("expected claimed guest to be no member anymore: %v", err)
+	}
+
+	// guests are deleted with their project
+	otherGuest := storage.User{ID: randomUserID(), DisplayName: "Other", GuestProjectID: uuid.NullUUID{UUID: other.ID, Valid: true}}
+	if err := s.AddGuest(ctx, otherGuest, ""); err != nil {
+		t.Fatalf("add guest: %s", err)
+	}
+	if err := s.DeleteProject(ctx, other.ID); err != nil {
+		t.Fatalf("delete project: %s", err)
+	}
+	_, err = s.GetUser(ctx, otherGuest.ID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected guest of deleted project to be deleted got %v", err)
+	}
+}
+
+func testRecurringTransactions(t *testing.T, s service.ProjectStorage) {
+	ctx := context.Background()
+	proj := mustAddProject(t, s, 2)
+	start := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	rt := storage.RecurringTransaction{
+		ID: uuid.New(), ProjectID: proj.ID, Name: "rent", TransactionType: "Expense", Amount: 90000, Currency: "EUR",
+		SourceID: proj.Members[0], TargetIDs: proj.Members, SplitMode: "Shares", Weights: []int{2, 1},
+		Frequency: "monthly", Interval: 1, StartDate: start,
+		EndDate:        sql.NullTime{Time: start.AddDate(1, 0, 0), Valid: true},
+		NextOccurrence: sql.NullTime{Time: start, Valid: true}, CreatedBy: proj.Members[0],
+	}
+	err := s.AddRecurringTransaction(ctx, rt)
+	if err != nil {
+		t.Fatalf("add recurring transaction: %s", err)
+	}
+	err = s.AddRecurringTransaction(ctx, rt)
+	if !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected already exists for duplicate recurring transaction got %v", err)
+	}
+	invalid := rt
+	invalid.ID = uuid.New()
+	invalid.TargetIDs = []string{randomUserID()}
+	err = s.AddRecurringTransaction(ctx, invalid)
+	if !errors.Is(err, storage.ErrInvalidReference) {
+		t.Errorf("expected invalid reference for target that is no member got %v", err)
+	}
+
+	recurring, err := s.GetRecurringTransactions(ctx, proj.ID)
+	if err != nil {
+		t.Fatalf("get recurring transactions: %s", err)
+	}
+	if len(recurring) != 1 {
+		t.Fatalf("expected one recurring transaction got %+v", recurring)
+	}
+	got := recurring[0]
+	if got.ID != rt.ID || got.Name != rt.Name || got.Amount != rt.Amount || got.SourceID != rt.SourceID ||
+		got.SplitMode != rt.SplitMode || got.Frequency != rt.Frequency || got.Interval != rt.Interval ||
+		!got.StartDate.Equal(rt.StartDate) || !got.EndDate.Time.Equal(rt.EndDate.Time) ||
+		!got.NextOccurrence.Time.Equal(start) || got.CreatedAt.IsZero() {
+		t.Errorf("expected recurring transaction %+v got %+v", rt, got)
+	}
+	if !maps.Equal(recurringTargetWeights(&got), recurringTargetWeights(&rt)) {
+		t.Errorf("expected targets %v with weights %v got %v with %v", rt.TargetIDs, rt.Weights, got.TargetIDs, got.Weights)
+	}
+
+	isDue := func(until time.Time) bool {
+		t.Helper()
+		due, err := s.GetDueRecurringTransactions(ctx, until)
+		if err != nil {
+			t.Fatalf("get due recurring transactions: %s", err)
+		}
+		return slices.ContainsFunc(due, func(d storage.RecurringTransaction) bool { return d.ID == rt.ID })
+	}
+	if isDue(start.AddDate(0, 0, -1)) || !isDue(start) {
+		t.Errorf("expected recurring transaction to be due from %s", start)
+	}
+	err = s.SetNextOccurrence(ctx, rt.ID, sql.NullTime{Time: start.AddDate(0, 1, 0), Valid: true})
+	if err != nil {
+		t.Fatalf("set next occurrence: %s", err)
+	}
+	if isDue(start) || !isDue(start.AddDate(0, 1, 0)) {
+		t.Errorf("expected recurring transaction to be due from the next occurrence")
+	}
+	proj.ArchivedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	if err := s.UpdateProject(ctx, proj); err != nil {
+		t.Fatalf("archive project: %s", err)
+	}
+	if isDue(start.AddDate(1, 0, 0)) {
+		t.Errorf("expected recurring transactions of archived projects not to be due")
+	}
+	proj.ArchivedAt = sql.NullTime{}
+	if err := s.UpdateProject(ctx, proj); err != nil {
+		t.Fatalf("unarchive project: %s", err)
+	}
+	err = s.SetNextOccurrence(ctx, rt.ID, sql.NullTime{})
+	if err != nil {
+		t.Fatalf("set next occurrence: %s", err)
+	}
+	if isDue(start.AddDate(10, 0, 0)) {
+		t.Errorf("expected ended recurring transaction not to be due")
+	}
+	err = s.SetNextOccurrence(ctx, uuid.New(), sql.NullTime{})
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found for unknown recurring transaction got %v", err)
+	}
+
+	err = s.DeleteRecurringTransaction(ctx, uuid.New(), rt.ID)
+	if !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected not found when deleting from another project got %v", err)
+	}
+	err = s.DeleteRecurringTransaction(ctx, proj.ID, rt.ID)
+	if err != nil {
+		t.Fatalf("delete recurring transaction: %s", err)
+	}
+	recurring, err = s.GetRecurringTransactions(ctx, proj.ID)
+	if err != nil || len(recurring) != 0 {
+		t.Errorf("expected no recurring transactions after delete got %+v: %v", recurring, err)
+	}
+}
+
+func recurringTargetWeights(rt *storage.RecurringTransaction) map[string]int {
+	weights := make(map[string]int, len(rt.TargetIDs))
+	for i, target := range rt.TargetIDs {
+		weights[target] = rt.Weight(i)
+	}
+	return weights
+}
+
+// isProjectMember reports whether the user is a current member of the project.
+func isProjectMember(ctx context.Context, s service.ProjectStorage, projectID uuid.UUID, userID string) (bool, error) {
+	_, err := s.GetProjectRole(ctx, projectID, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func mustAddUsers(t *testing.T, s service.ProjectStorage, userIDs ...string) {
+	t.Helper()
+	for _, id := range userIDs {
+		if err := s.AddUser(context.Background(), storage.User{ID: id}); err != nil {
+			t.Fatalf("add user %s: %s", id, err)
+		}
+	}
+}
+
+func mustAddProject(t *testing.T, s service.ProjectStorage, memberCount int) storage.Project {
+	t.Helper()
+	members := make([]string, 0, memberCount)
+	for i := 0; i < memberCount; i++ {
+		members = append(members, randomUserID())
+	}
+	slices.Sort(members)
+	mustAddUsers(t, s, members...)
+
+	proj := storage.Project{ID: uuid.New(), Name: "project", Currency: "EUR", Members: members}
+	_, err := s.AddProject(context.Background(), proj)
+	if err != nil {
+		t.Fatalf("add project: %s", err)
+	}
+	return proj
+}
+
+func mustGetTransactions(t *testing.T, s service.ProjectStorage, projectID uuid.UUID) []storage.Transaction {
+	t.Helper()
+	proj, err := s.GetProjectByID(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("get project: %s", err)
+	}
+	return proj.Transactions
+}
+
+func newTransaction(source string, targets ...string) storage.Transaction {
+	weights := make([]int, 0, len(targets))
+	for range targets {
+		weights = append(weights, 1)
+	}
+	return storage.Transaction{
+		ID:              uuid.New(),
+		Name:            "transaction",
+		TransactionType: "Expense",
+		Amount:          1234,
+		Currency:        "EUR",
+		SourceID:        source,
+		TargetIDs:       targets,
+		SplitMode:       "Equal",
+		Weights:         weights,
+		OccurredAt:      time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		CreatedBy:       source,
+	}
+}
+
+func compareTransaction(t *testing.T, actual, expected storage.Transaction, projectID uuid.UUID) {
+	t.Helper()
+	expected.ProjectID = projectID
+	if actual.ID != expected.ID || actual.ProjectID != expected.ProjectID || actual.Name != expected.Name ||
+		actual.Amount != expected.Amount || actual.Currency != expected.Currency || actual.SourceID != expected.SourceID ||
+		actual.TransactionType != expected.TransactionType || actual.SplitMode != expected.SplitMode ||
+		!actual.OccurredAt.Equal(expected.OccurredAt) || actual.CreatedBy != expected.CreatedBy {
+		t.Errorf("expected transaction %+v got %+v", expected, actual)
+	}
+	// the targets keep their order, the remainder of a split goes to the first ones
+	if !slices.Equal(actual.TargetIDs, expected.TargetIDs) || !slices.Equal(targetWeights(&actual), targetWeights(&expected)) {
+		t.Errorf("expected targets %v with weights %v got %v with %v",
+			expected.TargetIDs, expected.Weights, actual.TargetIDs, actual.Weights)
+	}
+}
+
+func targetWeights(tx *storage.Transaction) []int {
+	weights := make([]int, 0, len(tx.TargetIDs))
+	for i := range tx.TargetIDs {
+		weights = append(weights, tx.Weight(i))
+	}
+	return weights
+}
+
+func randomUserID() string {
+	return "user-" + uuid.NewString()
+}
@@ -1,30 +1,123 @@
 package auth
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 )
 
+const defaultJWKSRefreshInterval = 15 * time.Minute
+
 type Config struct {
+	// Key is the shared secret of HS256 signed tokens, it is only used if no JWKS source is set
 	Key string
+	// JWKSURL is fetched on start and refreshed in the background, tokens may be signed with any key of the set
+	JWKSURL string
+	// JWKSFile is a local key set, it is used if no JWKSURL is set
+	JWKSFile string
+	// JWKSRefreshInterval is how often the key set is reloaded, defaults to 15 minutes
+	JWKSRefreshInterval time.Duration
+	// Issuer and Audience are checked if set
+	Issuer   string
+	Audience string
+	// ClockSkew is the tolerance when checking exp, iat and nbf
+	ClockSkew time.Duration
+	// LocalUserID is the subject used by the LocalAuthMiddleware when no user is given
+	LocalUserID string
 }
 
 type Client struct {
-	cfg Config
+	cfg  Config
+	keys keySource
+
+	// lastForcedRefresh limits how often a token with an unknown key id reloads the key set
+	refreshMu         sync.Mutex
+	lastForcedRefresh time.Time
 }
 
-func New(cfg Config) *Client {
-	return &Client{
-		cfg: cfg,
+// New creates a client for the configured key source, a JWKS url is fetched once so misconfigurations fail early.
+func New(ctx context.Context, cfg Config) (*Client, error) {
+	if cfg.JWKSRefreshInterval <= 0 {
+		cfg.JWKSRefreshInterval = defaultJWKSRefreshInterval
+	}
+	client := &Client{cfg: cfg}
+	var err error
+	switch {
+	case cfg.JWKSURL != "":
+		client.keys, err = newURLKeySource(ctx, cfg.JWKSURL, cfg.JWKSRefreshInterval)
+	case cfg.JWKSFile != "":
+		client.keys, err = newFileKeySource(cfg.JWKSFile, cfg.JWKSRefreshInterval)
+	case cfg.Key == "":
+		err = fmt.Errorf("no key or key set configured")
 	}
+	if err != nil {
+		return nil, fmt.Errorf("create auth client: %w", err)
+	}
+	return client, nil
 }
 
-func (c *Client) Validate(tokenString string) (jwt.Token, error) {
-	token, err := jwt.Parse([]byte(tokenString), jwt.WithKey(jwa.HS256, []byte(c.cfg.Key)))
+func (c *Client) Validate(ctx context.Context, tokenString string) (jwt.Token, error) {
+	options := []jwt.ParseOption{
+		jwt.WithValidate(true),
+		jwt.WithRequiredClaim(jwt.ExpirationKey),
+		jwt.WithAcceptableSkew(c.cfg.ClockSkew),
+	}
+	if c.cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(c.cfg.Issuer))
+	}
+	if c.cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(c.cfg.Audience))
+	}
+
+	if c.keys == nil {
+		options = append(options, jwt.WithKey(jwa.HS256, []byte(c.cfg.Key)))
+	} else {
+		set, err := c.keySet(ctx, tokenString)
+		if err != nil {
+			return nil, fmt.Errorf("validate token: %w", err)
+		}
+		options = append(options, jwt.WithKeySet(set, jws.WithInferAlgorithmFromKey(true)))
+	}
+
+	token, err := jwt.Parse([]byte(tokenString), options...)
 	if err != nil {
-		return nil, fmt.Errorf("validate token: %s", err)
+		return nil, fmt.Errorf("validate token: %w", err)
 	}
 	return token, nil
 }
+
+// keySet returns the key set to verify the token with.
+// If the token is signed with an unknown key the keys were probably rotated, so the set is reloaded once per refresh interval.
+func (c *Client) keySet(ctx context.Context, tokenString string) (jwk.Set, error) {
+	set, err := c.keys.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := jws.Parse([]byte(tokenString))
+	if err != nil || len(msg.Signatures()) == 0 {
+		// the error is reported when parsing the token
+		return set, nil //nolint:nilerr
+	}
+	kid := msg.Signatures()[0].ProtectedHeaders().KeyID()
+	if _, ok := set.LookupKeyID(kid); ok || kid == "" {
+		return set, nil
+	}
+
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if time.Since(c.lastForcedRefresh) < c.cfg.JWKSRefreshInterval {
+		return set, nil
+	}
+	c.lastForcedRefresh = time.Now()
+	set, err = c.keys.refresh(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("refresh key set: %w", err)
+	}
+	return set, nil
+}
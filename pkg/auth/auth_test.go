@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	testIssuer   = "https://auth.example.com"
+	testAudience = "authenticated"
+)
+
+type signingKey struct {
+	alg jwa.SignatureAlgorithm
+	key jwk.Key
+}
+
+func newRSAKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	raw, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %s", err)
+	}
+	return newSigningKey(t, raw, kid, jwa.RS256)
+}
+
+func newECKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ec key: %s", err)
+	}
+	return newSigningKey(t, raw, kid, jwa.ES256)
+}
+
+func newSigningKey(t *testing.T, raw interface{}, kid string, alg jwa.SignatureAlgorithm) signingKey {
+	t.Helper()
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatalf("create jwk: %s", err)
+	}
+	err = key.Set(jwk.KeyIDKey, kid)
+	if err != nil {
+		t.Fatalf("set kid: %s", err)
+	}
+	return signingKey{alg: alg, key: key}
+}
+
+func publicKeySet(t *testing.T, keys ...signingKey) []byte {
+	t.Helper()
+	set := jwk.NewSet()
+	for _, k := range keys {
+		public, err := k.key.PublicKey()
+		if err != nil {
+			t.Fatalf("public key: %s", err)
+		}
+		err = set.AddKey(public)
+		if err != nil {
+			t.Fatalf("add key: %s", err)
+		}
+	}
+	content, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshal key set: %s", err)
+	}
+	return content
+}
+
+// jwksServer serves the public keys of the keys it currently holds.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	content []byte
+}
+
+func newJWKSServer(t *testing.T, keys ...signingKey) *jwksServer {
+	t.Helper()
+	s := &jwksServer{content: publicKeySet(t, keys...)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(s.content)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) rotate(t *testing.T, keys ...signingKey) {
+	t.Helper()
+	content := publicKeySet(t, keys...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content = content
+}
+
+type claims struct {
+	issuer   string
+	audience string
+	expiry   time.Time
+}
+
+func validClaims() claims {
+	return claims{issuer: testIssuer, audience: testAudience, expiry: time.Now().Add(time.Hour)}
+}
+
+func sign(t *testing.T, key signingKey, c claims) string {
+	t.Helper()
+	builder := jwt.NewBuilder().Subject("user1").Issuer(c.issuer).Audience([]string{c.audience}).IssuedAt(time.Now().Add(-2 * time.Hour))
+	if !c.expiry.IsZero() {
+		builder = builder.Expiration(c.expiry)
+	}
+	token, err := builder.Build()
+	if err != nil {
+		t.Fatalf("build token: %s", err)
+	}
+	signed, err := jwt.Sign(token, jwt.WithKey(key.alg, key.key))
+	if err != nil {
+		t.Fatalf("sign token: %s", err)
+	}
+	return string(signed)
+}
+
+func TestValidate(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	ecKey := newECKey(t, "ec")
+	unknownKey := newRSAKey(t, "unknown")
+	server := newJWKSServer(t, rsaKey, ecKey)
+
+	client, err := New(context.Background(), Config{
+		JWKSURL: server.URL, Issuer: testIssuer, Audience: testAudience, ClockSkew: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	withClaims := func(modify func(c *claims)) claims {
+		c := validClaims()
+		modify(&c)
+		return c
+	}
+	tests := []struct {
+		name   string
+		key    signingKey
+		claims claims
+		valid  bool
+	}{
+		{name: "rs256", key: rsaKey, claims: validClaims(), valid: true},
+		{name: "es256", key: ecKey, claims: validClaims(), valid: true},
+		{name: "unknown key", key: unknownKey, claims: validClaims()},
+		{name: "wrong issuer", key: rsaKey, claims: withClaims(func(c *claims) { c.issuer = "https://other.example.com" })},
+		{name: "wrong audience", key: rsaKey, claims: withClaims(func(c *claims) { c.audience = "anon" })},
+		{name: "expired within skew", key: rsaKey, claims: withClaims(func(c *claims) { c.expiry = time.Now().Add(-30 * time.Second) }), valid: true},
+		{name: "expired", key: rsaKey, claims: withClaims(func(c *claims) { c.expiry = time.Now().Add(-2 * time.Minute) })},
+		{name: "without expiry", key: ecKey, claims: withClaims(func(c *claims) { c.expiry = time.Time{} })},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := client.Validate(context.Background(), sign(t, test.key, test.claims))
+			if test.valid && err != nil {
+				t.Fatalf("expected valid token got %s", err)
+			}
+			if !test.valid && err == nil {
+				t.Fatalf("expected invalid token")
+			}
+			if test.valid && token.Subject() != "user1" {
+				t.Errorf("expected subject user1 got %s", token.Subject())
+			}
+		})
+	}
+}
+
+func TestValidateKeyRotation(t *testing.T) {
+	oldKey := newRSAKey(t, "old")
+	newKey := newECKey(t, "new")
+	server := newJWKSServer(t, oldKey)
+
+	client, err := New(context.Background(), Config{JWKSURL: server.URL})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+	_, err = client.Validate(context.Background(), sign(t, oldKey, validClaims()))
+	if err != nil {
+		t.Fatalf("expected token of old key to be valid got %s", err)
+	}
+
+	server.rotate(t, newKey)
+	// the unknown key id triggers a refresh
+	_, err = client.Validate(context.Background(), sign(t, newKey, validClaims()))
+	if err != nil {
+		t.Fatalf("expected token of new key to be valid got %s", err)
+	}
+	_, err = client.Validate(context.Background(), sign(t, oldKey, validClaims()))
+	if err == nil {
+		t.Fatalf("expected token of removed key to be invalid")
+	}
+}
+
+func TestValidateJWKSFile(t *testing.T) {
+	key := newECKey(t, "file")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	err := os.WriteFile(path, publicKeySet(t, key), 0o600)
+	if err != nil {
+		t.Fatalf("write key set: %s", err)
+	}
+
+	client, err := New(context.Background(), Config{JWKSFile: path, Issuer: testIssuer})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+	_, err = client.Validate(context.Background(), sign(t, key, validClaims()))
+	if err != nil {
+		t.Errorf("expected valid token got %s", err)
+	}
+
+	_, err = New(context.Background(), Config{JWKSFile: filepath.Join(t.TempDir(), "missing.json")})
+	if err == nil {
+		t.Errorf("expected error for missing key set")
+	}
+}
+
+func TestValidateSharedSecret(t *testing.T) {
+	client, err := New(context.Background(), Config{Key: "secret"})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+	secret, err := jwk.FromRaw([]byte("secret"))
+	if err != nil {
+		t.Fatalf("create jwk: %s", err)
+	}
+	_, err = client.Validate(context.Background(), sign(t, signingKey{alg: jwa.HS256, key: secret}, validClaims()))
+	if err != nil {
+		t.Errorf("expected valid token got %s", err)
+	}
+
+	// once a key set is configured tokens signed with a shared secret are rejected
+	rsaKey := newRSAKey(t, "rsa")
+	server := newJWKSServer(t, rsaKey)
+	jwksClient, err := New(context.Background(), Config{JWKSURL: server.URL})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+	_, err = jwksClient.Validate(context.Background(), sign(t, signingKey{alg: jwa.HS256, key: secret}, validClaims()))
+	if err == nil {
+		t.Errorf("expected hs256 token to be rejected by key set")
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// keySource provides the keys tokens are verified with.
+type keySource interface {
+	// get returns the cached keys
+	get(ctx context.Context) (jwk.Set, error)
+	// refresh reloads the keys right away
+	refresh(ctx context.Context) (jwk.Set, error)
+}
+
+// urlKeySource caches a remote key set, it is refreshed in the background until the context passed to New is done.
+type urlKeySource struct {
+	cache *jwk.Cache
+	url   string
+}
+
+func newURLKeySource(ctx context.Context, url string, refreshInterval time.Duration) (*urlKeySource, error) {
+	cache := jwk.NewCache(ctx)
+	err := cache.Register(url, jwk.WithRefreshInterval(refreshInterval))
+	if err != nil {
+		return nil, fmt.Errorf("register key set %s: %w", url, err)
+	}
+	_, err = cache.Refresh(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("fetch key set %s: %w", url, err)
+	}
+	return &urlKeySource{cache: cache, url: url}, nil
+}
+
+func (s *urlKeySource) get(ctx context.Context) (jwk.Set, error) {
+	return s.cache.Get(ctx, s.url)
+}
+
+func (s *urlKeySource) refresh(ctx context.Context) (jwk.Set, error) {
+	return s.cache.Refresh(ctx, s.url)
+}
+
+// fileKeySource reads a local key set, it is read again when it is older than the refresh interval.
+// If reading fails the previous keys are kept.
+type fileKeySource struct {
+	path            string
+	refreshInterval time.Duration
+
+	mu       sync.Mutex
+	set      jwk.Set
+	loadedAt time.Time
+}
+
+func newFileKeySource(path string, refreshInterval time.Duration) (*fileKeySource, error) {
+	s := &fileKeySource{path: path, refreshInterval: refreshInterval}
+	_, err := s.refresh(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileKeySource) get(ctx context.Context) (jwk.Set, error) {
+	s.mu.Lock()
+	set, stale := s.set, time.Since(s.loadedAt) > s.refreshInterval
+	s.mu.Unlock()
+	if !stale {
+		return set, nil
+	}
+	newSet, err := s.refresh(ctx)
+	if err != nil {
+		return set, nil //nolint:nilerr // a broken file must not lock out everyone
+	}
+	return newSet, nil
+}
+
+func (s *fileKeySource) refresh(_ context.Context) (jwk.Set, error) {
+	set, err := jwk.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("read key set %s: %w", s.path, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set = set
+	s.loadedAt = time.Now()
+	return set, nil
+}
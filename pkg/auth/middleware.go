@@ -13,8 +13,11 @@ const AuthorizationHeader = "Authorization"
 
 const BearerPrefix = "Bearer "
 
-func AuthMiddleware(cfg Config) gin.HandlerFunc {
-	authValidator := New(cfg)
+// LocalUserHeader can be used to act as a different user when running locally without token validation
+const LocalUserHeader = "X-User-ID"
+
+// AuthMiddleware validates the bearer token with the client and adds its subject as user id to the context.
+func AuthMiddleware(authValidator *Client) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authToken := ctx.Request.Header.Get(AuthorizationHeader)
 		if authToken == "" {
@@ -22,7 +25,7 @@ func AuthMiddleware(cfg Config) gin.HandlerFunc {
 			return
 		}
 		authTokenStripped, _ := strings.CutPrefix(authToken, BearerPrefix)
-		token, err := authValidator.Validate(authTokenStripped)
+		token, err := authValidator.Validate(ctx, authTokenStripped)
 		if err != nil {
 			logger.Debug(ctx).Err(err).Msg("validation of bearer token failed")
 			ctx.AbortWithStatus(http.StatusForbidden)
@@ -34,3 +37,17 @@ func AuthMiddleware(cfg Config) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// LocalAuthMiddleware trusts the LocalUserHeader without any validation and falls back to cfg.LocalUserID.
+// It must only be used for local development.
+func LocalAuthMiddleware(cfg Config) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sub := ctx.Request.Header.Get(LocalUserHeader)
+		if sub == "" {
+			sub = cfg.LocalUserID
+		}
+		requestCtx := contextutil.AddUserIDToCtx(ctx.Request.Context(), sub)
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+		ctx.Next()
+	}
+}